@@ -0,0 +1,49 @@
+-- every reading is kept, the latest one of a zone in an emission factor is its current carbon intensity
+CREATE TABLE IF NOT EXISTS carbon_intensities (
+    id BIGSERIAL PRIMARY KEY,
+    zone TEXT NOT NULL,
+    carbon_intensity DOUBLE PRECISION NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    measured_at TIMESTAMPTZ,
+    fetched_at TIMESTAMPTZ NOT NULL,
+    is_estimated BOOLEAN NOT NULL DEFAULT FALSE,
+    emission_type TEXT NOT NULL DEFAULT 'average',     -- average or marginal
+    emission_scope TEXT NOT NULL DEFAULT 'lifecycle',  -- lifecycle or direct
+    -- a source is fetched more often than it measures, the same measurement is stored once.
+    -- Readings without measurement time are one measurement too, otherwise every fetch added a row.
+    UNIQUE NULLS NOT DISTINCT (zone, source, emission_type, emission_scope, measured_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_carbon_intensities_zone_fetched ON carbon_intensities (zone, emission_type, emission_scope, fetched_at DESC);
+
+-- every power breakdown is kept, the latest one of a zone is returned with its carbon intensity
+CREATE TABLE IF NOT EXISTS carbon_power_breakdowns (
+    id BIGSERIAL PRIMARY KEY,
+    zone TEXT NOT NULL,
+    renewable_percentage DOUBLE PRECISION NOT NULL,
+    fossil_free_percentage DOUBLE PRECISION NOT NULL,
+    production_mix JSONB NOT NULL DEFAULT '{}',  -- production in MW by type
+    source TEXT NOT NULL DEFAULT '',
+    measured_at TIMESTAMPTZ,
+    fetched_at TIMESTAMPTZ NOT NULL,
+    UNIQUE NULLS NOT DISTINCT (zone, source, measured_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_carbon_power_breakdowns_zone_fetched ON carbon_power_breakdowns (zone, fetched_at DESC);
+
+CREATE TABLE IF NOT EXISTS carbon_zones (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}'  -- country, continent, regions, coordinates, neighbours and aliases
+);
+
+-- zones changed with the admin API, they take precedence over the environment
+CREATE TABLE IF NOT EXISTS carbon_zone_configs (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    token_hash TEXT NOT NULL DEFAULT '',    -- SHA-256 of the token, the token is never stored in plain text
+    sealed_token TEXT NOT NULL DEFAULT '',  -- token encrypted with ZONE_TOKEN_KEY, sent to the carbon source
+    removed BOOLEAN NOT NULL DEFAULT FALSE,
+    override_intensity DOUBLE PRECISION,
+    override_set_at TIMESTAMPTZ
+);
@@ -7,13 +7,38 @@ CREATE TABLE jobs (
     job_name TEXT NOT NULL,
     image_name TEXT NOT NULL,
     image_version TEXT NOT NULL,
+    image_digest TEXT DEFAULT '',
+    image_signature TEXT DEFAULT '',
     adjustment_parameters JSONB NOT NULL,
     creation_zone TEXT NOT NULL,
+    input_files JSONB NOT NULL DEFAULT '[]',
+    optimisation_target TEXT NOT NULL DEFAULT 'carbon-intensity',
+    allowed_zones JSONB NOT NULL DEFAULT '[]',
+    allowed_regions JSONB NOT NULL DEFAULT '[]',
     worker_id TEXT,
     compute_zone TEXT,
     carbon_intensity INTEGER DEFAULT -1,
     carbon_savings INTEGER DEFAULT -1,
+    carbon_data_age INTEGER DEFAULT -1,
     result TEXT DEFAULT '',
     error_message TEXT DEFAULT '',
+    result_id TEXT DEFAULT '',
+    progress_percent INTEGER DEFAULT 0,
+    progress_message TEXT DEFAULT '',
+    energy_wh DOUBLE PRECISION DEFAULT -1,
+    energy_method TEXT DEFAULT '',
+    carbon_emitted DOUBLE PRECISION DEFAULT -1,
+    carbon_saved DOUBLE PRECISION DEFAULT -1,
     job_status TEXT DEFAULT 'queued'
 );
+
+-- worker-gateway looks up the scheduled jobs of a single worker
+CREATE INDEX idx_jobs_worker_status ON jobs (worker_id, job_status);
+
+-- uploaded input files, kept out of the jobs row so job lists stay small
+CREATE TABLE job_input_files (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    content BYTEA NOT NULL,
+    PRIMARY KEY (job_id, name)
+);
@@ -1,5 +1,6 @@
 CREATE TABLE IF NOT EXISTS workers (
     id TEXT PRIMARY KEY,
     status TEXT NOT NULL,
-    zone TEXT NOT NULL
+    zone TEXT NOT NULL,
+    cached_images JSONB NOT NULL DEFAULT '[]'
 );
\ No newline at end of file
@@ -28,7 +28,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		tokenStr = strings.TrimPrefix(tokenStr, "Bearer ")
 
-		token, err := jwt.Parse(tokenStr, JWKS.Keyfunc) // main verification happens here
+		token, err := jwt.Parse(tokenStr, keyFunc) // main verification happens here
 		if err != nil || !token.Valid {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
@@ -43,6 +43,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			ctx := context.WithValue(r.Context(), "user", claims["sub"])                 // adds user to ctx
 			ctx = context.WithValue(ctx, "role", role)                                   // adds role to ctx
 			ctx = context.WithValue(ctx, "Authorization", r.Header.Get("Authorization")) // adds JWT to ctx
+			ctx = context.WithValue(ctx, "claims", claims)                               // adds all claims to ctx, e.g. the worker ID
 
 			exp, ok := claims["exp"].(float64)
 			if !ok || int64(exp) < time.Now().Unix() {
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Claims of the worker scoped tokens issued by the worker-gateway
+const (
+	ClaimWorkerID = "worker_id"
+	RoleWorker    = "worker"
+)
+
+// WorkerTokenRefreshWindow is how long after its expiry a worker token can still be renewed
+const WorkerTokenRefreshWindow = 7 * 24 * time.Hour
+
+var ErrWorkerTokensDisabled = errors.New("worker tokens are not enabled")
+
+var ErrWorkerTokenTooOld = errors.New("worker token expired too long ago to be renewed")
+
+// workerKey signs and verifies worker tokens, only the worker-gateway sets it.
+// Services without it reject worker tokens, they only accept tokens from the JWKS.
+var workerKey []byte
+
+func InitWorkerKey(key []byte) error {
+	if len(key) < 32 {
+		return errors.New("worker token key must be at least 32 bytes")
+	}
+	workerKey = key
+	return nil
+}
+
+// IssueWorkerToken creates a token bound to the worker ID, extra claims are added as they are.
+func IssueWorkerToken(workerID string, extra map[string]any, expiresAt time.Time) (string, error) {
+	if workerKey == nil {
+		return "", ErrWorkerTokensDisabled
+	}
+
+	claims := jwt.MapClaims{}
+	for key, value := range extra {
+		claims[key] = value
+	}
+	claims["sub"] = workerID
+	claims["role"] = RoleWorker
+	claims[ClaimWorkerID] = workerID
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = expiresAt.Unix()
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(workerKey)
+}
+
+// WorkerID returns the worker the request was authenticated as, if it used a worker token.
+func WorkerID(ctx context.Context) (string, bool) {
+	claims, ok := ctx.Value("claims").(jwt.MapClaims)
+	if !ok {
+		return "", false
+	}
+	workerID, ok := claims[ClaimWorkerID].(string)
+	return workerID, ok && workerID != ""
+}
+
+// Claim returns a string claim of the token the request was authenticated with.
+func Claim(ctx context.Context, name string) string {
+	claims, ok := ctx.Value("claims").(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	value, _ := claims[name].(string)
+	return value
+}
+
+// keyFunc verifies worker tokens with the worker key and all other tokens with the JWKS.
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if workerKey == nil {
+			return nil, ErrWorkerTokensDisabled
+		}
+		return workerKey, nil
+	}
+	if JWKS == nil {
+		return nil, errors.New("JWKS not initialized")
+	}
+	return JWKS.Keyfunc(token)
+}
+
+// VerifyWorkerToken checks the signature of a worker token and returns its claims, also if it expired
+// less than WorkerTokenRefreshWindow ago. It is only meant for renewing the token of a worker, all other
+// requests go through AuthMiddleware.
+func VerifyWorkerToken(tokenStr string) (map[string]any, error) {
+	if workerKey == nil {
+		return nil, ErrWorkerTokensDisabled
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithoutClaimsValidation())
+	if _, err := parser.ParseWithClaims(tokenStr, claims, func(*jwt.Token) (interface{}, error) {
+		return workerKey, nil
+	}); err != nil {
+		return nil, err
+	}
+	if workerID, ok := claims[ClaimWorkerID].(string); !ok || workerID == "" {
+		return nil, errors.New("not a worker token")
+	}
+	// a leaked token must not be renewable forever, only shortly after it expired
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("worker token without expiry")
+	}
+	if time.Since(time.Unix(int64(exp), 0)) > WorkerTokenRefreshWindow {
+		return nil, ErrWorkerTokenTooOld
+	}
+	return claims, nil
+}
+
+// UnverifiedClaims returns the claims of a token without verifying it, e.g. to read the
+// expiry of the provider token a worker token wraps.
+func UnverifiedClaims(tokenStr string) (map[string]any, bool) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenStr, claims); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// ExpiresAt returns the expiry of a token without verifying it.
+func ExpiresAt(tokenStr string) (time.Time, bool) {
+	claims, ok := UnverifiedClaims(tokenStr)
+	if !ok {
+		return time.Time{}, false
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
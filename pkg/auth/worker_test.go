@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serveWithToken(token string) (*httptest.ResponseRecorder, string) {
+	var workerID string
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		workerID, _ = WorkerID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr, workerID
+}
+
+func TestWorkerToken(t *testing.T) {
+	if err := InitWorkerKey([]byte("0123456789abcdef0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { workerKey = nil }()
+
+	token, err := IssueWorkerToken("worker-1", map[string]any{"provider_token": "abc"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, ok := ExpiresAt(token); !ok || time.Until(exp) < 59*time.Minute {
+		t.Errorf("expected expiry in one hour, got %v", exp)
+	}
+
+	rr, workerID := serveWithToken(token)
+	if rr.Code != http.StatusOK || workerID != "worker-1" {
+		t.Errorf("expected worker-1 to be authenticated, got %d %q", rr.Code, workerID)
+	}
+
+	expired, _ := IssueWorkerToken("worker-1", nil, time.Now().Add(-time.Hour))
+	if rr, _ := serveWithToken(expired); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected expired token to be rejected, got %d", rr.Code)
+	}
+
+	// expired tokens can still be renewed
+	if claims, err := VerifyWorkerToken(expired); err != nil || claims[ClaimWorkerID] != "worker-1" {
+		t.Errorf("expected expired worker token to verify for renewal, got %v %v", claims, err)
+	}
+
+	// tokens that expired long ago cannot be renewed anymore
+	tooOld, _ := IssueWorkerToken("worker-1", nil, time.Now().Add(-WorkerTokenRefreshWindow-time.Hour))
+	if _, err := VerifyWorkerToken(tooOld); !errors.Is(err, ErrWorkerTokenTooOld) {
+		t.Errorf("expected token expired before the refresh window to be rejected, got %v", err)
+	}
+
+	// token signed with a different key
+	workerKey = []byte("fedcba9876543210fedcba9876543210")
+	if rr, _ := serveWithToken(token); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected forged token to be rejected, got %d", rr.Code)
+	}
+	if _, err := VerifyWorkerToken(token); err == nil {
+		t.Error("expected forged token not to verify for renewal")
+	}
+
+	// services without worker key do not accept worker tokens
+	workerKey = nil
+	if rr, _ := serveWithToken(token); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected worker token to be rejected without worker key, got %d", rr.Code)
+	}
+}
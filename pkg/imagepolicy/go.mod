@@ -0,0 +1,3 @@
+module github.com/informatik-mannheim/cmg-ss2025/pkg/imagepolicy
+
+go 1.24.1
@@ -0,0 +1,148 @@
+// Package imagepolicy restricts the container images jobs may run. The job service checks the
+// central policy when a job is created, the worker daemon the policy of its provider before it runs a job.
+package imagepolicy
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrNotAllowed       = errors.New("image is not allowed by the image policy")
+	ErrSignatureInvalid = errors.New("image signature is missing or invalid")
+)
+
+// Image is the image of a job as far as the policy is concerned
+type Image struct {
+	Name      string // e.g. "ghcr.io/org/app" or "alpine"
+	Digest    string // "sha256:...", empty if the image is not pinned
+	Signature string // base64 encoded signature of "<registry>/<repository>@<digest>"
+}
+
+// Policy is loaded from a JSON file, the job service and the worker daemon understand the same file.
+type Policy struct {
+	AllowedRegistries   []string `json:"allowed_registries"`   // e.g. "ghcr.io", all registries if empty
+	AllowedRepositories []string `json:"allowed_repositories"` // patterns like "ghcr.io/org/*", all repositories if empty
+	RequireDigest       bool     `json:"require_digest"`       // images must be pinned by digest
+	PublicKeys          []string `json:"public_keys"`          // PEM files, if set images must be signed with one of the keys
+
+	keys []crypto.PublicKey
+}
+
+// Load reads the policy from a JSON file.
+// Relative public key paths are resolved against the directory of the policy file.
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for _, keyFile := range policy.PublicKeys {
+		if !filepath.IsAbs(keyFile) {
+			keyFile = filepath.Join(filepath.Dir(file), keyFile)
+		}
+		keyPEM, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parsePublicKey(keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyFile, err)
+		}
+		policy.keys = append(policy.keys, key)
+	}
+	return &policy, nil
+}
+
+// Check returns ErrNotAllowed if the image does not comply with the policy,
+// ErrSignatureInvalid if a required signature is missing or does not verify.
+// Signatures are verified cosign-style: a base64 encoded signature of the reference
+// "<registry>/<repository>@<digest>", as created by `cosign sign-blob`.
+func (p *Policy) Check(image Image) error {
+	registry, repository := SplitImageName(image.Name)
+	if len(p.AllowedRegistries) > 0 && !slices.Contains(p.AllowedRegistries, registry) {
+		return fmt.Errorf("%w: registry %s", ErrNotAllowed, registry)
+	}
+	if len(p.AllowedRepositories) > 0 && !slices.ContainsFunc(p.AllowedRepositories, func(pattern string) bool {
+		matched, _ := path.Match(pattern, registry+"/"+repository)
+		return matched
+	}) {
+		return fmt.Errorf("%w: repository %s/%s", ErrNotAllowed, registry, repository)
+	}
+	if (p.RequireDigest || len(p.keys) > 0) && image.Digest == "" {
+		return fmt.Errorf("%w: image must be pinned by digest", ErrNotAllowed)
+	}
+	if len(p.keys) == 0 {
+		return nil
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(image.Signature)
+	if err != nil || len(signature) == 0 {
+		return ErrSignatureInvalid
+	}
+	payload := []byte(registry + "/" + repository + "@" + image.Digest)
+	for _, key := range p.keys {
+		if verifySignature(key, payload, signature) {
+			return nil
+		}
+	}
+	return ErrSignatureInvalid
+}
+
+// SplitImageName returns the registry and the repository of an image name the way docker resolves it,
+// e.g. "alpine" is "docker.io" and "library/alpine".
+func SplitImageName(name string) (string, string) {
+	registry, repository, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(registry, ".:") && registry != "localhost") {
+		registry, repository = "docker.io", name
+	}
+	if registry == "docker.io" && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	return registry, repository
+}
+
+func parsePublicKey(keyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+func verifySignature(key crypto.PublicKey, payload []byte, signature []byte) bool {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(payload)
+		return ecdsa.VerifyASN1(key, hash[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, signature)
+	default:
+		return false
+	}
+}
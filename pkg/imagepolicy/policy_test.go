@@ -0,0 +1,126 @@
+package imagepolicy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDigest = "sha256:2d1b4e8f9a0c3b5d7e6f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d"
+
+// writePolicy writes the policy and the public key of a new signing key, like a provider would
+func writePolicy(t *testing.T, policy string) (string, *ecdsa.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "cosign.pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644)
+	file := filepath.Join(dir, "policy.json")
+	os.WriteFile(file, []byte(policy), 0o644)
+	return file, key
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, reference string) string {
+	t.Helper()
+	hash := sha256.Sum256([]byte(reference))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestPolicy_Check(t *testing.T) {
+	file, _ := writePolicy(t, `{
+		"allowed_registries": ["ghcr.io", "docker.io"],
+		"allowed_repositories": ["ghcr.io/org/*", "docker.io/library/*"],
+		"require_digest": true
+	}`)
+	policy, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		image   Image
+		wantErr error
+	}{
+		{"allowed repository", Image{Name: "ghcr.io/org/app", Digest: testDigest}, nil},
+		{"docker hub official image", Image{Name: "alpine", Digest: testDigest}, nil},
+		{"registry not allowed", Image{Name: "quay.io/org/app", Digest: testDigest}, ErrNotAllowed},
+		{"repository not allowed", Image{Name: "ghcr.io/other/app", Digest: testDigest}, ErrNotAllowed},
+		{"docker hub user image", Image{Name: "someone/app", Digest: testDigest}, ErrNotAllowed},
+		{"missing digest", Image{Name: "ghcr.io/org/app"}, ErrNotAllowed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := policy.Check(tc.image); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPolicy_Signature(t *testing.T) {
+	file, key := writePolicy(t, `{"public_keys": ["cosign.pub"]}`)
+	policy, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		name    string
+		image   Image
+		wantErr error
+	}{
+		{"signed", Image{Name: "ghcr.io/org/app", Digest: testDigest, Signature: sign(t, key, "ghcr.io/org/app@"+testDigest)}, nil},
+		{"signed with resolved name", Image{Name: "alpine", Digest: testDigest, Signature: sign(t, key, "docker.io/library/alpine@"+testDigest)}, nil},
+		{"unsigned", Image{Name: "ghcr.io/org/app", Digest: testDigest}, ErrSignatureInvalid},
+		{"signed by unknown key", Image{Name: "ghcr.io/org/app", Digest: testDigest, Signature: sign(t, otherKey, "ghcr.io/org/app@"+testDigest)}, ErrSignatureInvalid},
+		{"signature of another image", Image{Name: "ghcr.io/org/app", Digest: testDigest, Signature: sign(t, key, "ghcr.io/org/other@"+testDigest)}, ErrSignatureInvalid},
+		{"signature without digest", Image{Name: "ghcr.io/org/app", Signature: sign(t, key, "ghcr.io/org/app@")}, ErrNotAllowed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := policy.Check(tc.image); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSplitImageName(t *testing.T) {
+	tests := []struct {
+		name, registry, repository string
+	}{
+		{"alpine", "docker.io", "library/alpine"},
+		{"someone/app", "docker.io", "someone/app"},
+		{"ghcr.io/org/app", "ghcr.io", "org/app"},
+		{"localhost:5000/app", "localhost:5000", "app"},
+		{"localhost/app", "localhost", "app"},
+	}
+	for _, tc := range tests {
+		registry, repository := SplitImageName(tc.name)
+		if registry != tc.registry || repository != tc.repository {
+			t.Errorf("%s: expected %s %s, got %s %s", tc.name, tc.registry, tc.repository, registry, repository)
+		}
+	}
+}
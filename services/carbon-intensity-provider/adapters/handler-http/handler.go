@@ -2,34 +2,66 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
 	"net/http"
+	"slices"
+	"strings"
 )
 
 // Handler struct connects HTTP routes to the service logic.
 type Handler struct {
-	Service ports.CarbonIntensityProvider
+	Service   ports.CarbonIntensityProvider
+	Admin     ports.ZoneAdmin
+	Simulator ports.Simulator
 }
 
 // NewHandler creates and returns a configured router.
-func NewHandler(service ports.CarbonIntensityProvider) *mux.Router {
+func NewHandler(service ports.CarbonIntensityProvider, admin ports.ZoneAdmin, simulator ports.Simulator) *mux.Router {
 	h := &Handler{
-		Service: service,
+		Service:   service,
+		Admin:     admin,
+		Simulator: simulator,
 	}
 
 	r := mux.NewRouter()
+	r.HandleFunc("/carbon-intensity", h.GetCarbonIntensities).Methods("GET")
 	r.HandleFunc("/carbon-intensity/zones", h.GetAvailableZones).Methods("GET")
+	r.HandleFunc("/carbon-intensity/health", h.GetFetchHealth).Methods("GET")
 	r.HandleFunc("/carbon-intensity/{zone}", h.GetCarbonIntensityByZone).Methods("GET")
 
+	r.HandleFunc("/admin/zones", adminOnly(h.GetZoneConfigs)).Methods("GET")
+	r.HandleFunc("/admin/zones/{zone}", adminOnly(h.AddZone)).Methods("PUT")
+	r.HandleFunc("/admin/zones/{zone}", adminOnly(h.RemoveZone)).Methods("DELETE")
+	r.HandleFunc("/admin/zones/{zone}/token", adminOnly(h.RotateToken)).Methods("PUT")
+	r.HandleFunc("/admin/zones/{zone}/override", adminOnly(h.SetOverride)).Methods("PUT")
+	r.HandleFunc("/admin/zones/{zone}/override", adminOnly(h.RemoveOverride)).Methods("DELETE")
+	r.HandleFunc("/admin/simulation", adminOnly(h.GetSimulation)).Methods("GET")
+	r.HandleFunc("/admin/simulation", adminOnly(h.SetScenario)).Methods("PUT")
+
 	return r
 }
 
-// GetCarbonIntensityByZone handles GET /carbon-intensity/{zone}
+// emissionFactor reads ?type=average|marginal and ?scope=direct|lifecycle, average lifecycle if not given
+func emissionFactor(w http.ResponseWriter, r *http.Request) (ports.EmissionFactor, bool) {
+	factor, err := ports.ParseEmissionFactor(r.URL.Query().Get("type"), r.URL.Query().Get("scope"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return ports.EmissionFactor{}, false
+	}
+	return factor, true
+}
+
+// GetCarbonIntensityByZone handles GET /carbon-intensity/{zone}?type=...&scope=...
 func (h *Handler) GetCarbonIntensityByZone(w http.ResponseWriter, r *http.Request) {
 	zone := mux.Vars(r)["zone"]
+	factor, ok := emissionFactor(w, r)
+	if !ok {
+		return
+	}
 
-	data, err := h.Service.GetCarbonIntensityByZone(zone, r.Context())
+	data, err := h.Service.GetCarbonIntensityByZone(zone, factor, r.Context())
 	if err != nil {
 		http.Error(w, "Zone not found", http.StatusNotFound)
 		return
@@ -39,6 +71,36 @@ func (h *Handler) GetCarbonIntensityByZone(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(data)
 }
 
+// GetCarbonIntensities handles GET /carbon-intensity?zones=DE,FR&type=...&scope=...
+// Zones without data are left out of the response, so the caller can work with the remaining ones.
+func (h *Handler) GetCarbonIntensities(w http.ResponseWriter, r *http.Request) {
+	var zones []string
+	for _, zone := range strings.Split(r.URL.Query().Get("zones"), ",") {
+		zone = strings.TrimSpace(zone)
+		if zone != "" && !slices.Contains(zones, zone) {
+			zones = append(zones, zone)
+		}
+	}
+	if len(zones) == 0 {
+		http.Error(w, "zones required", http.StatusBadRequest)
+		return
+	}
+
+	factor, ok := emissionFactor(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.Service.GetCarbonIntensities(zones, factor, r.Context())
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
 // GetAvailableZones handles GET /carbon-intensity/zones
 func (h *Handler) GetAvailableZones(w http.ResponseWriter, r *http.Request) {
 	zones := h.Service.GetAvailableZones(r.Context())
@@ -49,3 +111,155 @@ func (h *Handler) GetAvailableZones(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetFetchHealth handles GET /carbon-intensity/health
+func (h *Handler) GetFetchHealth(w http.ResponseWriter, r *http.Request) {
+	response := ports.FetchHealthResponse{
+		Zones: h.Service.GetFetchHealth(r.Context()),
+	}
+	if response.Zones == nil {
+		response.Zones = []ports.ZoneFetchHealth{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// adminOnly rejects requests whose token does not have the admin role
+func adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value("role") != ports.RoleAdmin {
+			http.Error(w, "Admin role required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeAdminError maps the errors of the admin API to status codes
+func writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ports.ErrInvalidZoneConfig), errors.Is(err, ports.ErrInvalidScenario):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ports.ErrZoneNotFound):
+		http.Error(w, "Zone not found", http.StatusNotFound)
+	case errors.Is(err, ports.ErrSimulationDisabled):
+		http.Error(w, "Simulation source not configured", http.StatusNotFound)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetZoneConfigs handles GET /admin/zones
+func (h *Handler) GetZoneConfigs(w http.ResponseWriter, r *http.Request) {
+	configs, err := h.Admin.GetZoneConfigs(r.Context())
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	response := ports.AdminZonesResponse{Zones: make([]ports.AdminZone, 0, len(configs))}
+	for _, config := range configs {
+		response.Zones = append(response.Zones, ports.AdminZone{
+			Code:     config.Code,
+			Name:     config.Name,
+			HasToken: config.TokenHash != "",
+			Removed:  config.Removed,
+			Override: config.Override,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AddZone handles PUT /admin/zones/{zone}
+func (h *Handler) AddZone(w http.ResponseWriter, r *http.Request) {
+	var req ports.AddZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zone := ports.Zone{Code: mux.Vars(r)["zone"], Name: req.Name}
+	if err := h.Admin.AddZone(zone, req.Token, r.Context()); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveZone handles DELETE /admin/zones/{zone}
+func (h *Handler) RemoveZone(w http.ResponseWriter, r *http.Request) {
+	if err := h.Admin.RemoveZone(mux.Vars(r)["zone"], r.Context()); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateToken handles PUT /admin/zones/{zone}/token
+func (h *Handler) RotateToken(w http.ResponseWriter, r *http.Request) {
+	var req ports.RotateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Admin.RotateToken(mux.Vars(r)["zone"], req.Token, r.Context()); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetOverride handles PUT /admin/zones/{zone}/override
+func (h *Handler) SetOverride(w http.ResponseWriter, r *http.Request) {
+	var req ports.OverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CarbonIntensity == nil {
+		http.Error(w, "carbonIntensity required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Admin.SetOverride(mux.Vars(r)["zone"], req.CarbonIntensity, r.Context()); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveOverride handles DELETE /admin/zones/{zone}/override
+func (h *Handler) RemoveOverride(w http.ResponseWriter, r *http.Request) {
+	if err := h.Admin.SetOverride(mux.Vars(r)["zone"], nil, r.Context()); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSimulation handles GET /admin/simulation
+func (h *Handler) GetSimulation(w http.ResponseWriter, r *http.Request) {
+	status, err := h.Simulator.GetSimulation(r.Context())
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// SetScenario handles PUT /admin/simulation
+func (h *Handler) SetScenario(w http.ResponseWriter, r *http.Request) {
+	var scenario ports.Scenario
+	if err := json.NewDecoder(r.Body).Decode(&scenario); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Simulator.SetScenario(scenario, r.Context()); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
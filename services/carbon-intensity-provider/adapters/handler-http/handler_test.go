@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	handler "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/handler-http"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// FakeAdmin implements ports.ZoneAdmin for the zone DE
+type FakeAdmin struct {
+	tokens map[string]string
+}
+
+func (f *FakeAdmin) GetZoneConfigs(ctx context.Context) ([]ports.ZoneConfig, error) {
+	return []ports.ZoneConfig{{Code: "DE", Name: "Germany", TokenHash: f.tokens["DE"]}}, nil
+}
+
+func (f *FakeAdmin) AddZone(zone ports.Zone, token string, ctx context.Context) error {
+	return nil
+}
+
+func (f *FakeAdmin) RemoveZone(code string, ctx context.Context) error {
+	if code != "DE" {
+		return ports.ErrZoneNotFound
+	}
+	return nil
+}
+
+func (f *FakeAdmin) RotateToken(code string, token string, ctx context.Context) error {
+	if token == "" {
+		return ports.ErrInvalidZoneConfig
+	}
+	if code != "DE" {
+		return ports.ErrZoneNotFound
+	}
+	f.tokens[code] = token
+	return nil
+}
+
+func (f *FakeAdmin) SetOverride(code string, intensity *float64, ctx context.Context) error {
+	return nil
+}
+
+// FakeSimulator implements ports.Simulator without a simulation source
+type FakeSimulator struct{}
+
+func (f *FakeSimulator) SetScenario(scenario ports.Scenario, ctx context.Context) error {
+	if scenario.Speed < 0 {
+		return ports.ErrInvalidScenario
+	}
+	return nil
+}
+
+func (f *FakeSimulator) GetSimulation(ctx context.Context) (ports.SimulationStatus, error) {
+	return ports.SimulationStatus{}, ports.ErrSimulationDisabled
+}
+
+func adminRequest(method, target, body, role string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	return req.WithContext(context.WithValue(req.Context(), "role", role))
+}
+
+func TestAdminRoutes(t *testing.T) {
+	admin := &FakeAdmin{tokens: map[string]string{}}
+	router := handler.NewHandler(nil, admin, &FakeSimulator{})
+
+	tests := []struct {
+		name     string
+		request  *http.Request
+		expected int
+	}{
+		{"consumer role", adminRequest("PUT", "/admin/zones/DE/token", `{"token":"new"}`, "consumer"), http.StatusForbidden},
+		{"no role", httptest.NewRequest("GET", "/admin/zones", nil), http.StatusForbidden},
+		{"rotate token", adminRequest("PUT", "/admin/zones/DE/token", `{"token":"new"}`, "admin"), http.StatusNoContent},
+		{"empty token", adminRequest("PUT", "/admin/zones/DE/token", `{"token":""}`, "admin"), http.StatusBadRequest},
+		{"unknown zone", adminRequest("DELETE", "/admin/zones/XX", "", "admin"), http.StatusNotFound},
+		{"override without intensity", adminRequest("PUT", "/admin/zones/DE/override", `{}`, "admin"), http.StatusBadRequest},
+		{"scenario", adminRequest("PUT", "/admin/simulation", `{"points":[{"zone":"DE","at":"0h","carbonIntensity":300}],"speed":60}`, "admin"), http.StatusNoContent},
+		{"invalid scenario", adminRequest("PUT", "/admin/simulation", `{"speed":-1}`, "admin"), http.StatusBadRequest},
+		{"no simulation source", adminRequest("GET", "/admin/simulation", "", "admin"), http.StatusNotFound},
+		{"unknown emission factor", httptest.NewRequest("GET", "/carbon-intensity/DE?type=peak", nil), http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, tc.request)
+		if rec.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.expected, rec.Code)
+		}
+	}
+
+	// the token is not shown
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest("GET", "/admin/zones", "", "admin"))
+	var response ports.AdminZonesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || len(response.Zones) != 1 || !response.Zones[0].HasToken {
+		t.Errorf("expected DE with a token, got %+v, %v", response, err)
+	}
+	if strings.Contains(rec.Body.String(), "new") {
+		t.Errorf("expected the token to be hidden, got %s", rec.Body.String())
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
 )
@@ -17,16 +20,32 @@ type Zone struct {
 	Name string `json:"name"`
 }
 
+// Name of the source in the configuration and the stored data
+const Name = "electricity-maps"
+
 // Default API URLs (overridable in tests)
 var (
 	FetchURL        = "https://api.electricitymap.org/v3/carbon-intensity/latest?zone=%s"
+	BreakdownURL    = "https://api.electricitymap.org/v3/power-breakdown/latest?zone=%s"
 	ZoneMetadataURL = "https://api.electricitymap.org/v3/zones"
 )
 
-// Fetcher fetches carbon intensity data using tokens per zone
+// Fetcher fetches carbon intensity data using tokens per zone.
+// TokenByZone is set up before the first fetch, later changes go through SetToken and RemoveToken.
 type Fetcher struct {
 	TokenByZone map[string]string
 	Client      *http.Client
+
+	mu sync.RWMutex
+}
+
+var (
+	_ ports.TokenSource     = (*Fetcher)(nil)
+	_ ports.BreakdownSource = (*Fetcher)(nil)
+)
+
+func (f *Fetcher) Name() string {
+	return Name
 }
 
 // NewFromEnv creates a Fetcher with tokens from environment
@@ -55,41 +74,103 @@ func NewWithClient(client *http.Client) *Fetcher {
 	}
 }
 
-// Fetch gets the carbon intensity for a specific zone
-func (f *Fetcher) Fetch(zone string, ctx context.Context) (ports.CarbonIntensityData, error) {
+// SetToken sets or rotates the token of the zone
+func (f *Fetcher) SetToken(zone string, token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.TokenByZone[zone] = token
+}
+
+// RemoveToken removes the token of the zone, the zone is no longer supported
+func (f *Fetcher) RemoveToken(zone string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.TokenByZone, zone)
+}
+
+// Fetch gets the average carbon intensity for a specific zone, direct or lifecycle.
+// Each scope is a request of its own.
+func (f *Fetcher) Fetch(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	if factor.Type != ports.TypeAverage {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: no %s intensity", ports.ErrZoneNotSupported, factor)
+	}
+
+	var parsed struct {
+		CarbonIntensity float64   `json:"carbonIntensity"`
+		Datetime        time.Time `json:"datetime"`
+		IsEstimated     bool      `json:"isEstimated"`
+	}
+	if err := f.get(fmt.Sprintf(FetchURL, zone)+"&emissionFactorType="+factor.Scope, zone, &parsed, ctx); err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+
+	return ports.CarbonIntensityData{
+		Zone:            zone,
+		CarbonIntensity: parsed.CarbonIntensity,
+		EmissionFactor:  factor,
+		Source:          Name,
+		MeasuredAt:      parsed.Datetime,
+		IsEstimated:     parsed.IsEstimated,
+	}, nil
+}
+
+// FetchBreakdown gets the latest power breakdown for a specific zone. Production types without
+// a value are left out of the production mix.
+func (f *Fetcher) FetchBreakdown(zone string, ctx context.Context) (ports.PowerBreakdown, error) {
+	var parsed struct {
+		Datetime             time.Time           `json:"datetime"`
+		Production           map[string]*float64 `json:"powerProductionBreakdown"`
+		RenewablePercentage  *float64            `json:"renewablePercentage"`
+		FossilFreePercentage *float64            `json:"fossilFreePercentage"`
+	}
+	if err := f.get(fmt.Sprintf(BreakdownURL, zone), zone, &parsed, ctx); err != nil {
+		return ports.PowerBreakdown{}, err
+	}
+	if parsed.RenewablePercentage == nil || parsed.FossilFreePercentage == nil {
+		return ports.PowerBreakdown{}, fmt.Errorf("no renewable and fossil-free percentage for zone %s", zone)
+	}
+
+	mix := make(map[string]float64, len(parsed.Production))
+	for production, power := range parsed.Production {
+		if power != nil {
+			mix[production] = *power
+		}
+	}
+	return ports.PowerBreakdown{
+		Zone:                 zone,
+		RenewablePercentage:  *parsed.RenewablePercentage,
+		FossilFreePercentage: *parsed.FossilFreePercentage,
+		ProductionMix:        mix,
+		Source:               Name,
+		MeasuredAt:           parsed.Datetime,
+	}, nil
+}
+
+// get requests the url with the token of the zone and decodes the JSON response into v
+func (f *Fetcher) get(url string, zone string, v any, ctx context.Context) error {
+	f.mu.RLock()
 	token, ok := f.TokenByZone[zone]
+	f.mu.RUnlock()
 	if !ok || token == "" {
-		return ports.CarbonIntensityData{}, fmt.Errorf("no token configured for zone %s", zone)
+		return fmt.Errorf("%w: no token configured for zone %s", ports.ErrZoneNotSupported, zone)
 	}
 
-	url := fmt.Sprintf(FetchURL, zone)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return ports.CarbonIntensityData{}, err
+		return err
 	}
 	req.Header.Set("auth-token", token)
 
 	res, err := f.Client.Do(req)
 	if err != nil {
-		return ports.CarbonIntensityData{}, err
+		return err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return ports.CarbonIntensityData{}, fmt.Errorf("API returned status: %d", res.StatusCode)
-	}
-
-	var parsed struct {
-		CarbonIntensity float64 `json:"carbonIntensity"`
+		return ports.NewStatusError(res)
 	}
-	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
-		return ports.CarbonIntensityData{}, err
-	}
-
-	return ports.CarbonIntensityData{
-		Zone:            zone,
-		CarbonIntensity: parsed.CarbonIntensity,
-	}, nil
+	return json.NewDecoder(res.Body).Decode(v)
 }
 
 // AllElectricityMapZones returns all zones (unauthenticated)
@@ -128,8 +209,36 @@ func (f *Fetcher) AllElectricityMapZones(ctx context.Context) ([]Zone, error) {
 	return zones, nil
 }
 
+// Zones returns the zones with a configured token, named by the zone metadata of Electricity Maps.
+// If the metadata cannot be fetched, the zones are returned with their code as name along with the error.
+func (f *Fetcher) Zones(ctx context.Context) ([]ports.Zone, error) {
+	names := map[string]string{}
+	detailedZones, err := f.AllElectricityMapZones(ctx)
+	for _, z := range detailedZones {
+		names[z.Code] = z.Name
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	zones := make([]ports.Zone, 0, len(f.TokenByZone))
+	for code := range f.TokenByZone {
+		name, ok := names[code]
+		if !ok {
+			if err == nil {
+				continue // unknown to Electricity Maps
+			}
+			name = code
+		}
+		zones = append(zones, ports.Zone{Code: code, Name: name})
+	}
+	slices.SortFunc(zones, func(a, b ports.Zone) int { return strings.Compare(a.Code, b.Code) })
+	return zones, err
+}
+
 // GetConfiguredZones returns zones with a configured token
 func (f *Fetcher) GetConfiguredZones(ctx context.Context) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 	zones := make([]string, 0, len(f.TokenByZone))
 	for zone := range f.TokenByZone {
 		zones = append(zones, zone)
@@ -0,0 +1,232 @@
+package simulation
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// Name of the source in the configuration and the stored data
+const Name = "simulation"
+
+// point is a ScenarioPoint with its parsed offset
+type point struct {
+	at                   time.Duration
+	carbonIntensity      float64
+	renewablePercentage  float64
+	fossilFreePercentage float64
+}
+
+// series identifies the points of a zone in an emission factor
+type series struct {
+	zone   string
+	factor ports.EmissionFactor
+}
+
+// Replay serves the carbon intensities of a scenario at the current offset of the replay. It is meant for
+// tests of the scheduler, e.g. to run a whole day of intensity curves in minutes.
+type Replay struct {
+	mu        sync.RWMutex
+	scenario  ports.Scenario
+	points    map[series][]point // ordered by offset
+	breakdown map[string][]point // points with a power breakdown by zone, ordered by offset
+	zones     []ports.Zone
+	start     time.Duration
+	startedAt time.Time
+	now       func() time.Time
+}
+
+var (
+	_ ports.SimulationSource = (*Replay)(nil)
+	_ ports.BreakdownSource  = (*Replay)(nil)
+)
+
+// New creates a Replay without a scenario, it has no zones until one is set
+func New() *Replay {
+	r := &Replay{now: time.Now}
+	r.startedAt = r.now()
+	return r
+}
+
+// LoadScenario reads a scenario from a JSON file
+func LoadScenario(file string) (ports.Scenario, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return ports.Scenario{}, err
+	}
+	defer f.Close()
+
+	var scenario ports.Scenario
+	if err := json.NewDecoder(f).Decode(&scenario); err != nil {
+		return ports.Scenario{}, fmt.Errorf("%s: %w", file, err)
+	}
+	return scenario, nil
+}
+
+func (r *Replay) Name() string {
+	return Name
+}
+
+// SetScenario validates the scenario and replays it from now on
+func (r *Replay) SetScenario(scenario ports.Scenario) error {
+	if scenario.Speed < 0 {
+		return fmt.Errorf("%w: speed must not be negative", ports.ErrInvalidScenario)
+	}
+	var start time.Duration
+	if scenario.Start != "" {
+		var err error
+		if start, err = time.ParseDuration(scenario.Start); err != nil || start < 0 {
+			return fmt.Errorf("%w: invalid start %q", ports.ErrInvalidScenario, scenario.Start)
+		}
+	}
+
+	points := map[series][]point{}
+	breakdown := map[string][]point{}
+	var zones []ports.Zone
+	for _, p := range scenario.Points {
+		if p.Zone == "" {
+			return fmt.Errorf("%w: point without zone", ports.ErrInvalidScenario)
+		}
+		at, err := time.ParseDuration(p.At)
+		if err != nil || at < 0 {
+			return fmt.Errorf("%w: invalid offset %q for zone %s", ports.ErrInvalidScenario, p.At, p.Zone)
+		}
+		if p.CarbonIntensity < 0 {
+			return fmt.Errorf("%w: negative carbon intensity for zone %s", ports.ErrInvalidScenario, p.Zone)
+		}
+		factor, err := ports.ParseEmissionFactor(p.Type, p.Scope)
+		if err != nil {
+			return fmt.Errorf("%w: zone %s: %v", ports.ErrInvalidScenario, p.Zone, err)
+		}
+		key := series{zone: p.Zone, factor: factor}
+		points[key] = append(points[key], point{at: at, carbonIntensity: p.CarbonIntensity})
+		if p.RenewablePercentage != nil || p.FossilFreePercentage != nil {
+			if !isPercentage(p.RenewablePercentage) || !isPercentage(p.FossilFreePercentage) {
+				return fmt.Errorf("%w: renewable and fossil-free percentage between 0 and 100 required for zone %s", ports.ErrInvalidScenario, p.Zone)
+			}
+			breakdown[p.Zone] = append(breakdown[p.Zone], point{
+				at:                   at,
+				renewablePercentage:  *p.RenewablePercentage,
+				fossilFreePercentage: *p.FossilFreePercentage,
+			})
+		}
+
+		i := slices.IndexFunc(zones, func(z ports.Zone) bool { return z.Code == p.Zone })
+		if i < 0 {
+			zones = append(zones, ports.Zone{Code: p.Zone, Name: p.Zone})
+			i = len(zones) - 1
+		}
+		if p.Name != "" && zones[i].Name == zones[i].Code {
+			zones[i].Name = p.Name
+		}
+	}
+	for _, zonePoints := range points {
+		sortPoints(zonePoints)
+	}
+	for _, zonePoints := range breakdown {
+		sortPoints(zonePoints)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scenario = scenario
+	r.points = points
+	r.breakdown = breakdown
+	r.zones = zones
+	r.start = start
+	r.startedAt = r.now()
+	return nil
+}
+
+// offset returns the current offset in the scenario, r.mu must be held
+func (r *Replay) offset() time.Duration {
+	elapsed := r.now().Sub(r.startedAt)
+	return r.start + time.Duration(float64(elapsed)*r.scenario.Speed)
+}
+
+// Fetch returns the value of the last point of the zone at the current offset, the first point
+// before it. The value counts as measured now, simulated data is never stale.
+func (r *Replay) Fetch(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	zonePoints, ok := r.points[series{zone: zone, factor: factor}]
+	if !ok {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: %s %s", ports.ErrZoneNotSupported, zone, factor)
+	}
+	current := r.current(zonePoints)
+
+	return ports.CarbonIntensityData{
+		Zone:            zone,
+		CarbonIntensity: current.carbonIntensity,
+		EmissionFactor:  factor,
+		Source:          Name,
+		MeasuredAt:      r.now(),
+		IsEstimated:     true,
+	}, nil
+}
+
+// FetchBreakdown returns the power breakdown of the last point of the zone with one, like Fetch
+func (r *Replay) FetchBreakdown(zone string, ctx context.Context) (ports.PowerBreakdown, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	zonePoints, ok := r.breakdown[zone]
+	if !ok {
+		return ports.PowerBreakdown{}, fmt.Errorf("%w: no power breakdown for %s", ports.ErrZoneNotSupported, zone)
+	}
+	current := r.current(zonePoints)
+
+	return ports.PowerBreakdown{
+		Zone:                 zone,
+		RenewablePercentage:  current.renewablePercentage,
+		FossilFreePercentage: current.fossilFreePercentage,
+		Source:               Name,
+		MeasuredAt:           r.now(),
+	}, nil
+}
+
+// current returns the last point at the current offset, the first point before it, r.mu must be held
+func (r *Replay) current(points []point) point {
+	offset := r.offset()
+	current := points[0]
+	for _, p := range points {
+		if p.at > offset {
+			break
+		}
+		current = p
+	}
+	return current
+}
+
+func sortPoints(points []point) {
+	slices.SortStableFunc(points, func(a, b point) int { return cmp.Compare(a.at, b.at) })
+}
+
+func isPercentage(value *float64) bool {
+	return value != nil && *value >= 0 && *value <= 100
+}
+
+// Zones returns the zones of the scenario, named by their first point with a name
+func (r *Replay) Zones(ctx context.Context) ([]ports.Zone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.zones), nil
+}
+
+func (r *Replay) Status() ports.SimulationStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return ports.SimulationStatus{
+		Scenario:  r.scenario,
+		StartedAt: r.startedAt,
+		Offset:    r.offset().String(),
+	}
+}
@@ -0,0 +1,125 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+func TestReplay(t *testing.T) {
+	now := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	replay := New()
+	replay.now = func() time.Time { return now }
+
+	err := replay.SetScenario(ports.Scenario{
+		Speed: 60, // an hour per minute
+		Start: "6h",
+		Points: []ports.ScenarioPoint{
+			{Zone: "DE", Name: "Germany", At: "12h", CarbonIntensity: 120},
+			{Zone: "DE", At: "0h", CarbonIntensity: 300},
+			{Zone: "FR", Name: "France", At: "8h", CarbonIntensity: 50},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		elapsed  time.Duration
+		zone     string
+		expected float64
+	}{
+		{0, "DE", 300},               // 6h
+		{0, "FR", 50},                // before the first point of FR
+		{5 * time.Minute, "DE", 300}, // 11h
+		{6 * time.Minute, "DE", 120}, // 12h
+		{time.Hour, "DE", 120},       // after the last point
+	}
+	for _, tc := range tests {
+		now = time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC).Add(tc.elapsed)
+		data, err := replay.Fetch(tc.zone, ports.DefaultEmissionFactor, context.Background())
+		if err != nil || data.CarbonIntensity != tc.expected || data.Source != Name || !data.MeasuredAt.Equal(now) {
+			t.Errorf("%s after %v: expected %g, got %+v, %v", tc.zone, tc.elapsed, tc.expected, data, err)
+		}
+	}
+	if status := replay.Status(); status.Offset != "66h0m0s" {
+		t.Errorf("expected offset 66h after an hour, got %s", status.Offset)
+	}
+
+	zones, _ := replay.Zones(context.Background())
+	if len(zones) != 2 || !reflect.DeepEqual(zones[0], ports.Zone{Code: "DE", Name: "Germany"}) || !reflect.DeepEqual(zones[1], ports.Zone{Code: "FR", Name: "France"}) {
+		t.Errorf("expected DE and FR, got %v", zones)
+	}
+	if _, err := replay.Fetch("GB", ports.DefaultEmissionFactor, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected ErrZoneNotSupported, got %v", err)
+	}
+
+	// a paused scenario stays at its start
+	replay.SetScenario(ports.Scenario{Start: "12h", Points: []ports.ScenarioPoint{{Zone: "DE", At: "12h", CarbonIntensity: 120}}})
+	now = now.Add(24 * time.Hour)
+	if data, _ := replay.Fetch("DE", ports.DefaultEmissionFactor, context.Background()); data.CarbonIntensity != 120 {
+		t.Errorf("expected paused value, got %+v", data)
+	}
+	if _, err := replay.Fetch("FR", ports.DefaultEmissionFactor, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected FR to be gone with the new scenario, got %v", err)
+	}
+}
+
+func TestSetScenario_Invalid(t *testing.T) {
+	scenarios := []ports.Scenario{
+		{Speed: -1},
+		{Start: "noon"},
+		{Points: []ports.ScenarioPoint{{At: "1h", CarbonIntensity: 100}}},
+		{Points: []ports.ScenarioPoint{{Zone: "DE", At: "-1h", CarbonIntensity: 100}}},
+		{Points: []ports.ScenarioPoint{{Zone: "DE", At: "1h", CarbonIntensity: -100}}},
+		{Points: []ports.ScenarioPoint{{Zone: "DE", At: "1h", CarbonIntensity: 100, Scope: "indirect"}}},
+		{Points: []ports.ScenarioPoint{{Zone: "DE", At: "1h", CarbonIntensity: 100, RenewablePercentage: percent(50)}}},
+		{Points: []ports.ScenarioPoint{{Zone: "DE", At: "1h", CarbonIntensity: 100, RenewablePercentage: percent(120), FossilFreePercentage: percent(50)}}},
+	}
+	for _, scenario := range scenarios {
+		if err := New().SetScenario(scenario); !errors.Is(err, ports.ErrInvalidScenario) {
+			t.Errorf("%+v: expected ErrInvalidScenario, got %v", scenario, err)
+		}
+	}
+}
+
+func percent(value float64) *float64 {
+	return &value
+}
+
+func TestReplay_Breakdown(t *testing.T) {
+	now := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	replay := New()
+	replay.now = func() time.Time { return now }
+
+	err := replay.SetScenario(ports.Scenario{
+		Speed: 60,
+		Points: []ports.ScenarioPoint{
+			{Zone: "DE", At: "0h", CarbonIntensity: 300, RenewablePercentage: percent(40), FossilFreePercentage: percent(45)},
+			{Zone: "DE", At: "6h", CarbonIntensity: 250},
+			{Zone: "DE", At: "12h", CarbonIntensity: 120, RenewablePercentage: percent(70), FossilFreePercentage: percent(75)},
+			{Zone: "FR", At: "0h", CarbonIntensity: 50},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the point at 6h has no breakdown, the one of 0h is kept
+	now = now.Add(7 * time.Minute)
+	breakdown, err := replay.FetchBreakdown("DE", context.Background())
+	if err != nil || breakdown.RenewablePercentage != 40 || breakdown.FossilFreePercentage != 45 || breakdown.Source != Name {
+		t.Errorf("expected breakdown of 0h, got %+v, %v", breakdown, err)
+	}
+	now = now.Add(6 * time.Minute)
+	if breakdown, _ := replay.FetchBreakdown("DE", context.Background()); breakdown.RenewablePercentage != 70 {
+		t.Errorf("expected breakdown of 12h, got %+v", breakdown)
+	}
+	if _, err := replay.FetchBreakdown("FR", context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected ErrZoneNotSupported for zone without breakdown, got %v", err)
+	}
+}
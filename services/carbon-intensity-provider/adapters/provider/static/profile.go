@@ -0,0 +1,189 @@
+package static
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// Name of the source in the configuration and the stored data
+const Name = "static"
+
+// Entry is the carbon intensity of a zone, for one hour of the day (UTC) or for the whole day if Hour is nil.
+// An empty type or scope is the one of ports.DefaultEmissionFactor.
+type Entry struct {
+	Zone            string  `json:"zone"`
+	Name            string  `json:"name,omitempty"`
+	Hour            *int    `json:"hour,omitempty"`
+	CarbonIntensity float64 `json:"carbonIntensity"`
+	Type            string  `json:"type,omitempty"`
+	Scope           string  `json:"scope,omitempty"`
+}
+
+func (e Entry) factor() ports.EmissionFactor {
+	return ports.EmissionFactor{Type: e.Type, Scope: e.Scope}.OrDefault()
+}
+
+// Profile serves fixed values, read from a CSV or JSON file. It is meant for offline use and tests.
+type Profile struct {
+	entries []Entry
+	now     func() time.Time
+}
+
+var _ ports.CarbonSource = (*Profile)(nil)
+
+func New(entries []Entry) *Profile {
+	return &Profile{entries: entries, now: time.Now}
+}
+
+// Load reads a profile from a .json file, a list of entries, or from a .csv file with the header
+// zone,carbonIntensity and the optional columns hour, name, type and scope.
+func Load(file string) (*Profile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&entries)
+	case ".csv":
+		entries, err = parseCSV(f)
+	default:
+		return nil, fmt.Errorf("%s: unsupported profile format, use .csv or .json", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	for _, entry := range entries {
+		if entry.Zone == "" {
+			return nil, fmt.Errorf("%s: entry without zone", file)
+		}
+		if entry.Hour != nil && (*entry.Hour < 0 || *entry.Hour > 23) {
+			return nil, fmt.Errorf("%s: invalid hour %d for zone %s", file, *entry.Hour, entry.Zone)
+		}
+		if _, err := ports.ParseEmissionFactor(entry.Type, entry.Scope); err != nil {
+			return nil, fmt.Errorf("%s: zone %s: %w", file, entry.Zone, err)
+		}
+	}
+	return New(entries), nil
+}
+
+func parseCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	zoneColumn, hasZone := columns["zone"]
+	intensityColumn, hasIntensity := columns["carbonIntensity"]
+	if !hasZone || !hasIntensity {
+		return nil, fmt.Errorf("header must contain zone and carbonIntensity")
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := Entry{Zone: record[zoneColumn]}
+		if entry.CarbonIntensity, err = strconv.ParseFloat(record[intensityColumn], 64); err != nil {
+			return nil, fmt.Errorf("zone %s: %w", entry.Zone, err)
+		}
+		if i, ok := columns["hour"]; ok && record[i] != "" {
+			hour, err := strconv.Atoi(record[i])
+			if err != nil {
+				return nil, fmt.Errorf("zone %s: %w", entry.Zone, err)
+			}
+			entry.Hour = &hour
+		}
+		if i, ok := columns["name"]; ok {
+			entry.Name = record[i]
+		}
+		if i, ok := columns["type"]; ok {
+			entry.Type = record[i]
+		}
+		if i, ok := columns["scope"]; ok {
+			entry.Scope = record[i]
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (p *Profile) Name() string {
+	return Name
+}
+
+// Fetch returns the value of the current hour, the value for the whole day if the hour has none.
+// The value counts as measured at the start of the hour.
+func (p *Profile) Fetch(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	now := p.now().UTC()
+
+	var dayEntry *Entry
+	for i, entry := range p.entries {
+		if entry.Zone != zone || entry.factor() != factor {
+			continue
+		}
+		if entry.Hour == nil {
+			dayEntry = &p.entries[i]
+		} else if *entry.Hour == now.Hour() {
+			return p.data(entry, now), nil
+		}
+	}
+	if dayEntry == nil {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: %s %s", ports.ErrZoneNotSupported, zone, factor)
+	}
+	return p.data(*dayEntry, now), nil
+}
+
+func (p *Profile) data(entry Entry, now time.Time) ports.CarbonIntensityData {
+	return ports.CarbonIntensityData{
+		Zone:            entry.Zone,
+		CarbonIntensity: entry.CarbonIntensity,
+		EmissionFactor:  entry.factor(),
+		Source:          Name,
+		MeasuredAt:      now.Truncate(time.Hour),
+		IsEstimated:     true,
+	}
+}
+
+// Zones returns the zones of the profile, named by their first entry with a name
+func (p *Profile) Zones(ctx context.Context) ([]ports.Zone, error) {
+	var zones []ports.Zone
+	for _, entry := range p.entries {
+		i := slices.IndexFunc(zones, func(z ports.Zone) bool { return z.Code == entry.Zone })
+		if i < 0 {
+			zones = append(zones, ports.Zone{Code: entry.Zone, Name: entry.Zone})
+			i = len(zones) - 1
+		}
+		if entry.Name != "" && zones[i].Name == zones[i].Code {
+			zones[i].Name = entry.Name
+		}
+	}
+	return zones, nil
+}
@@ -0,0 +1,94 @@
+package static
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+func writeProfile(t *testing.T, name string, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	csvFile := writeProfile(t, "profile.csv", `zone,hour,carbonIntensity,name,type,scope
+# whole day and a sunny noon
+DE,,300,Germany,,
+DE,12,120,,,
+DE,,600,,marginal,direct
+FR,,50,France,,
+`)
+	jsonFile := writeProfile(t, "profile.json", `[
+		{"zone": "DE", "name": "Germany", "carbonIntensity": 300},
+		{"zone": "DE", "hour": 12, "carbonIntensity": 120},
+		{"zone": "DE", "carbonIntensity": 600, "type": "marginal", "scope": "direct"},
+		{"zone": "FR", "name": "France", "carbonIntensity": 50}
+	]`)
+
+	for _, file := range []string{csvFile, jsonFile} {
+		t.Run(filepath.Ext(file), func(t *testing.T) {
+			profile, err := Load(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			profile.now = func() time.Time { return time.Date(2025, 7, 1, 12, 30, 0, 0, time.UTC) }
+
+			data, err := profile.Fetch("DE", ports.DefaultEmissionFactor, context.Background())
+			if err != nil || data.CarbonIntensity != 120 || data.Source != Name {
+				t.Errorf("expected value of the hour, got %+v, %v", data, err)
+			}
+			if !data.MeasuredAt.Equal(time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("expected start of the hour, got %v", data.MeasuredAt)
+			}
+
+			profile.now = func() time.Time { return time.Date(2025, 7, 1, 20, 0, 0, 0, time.UTC) }
+			if data, _ := profile.Fetch("DE", ports.DefaultEmissionFactor, context.Background()); data.CarbonIntensity != 300 {
+				t.Errorf("expected value of the day, got %+v", data)
+			}
+
+			marginal := ports.EmissionFactor{Type: ports.TypeMarginal, Scope: ports.ScopeDirect}
+			if data, _ := profile.Fetch("DE", marginal, context.Background()); data.CarbonIntensity != 600 || data.EmissionFactor != marginal {
+				t.Errorf("expected marginal value, got %+v", data)
+			}
+
+			if _, err := profile.Fetch("GB", ports.DefaultEmissionFactor, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+				t.Errorf("expected ErrZoneNotSupported, got %v", err)
+			}
+			if _, err := profile.Fetch("FR", marginal, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+				t.Errorf("expected ErrZoneNotSupported without marginal value, got %v", err)
+			}
+
+			zones, _ := profile.Zones(context.Background())
+			if len(zones) != 2 || !reflect.DeepEqual(zones[0], ports.Zone{Code: "DE", Name: "Germany"}) || !reflect.DeepEqual(zones[1], ports.Zone{Code: "FR", Name: "France"}) {
+				t.Errorf("expected DE and FR, got %v", zones)
+			}
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	files := []string{
+		writeProfile(t, "no-intensity.csv", "zone,hour\nDE,1\n"),
+		writeProfile(t, "invalid-hour.csv", "zone,hour,carbonIntensity\nDE,24,100\n"),
+		writeProfile(t, "invalid-number.csv", "zone,carbonIntensity\nDE,high\n"),
+		writeProfile(t, "no-zone.json", `[{"carbonIntensity": 100}]`),
+		writeProfile(t, "invalid-type.json", `[{"zone": "DE", "carbonIntensity": 100, "type": "peak"}]`),
+		writeProfile(t, "profile.txt", "DE 100"),
+	}
+	for _, file := range files {
+		if _, err := Load(file); err == nil {
+			t.Errorf("%s: expected error", filepath.Base(file))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ukcarbonintensity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// Name of the source in the configuration and the stored data
+const Name = "uk-carbon-intensity"
+
+// Zone is the Electricity Maps code of Great Britain, the only zone of the national API
+const Zone = "GB"
+
+// Factor is the only emission factor of the API, it counts the emissions of the generation
+var Factor = ports.EmissionFactor{Type: ports.TypeAverage, Scope: ports.ScopeDirect}
+
+// Default API URL (overridable in tests)
+var IntensityURL = "https://api.carbonintensity.org.uk/intensity"
+
+// Fetcher fetches the carbon intensity of Great Britain from the Carbon Intensity API of the
+// National Grid ESO. The API needs no token.
+type Fetcher struct {
+	Client *http.Client
+}
+
+var _ ports.CarbonSource = (*Fetcher)(nil)
+
+func New(client *http.Client) *Fetcher {
+	return &Fetcher{Client: client}
+}
+
+func (f *Fetcher) Name() string {
+	return Name
+}
+
+// Fetch returns the actual intensity of the current half hour, the forecast if it is not measured yet
+func (f *Fetcher) Fetch(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	if zone != Zone {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: %s", ports.ErrZoneNotSupported, zone)
+	}
+	if factor != Factor {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: no %s intensity", ports.ErrZoneNotSupported, factor)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, IntensityURL, nil)
+	if err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ports.CarbonIntensityData{}, ports.NewStatusError(res)
+	}
+
+	var parsed struct {
+		Data []struct {
+			From      string `json:"from"`
+			Intensity struct {
+				Forecast *float64 `json:"forecast"`
+				Actual   *float64 `json:"actual"`
+			} `json:"intensity"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+	if len(parsed.Data) == 0 {
+		return ports.CarbonIntensityData{}, fmt.Errorf("API returned no data")
+	}
+
+	period := parsed.Data[0]
+	intensity := period.Intensity.Actual
+	if intensity == nil {
+		intensity = period.Intensity.Forecast
+	}
+	if intensity == nil {
+		return ports.CarbonIntensityData{}, fmt.Errorf("API returned no intensity")
+	}
+	// the API omits the seconds, e.g. 2018-01-20T12:00Z
+	measuredAt, err := time.Parse("2006-01-02T15:04Z", period.From)
+	if err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+
+	return ports.CarbonIntensityData{
+		Zone:            Zone,
+		CarbonIntensity: *intensity,
+		EmissionFactor:  Factor,
+		Source:          Name,
+		MeasuredAt:      measuredAt,
+		IsEstimated:     period.Intensity.Actual == nil,
+	}, nil
+}
+
+func (f *Fetcher) Zones(ctx context.Context) ([]ports.Zone, error) {
+	return []ports.Zone{{Code: Zone, Name: "Great Britain"}}, nil
+}
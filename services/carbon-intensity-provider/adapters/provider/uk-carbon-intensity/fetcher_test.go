@@ -0,0 +1,53 @@
+package ukcarbonintensity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ukcarbonintensity "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/uk-carbon-intensity"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+func TestFetch(t *testing.T) {
+	response := `{"data":[{"from":"2025-07-01T12:00Z","to":"2025-07-01T12:30Z","intensity":{"forecast":180,"actual":null,"index":"moderate"}}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	ukcarbonintensity.IntensityURL = server.URL
+	fetcher := ukcarbonintensity.New(server.Client())
+
+	// the actual value is not measured yet
+	data, err := fetcher.Fetch("GB", ukcarbonintensity.Factor, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ports.CarbonIntensityData{
+		Zone:            "GB",
+		CarbonIntensity: 180,
+		EmissionFactor:  ports.EmissionFactor{Type: ports.TypeAverage, Scope: ports.ScopeDirect},
+		Source:          ukcarbonintensity.Name,
+		MeasuredAt:      time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+		IsEstimated:     true,
+	}
+	if data != expected {
+		t.Errorf("expected %+v, got %+v", expected, data)
+	}
+
+	response = `{"data":[{"from":"2025-07-01T12:00Z","to":"2025-07-01T12:30Z","intensity":{"forecast":180,"actual":175,"index":"moderate"}}]}`
+	if data, _ := fetcher.Fetch("GB", ukcarbonintensity.Factor, context.Background()); data.CarbonIntensity != 175 || data.IsEstimated {
+		t.Errorf("expected actual value, got %+v", data)
+	}
+
+	if _, err := fetcher.Fetch("DE", ukcarbonintensity.Factor, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected ErrZoneNotSupported, got %v", err)
+	}
+	// the API only has the direct emissions
+	if _, err := fetcher.Fetch("GB", ports.DefaultEmissionFactor, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected ErrZoneNotSupported for lifecycle emissions, got %v", err)
+	}
+}
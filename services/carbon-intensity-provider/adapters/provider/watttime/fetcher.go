@@ -0,0 +1,200 @@
+package watttime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// Name of the source in the configuration and the stored data
+const Name = "watttime"
+
+// lbsPerMWhToGramsPerKWh converts the MOER of WattTime to the unit of the other sources
+const lbsPerMWhToGramsPerKWh = 0.453592
+
+// Factor is the emission factor of the MOER
+var Factor = ports.EmissionFactor{Type: ports.TypeMarginal, Scope: ports.ScopeDirect}
+
+// tokenTTL is shorter than the 30 minutes a WattTime token is valid
+const tokenTTL = 25 * time.Minute
+
+// Default API URLs (overridable in tests)
+var (
+	LoginURL    = "https://api.watttime.org/login"
+	ForecastURL = "https://api.watttime.org/v3/forecast"
+)
+
+// Fetcher fetches the marginal operating emissions rate (MOER) from WattTime. WattTime has its own
+// region names, RegionByZone maps the zones of the provider to them.
+type Fetcher struct {
+	Username     string
+	Password     string
+	RegionByZone map[string]string
+	Client       *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+var _ ports.CarbonSource = (*Fetcher)(nil)
+
+// NewFromEnv creates a Fetcher from WATTTIME_USERNAME, WATTTIME_PASSWORD and WATTTIME_REGIONS,
+// e.g. WATTTIME_REGIONS=US-CAL-CISO=CAISO_NORTH,US-NY-NYIS=NYISO
+func NewFromEnv() *Fetcher {
+	regions := map[string]string{}
+	for _, entry := range strings.Split(os.Getenv("WATTTIME_REGIONS"), ",") {
+		zone, region, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if ok && zone != "" && region != "" {
+			regions[zone] = region
+		}
+	}
+	return &Fetcher{
+		Username:     os.Getenv("WATTTIME_USERNAME"),
+		Password:     os.Getenv("WATTTIME_PASSWORD"),
+		RegionByZone: regions,
+		Client:       http.DefaultClient,
+	}
+}
+
+func (f *Fetcher) Name() string {
+	return Name
+}
+
+// Fetch returns the current MOER of the region of the zone in gCO2eq/kWh
+func (f *Fetcher) Fetch(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	region, ok := f.RegionByZone[zone]
+	if !ok {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: no WattTime region configured for zone %s", ports.ErrZoneNotSupported, zone)
+	}
+	if factor != Factor {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: no %s intensity", ports.ErrZoneNotSupported, factor)
+	}
+
+	query := url.Values{}
+	query.Set("region", region)
+	query.Set("signal_type", "co2_moer")
+	query.Set("horizon_hours", "0")
+
+	res, err := f.get(ctx, ForecastURL+"?"+query.Encode())
+	if err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ports.CarbonIntensityData{}, ports.NewStatusError(res)
+	}
+
+	var parsed struct {
+		Data []struct {
+			PointTime time.Time `json:"point_time"`
+			Value     float64   `json:"value"`
+		} `json:"data"`
+		Meta struct {
+			Units string `json:"units"`
+		} `json:"meta"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+	if len(parsed.Data) == 0 {
+		return ports.CarbonIntensityData{}, fmt.Errorf("API returned no data for region %s", region)
+	}
+	if parsed.Meta.Units != "lbs_co2_per_mwh" {
+		return ports.CarbonIntensityData{}, fmt.Errorf("API returned unexpected unit %q", parsed.Meta.Units)
+	}
+
+	return ports.CarbonIntensityData{
+		Zone:            zone,
+		CarbonIntensity: parsed.Data[0].Value * lbsPerMWhToGramsPerKWh,
+		EmissionFactor:  Factor,
+		Source:          Name,
+		MeasuredAt:      parsed.Data[0].PointTime,
+		IsEstimated:     true, // the first point of the forecast
+	}, nil
+}
+
+// Zones returns the zones with a configured region, named by the region
+func (f *Fetcher) Zones(ctx context.Context) ([]ports.Zone, error) {
+	zones := make([]ports.Zone, 0, len(f.RegionByZone))
+	for zone, region := range f.RegionByZone {
+		zones = append(zones, ports.Zone{Code: zone, Name: region})
+	}
+	slices.SortFunc(zones, func(a, b ports.Zone) int { return strings.Compare(a.Code, b.Code) })
+	return zones, nil
+}
+
+// get sends the request with a token, a rejected token is renewed once
+func (f *Fetcher) get(ctx context.Context, url string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := f.currentToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		res, err := f.Client.Do(req)
+		if err != nil || res.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return res, err
+		}
+		res.Body.Close()
+
+		f.mu.Lock()
+		f.token = ""
+		f.mu.Unlock()
+	}
+}
+
+// currentToken logs in with basic auth if there is no valid token
+func (f *Fetcher) currentToken(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.token != "" && time.Now().Before(f.expiresAt) {
+		return f.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, LoginURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(f.Username, f.Password)
+
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login returned status: %d", res.StatusCode)
+	}
+
+	var parsed struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return "", err
+	}
+	if parsed.Token == "" {
+		return "", fmt.Errorf("login returned no token")
+	}
+
+	f.token = parsed.Token
+	f.expiresAt = time.Now().Add(tokenTTL)
+	return f.token, nil
+}
@@ -0,0 +1,75 @@
+package watttime_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/watttime"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+func TestFetch(t *testing.T) {
+	logins := 0
+	validToken := "token1"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "user" || password != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		logins++
+		w.Write([]byte(`{"token":"` + validToken + `"}`))
+	})
+	mux.HandleFunc("/v3/forecast", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("region") != "CAISO_NORTH" || r.URL.Query().Get("signal_type") != "co2_moer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":[{"point_time":"2025-07-01T12:00:00+00:00","value":1000}],"meta":{"region":"CAISO_NORTH","units":"lbs_co2_per_mwh"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	watttime.LoginURL = server.URL + "/login"
+	watttime.ForecastURL = server.URL + "/v3/forecast"
+
+	fetcher := &watttime.Fetcher{
+		Username:     "user",
+		Password:     "secret",
+		RegionByZone: map[string]string{"US-CAL-CISO": "CAISO_NORTH"},
+		Client:       server.Client(),
+	}
+
+	data, err := fetcher.Fetch("US-CAL-CISO", watttime.Factor, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1000 lbs/MWh are 453.592 g/kWh
+	if math.Abs(data.CarbonIntensity-453.592) > 0.001 || data.Source != watttime.Name || data.MeasuredAt.IsZero() || !data.IsEstimated || data.Type != ports.TypeMarginal {
+		t.Errorf("unexpected data %+v", data)
+	}
+
+	// the token is reused and renewed once it is rejected
+	fetcher.Fetch("US-CAL-CISO", watttime.Factor, context.Background())
+	if logins != 1 {
+		t.Errorf("expected one login, got %d", logins)
+	}
+	validToken = "token2"
+	if _, err := fetcher.Fetch("US-CAL-CISO", watttime.Factor, context.Background()); err != nil || logins != 2 {
+		t.Errorf("expected renewed token, got %v after %d logins", err, logins)
+	}
+
+	if _, err := fetcher.Fetch("DE", watttime.Factor, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected ErrZoneNotSupported, got %v", err)
+	}
+	if _, err := fetcher.Fetch("US-CAL-CISO", ports.DefaultEmissionFactor, context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) || logins != 2 {
+		t.Errorf("expected ErrZoneNotSupported without request for average intensity, got %v", err)
+	}
+}
@@ -12,11 +12,15 @@ import (
 var (
 	storageFile     = "zones.json"
 	metadataStorage = "zones_metadata.json"
+	configStorage   = "zones_config.json"
+	breakdownFile   = "zones_breakdown.json"
 )
 
 type Repo struct {
 	carbonIntensityProviders map[string]ports.CarbonIntensityData
 	availableZones           []ports.Zone
+	zoneConfigs              map[string]ports.ZoneConfig
+	breakdowns               map[string]ports.PowerBreakdown
 	mu                       sync.RWMutex
 }
 
@@ -25,9 +29,13 @@ var _ ports.Repo = (*Repo)(nil)
 func NewRepo() *Repo {
 	r := &Repo{
 		carbonIntensityProviders: make(map[string]ports.CarbonIntensityData),
+		zoneConfigs:              make(map[string]ports.ZoneConfig),
+		breakdowns:               make(map[string]ports.PowerBreakdown),
 	}
 	r.loadFromFile()
 	r.loadZoneMetadata()
+	r.loadZoneConfigs()
+	r.loadBreakdowns()
 	return r
 }
 
@@ -35,7 +43,7 @@ func (r *Repo) Store(data ports.CarbonIntensityData, ctx context.Context) error
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.carbonIntensityProviders[data.Zone] = data
+	r.carbonIntensityProviders[storageKey(data.Zone, data.EmissionFactor.OrDefault())] = data
 
 	if err := r.saveToFile(); err != nil {
 		return err
@@ -43,17 +51,25 @@ func (r *Repo) Store(data ports.CarbonIntensityData, ctx context.Context) error
 	return nil
 }
 
-func (r *Repo) FindById(id string, ctx context.Context) (ports.CarbonIntensityData, error) {
+func (r *Repo) FindById(id string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	data, ok := r.carbonIntensityProviders[id]
+	data, ok := r.carbonIntensityProviders[storageKey(id, factor)]
 	if !ok {
 		return ports.CarbonIntensityData{}, ports.ErrCarbonIntensityProviderNotFound
 	}
 	return data, nil
 }
 
+// storageKey keys the data of the default emission factor by zone, so zones.json of older versions stays valid
+func storageKey(zone string, factor ports.EmissionFactor) string {
+	if factor == ports.DefaultEmissionFactor {
+		return zone
+	}
+	return zone + "/" + factor.String()
+}
+
 func (r *Repo) FindAll(ctx context.Context) ([]ports.CarbonIntensityData, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -65,6 +81,25 @@ func (r *Repo) FindAll(ctx context.Context) ([]ports.CarbonIntensityData, error)
 	return result, nil
 }
 
+func (r *Repo) StoreBreakdown(breakdown ports.PowerBreakdown, ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.breakdowns[breakdown.Zone] = breakdown
+	return r.saveBreakdowns()
+}
+
+func (r *Repo) FindBreakdown(zone string, ctx context.Context) (ports.PowerBreakdown, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	breakdown, ok := r.breakdowns[zone]
+	if !ok {
+		return ports.PowerBreakdown{}, ports.ErrCarbonIntensityProviderNotFound
+	}
+	return breakdown, nil
+}
+
 func (r *Repo) StoreZones(zones []ports.Zone, ctx context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -126,3 +161,66 @@ func (r *Repo) loadZoneMetadata() {
 
 	_ = json.NewDecoder(file).Decode(&r.availableZones)
 }
+
+func (r *Repo) StoreZoneConfig(config ports.ZoneConfig, ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.zoneConfigs[config.Code] = config
+	return r.saveZoneConfigs()
+}
+
+func (r *Repo) GetZoneConfigs(ctx context.Context) ([]ports.ZoneConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]ports.ZoneConfig, 0, len(r.zoneConfigs))
+	for _, config := range r.zoneConfigs {
+		result = append(result, config)
+	}
+	return result, nil
+}
+
+func (r *Repo) saveZoneConfigs() error {
+	file, err := os.OpenFile(configStorage, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // contains the encrypted tokens
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.zoneConfigs)
+}
+
+func (r *Repo) loadZoneConfigs() {
+	file, err := os.Open(configStorage)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	_ = json.NewDecoder(file).Decode(&r.zoneConfigs)
+}
+
+func (r *Repo) saveBreakdowns() error {
+	file, err := os.Create(breakdownFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.breakdowns)
+}
+
+func (r *Repo) loadBreakdowns() {
+	file, err := os.Open(breakdownFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	_ = json.NewDecoder(file).Decode(&r.breakdowns)
+}
@@ -0,0 +1,258 @@
+package repo_postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Repo keeps every reading in carbon_intensities, the latest reading of a zone in an emission factor is its current data.
+// Power breakdowns are kept the same way in carbon_power_breakdowns.
+// The tables are created by database/carbon-intensity-provider-init.sql.
+type Repo struct {
+	db *sql.DB
+}
+
+var _ ports.Repo = (*Repo)(nil)
+
+func NewRepo(host, port, user, password, dbName string, sslmode bool, ctx context.Context) (*Repo, error) {
+	escapedPassword := url.QueryEscape(password)
+
+	sslModeParam := "disable"
+	if sslmode {
+		sslModeParam = "require"
+	}
+
+	connectionString := fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
+		user, escapedPassword, host, port, dbName, sslModeParam,
+	)
+
+	db, err := sql.Open("pgx", connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	logging.Debug("Successfully connected to the PostgreSQL database")
+	return &Repo{db: db}, nil
+}
+
+const dataColumns = `zone, carbon_intensity, source, measured_at, fetched_at, is_estimated, emission_type, emission_scope`
+
+// Store adds the reading to the history. A measurement that is already stored only updates its fetch time.
+func (r *Repo) Store(data ports.CarbonIntensityData, ctx context.Context) error {
+	fetchedAt := data.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	factor := data.EmissionFactor.OrDefault()
+	query := `INSERT INTO carbon_intensities (` + dataColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (zone, source, emission_type, emission_scope, measured_at) DO UPDATE SET
+        carbon_intensity = EXCLUDED.carbon_intensity, fetched_at = EXCLUDED.fetched_at, is_estimated = EXCLUDED.is_estimated`
+	_, err := r.db.ExecContext(ctx, query,
+		data.Zone, data.CarbonIntensity, data.Source, nullTime(data.MeasuredAt), fetchedAt, data.IsEstimated, factor.Type, factor.Scope)
+	if err != nil {
+		logging.From(ctx).Warn("Storing carbon intensity failed", "zone", data.Zone, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *Repo) FindById(id string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	query := `SELECT ` + dataColumns + ` FROM carbon_intensities WHERE zone = $1 AND emission_type = $2 AND emission_scope = $3
+        ORDER BY fetched_at DESC, id DESC LIMIT 1`
+	data, err := scanData(r.db.QueryRowContext(ctx, query, id, factor.Type, factor.Scope))
+	if err == sql.ErrNoRows {
+		return ports.CarbonIntensityData{}, ports.ErrCarbonIntensityProviderNotFound
+	}
+	return data, err
+}
+
+func (r *Repo) FindAll(ctx context.Context) ([]ports.CarbonIntensityData, error) {
+	query := `SELECT DISTINCT ON (zone, emission_type, emission_scope) ` + dataColumns + ` FROM carbon_intensities
+        ORDER BY zone, emission_type, emission_scope, fetched_at DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []ports.CarbonIntensityData{}
+	for rows.Next() {
+		data, err := scanData(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+	return result, rows.Err()
+}
+
+// StoreBreakdown adds the power breakdown to the history, like Store for the readings
+func (r *Repo) StoreBreakdown(breakdown ports.PowerBreakdown, ctx context.Context) error {
+	fetchedAt := breakdown.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	mix, err := json.Marshal(breakdown.ProductionMix)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO carbon_power_breakdowns (zone, renewable_percentage, fossil_free_percentage, production_mix, source, measured_at, fetched_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (zone, source, measured_at) DO UPDATE SET renewable_percentage = EXCLUDED.renewable_percentage,
+        fossil_free_percentage = EXCLUDED.fossil_free_percentage, production_mix = EXCLUDED.production_mix, fetched_at = EXCLUDED.fetched_at`
+	_, err = r.db.ExecContext(ctx, query, breakdown.Zone, breakdown.RenewablePercentage, breakdown.FossilFreePercentage, mix,
+		breakdown.Source, nullTime(breakdown.MeasuredAt), fetchedAt)
+	if err != nil {
+		logging.From(ctx).Warn("Storing power breakdown failed", "zone", breakdown.Zone, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *Repo) FindBreakdown(zone string, ctx context.Context) (ports.PowerBreakdown, error) {
+	query := `SELECT zone, renewable_percentage, fossil_free_percentage, production_mix, source, measured_at, fetched_at
+        FROM carbon_power_breakdowns WHERE zone = $1 ORDER BY fetched_at DESC, id DESC LIMIT 1`
+	var breakdown ports.PowerBreakdown
+	var mix []byte
+	var measuredAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, zone).Scan(&breakdown.Zone, &breakdown.RenewablePercentage,
+		&breakdown.FossilFreePercentage, &mix, &breakdown.Source, &measuredAt, &breakdown.FetchedAt)
+	if err == sql.ErrNoRows {
+		return ports.PowerBreakdown{}, ports.ErrCarbonIntensityProviderNotFound
+	}
+	if err != nil {
+		return ports.PowerBreakdown{}, err
+	}
+	if err := json.Unmarshal(mix, &breakdown.ProductionMix); err != nil {
+		return ports.PowerBreakdown{}, err
+	}
+	if measuredAt.Valid {
+		breakdown.MeasuredAt = measuredAt.Time
+	}
+	return breakdown, nil
+}
+
+// StoreZones replaces the available zones
+func (r *Repo) StoreZones(zones []ports.Zone, ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM carbon_zones`); err != nil {
+		return err
+	}
+	for _, zone := range zones {
+		metadata, err := json.Marshal(zone.ZoneMetadata)
+		if err != nil {
+			return err
+		}
+		query := `INSERT INTO carbon_zones (code, name, metadata) VALUES ($1, $2, $3)
+			ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, metadata = EXCLUDED.metadata`
+		if _, err := tx.ExecContext(ctx, query, zone.Code, zone.Name, metadata); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Repo) GetZones(ctx context.Context) []ports.Zone {
+	rows, err := r.db.QueryContext(ctx, `SELECT code, name, metadata FROM carbon_zones ORDER BY code`)
+	if err != nil {
+		logging.From(ctx).Warn("Reading zones failed", "error", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var zones []ports.Zone
+	for rows.Next() {
+		var zone ports.Zone
+		var metadata []byte
+		if err := rows.Scan(&zone.Code, &zone.Name, &metadata); err != nil {
+			logging.From(ctx).Warn("Reading zones failed", "error", err)
+			return nil
+		}
+		if err := json.Unmarshal(metadata, &zone.ZoneMetadata); err != nil {
+			logging.From(ctx).Warn("Reading zone metadata failed", "zone", zone.Code, "error", err)
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
+// StoreZoneConfig inserts or replaces the configuration of the zone
+func (r *Repo) StoreZoneConfig(config ports.ZoneConfig, ctx context.Context) error {
+	var overrideIntensity sql.NullFloat64
+	var overrideSetAt sql.NullTime
+	if config.Override != nil {
+		overrideIntensity = sql.NullFloat64{Float64: config.Override.CarbonIntensity, Valid: true}
+		overrideSetAt = sql.NullTime{Time: config.Override.SetAt, Valid: true}
+	}
+	query := `INSERT INTO carbon_zone_configs (code, name, token_hash, sealed_token, removed, override_intensity, override_set_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, token_hash = EXCLUDED.token_hash, sealed_token = EXCLUDED.sealed_token,
+        removed = EXCLUDED.removed, override_intensity = EXCLUDED.override_intensity, override_set_at = EXCLUDED.override_set_at`
+	_, err := r.db.ExecContext(ctx, query, config.Code, config.Name, config.TokenHash, config.SealedToken, config.Removed,
+		overrideIntensity, overrideSetAt)
+	return err
+}
+
+func (r *Repo) GetZoneConfigs(ctx context.Context) ([]ports.ZoneConfig, error) {
+	query := `SELECT code, name, token_hash, sealed_token, removed, override_intensity, override_set_at FROM carbon_zone_configs ORDER BY code`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []ports.ZoneConfig
+	for rows.Next() {
+		var config ports.ZoneConfig
+		var overrideIntensity sql.NullFloat64
+		var overrideSetAt sql.NullTime
+		if err := rows.Scan(&config.Code, &config.Name, &config.TokenHash, &config.SealedToken, &config.Removed,
+			&overrideIntensity, &overrideSetAt); err != nil {
+			return nil, err
+		}
+		if overrideIntensity.Valid {
+			config.Override = &ports.Override{CarbonIntensity: overrideIntensity.Float64, SetAt: overrideSetAt.Time}
+		}
+		configs = append(configs, config)
+	}
+	return configs, rows.Err()
+}
+
+// scanData reads a reading selected with dataColumns
+func scanData(row interface{ Scan(dest ...any) error }) (ports.CarbonIntensityData, error) {
+	var data ports.CarbonIntensityData
+	var measuredAt sql.NullTime
+	if err := row.Scan(&data.Zone, &data.CarbonIntensity, &data.Source, &measuredAt, &data.FetchedAt, &data.IsEstimated,
+		&data.Type, &data.Scope); err != nil {
+		return ports.CarbonIntensityData{}, err
+	}
+	if measuredAt.Valid {
+		data.MeasuredAt = measuredAt.Time
+	}
+	return data, nil
+}
+
+// nullTime stores an unknown measurement time as NULL, the unique constraints treat NULLs as equal,
+// so repeated readings without measurement time update one row
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
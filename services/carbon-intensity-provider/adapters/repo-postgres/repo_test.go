@@ -0,0 +1,189 @@
+//go:build integration
+
+package repo_postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// newTestRepo connects to the test database configured like the service with DB_HOST, DB_PORT, DB_USER,
+// DB_PASSWORD, DB_NAME and SSL_MODE, e.g. the postgres of docker-compose.yaml. Every test gets its own schema
+// created by database/carbon-intensity-provider-init.sql, which is dropped afterwards.
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST not set, no test database")
+	}
+	sslMode, _ := strconv.ParseBool(os.Getenv("SSL_MODE"))
+	ctx := context.Background()
+	r, err := NewRepo(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"), sslMode, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one connection, so the search path applies to all queries of the test
+	r.db.SetMaxOpenConns(1)
+	schema := fmt.Sprintf("carbon_test_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		r.db.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`)
+		r.db.Close()
+	})
+	schemaSQL, err := os.ReadFile("../../../../database/carbon-intensity-provider-init.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{`CREATE SCHEMA ` + schema, `SET search_path TO ` + schema, string(schemaSQL)} {
+		if _, err := r.db.ExecContext(ctx, statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func countRows(t *testing.T, r *Repo, table string) int {
+	t.Helper()
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestRepo_StoreSameMeasurementOnce(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	measuredAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	fetchedAt := measuredAt.Add(time.Minute)
+
+	data := ports.CarbonIntensityData{Zone: "DE", CarbonIntensity: 300, Source: "electricity-maps", MeasuredAt: measuredAt, FetchedAt: fetchedAt}
+	if err := r.Store(data, ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the next fetch returns the same measurement with a corrected value
+	data.CarbonIntensity = 310
+	data.FetchedAt = fetchedAt.Add(5 * time.Minute)
+	if err := r.Store(data, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := countRows(t, r, "carbon_intensities"); count != 1 {
+		t.Errorf("expected the measurement to be stored once, got %d rows", count)
+	}
+	stored, err := r.FindById("DE", ports.DefaultEmissionFactor, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CarbonIntensity != 310 || !stored.MeasuredAt.Equal(measuredAt) || !stored.FetchedAt.Equal(data.FetchedAt) {
+		t.Errorf("expected updated reading, got %+v", stored)
+	}
+}
+
+func TestRepo_StoreWithoutMeasurementTime(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	fetchedAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, carbon := range []float64{100, 120} {
+		data := ports.CarbonIntensityData{Zone: "FR", CarbonIntensity: carbon, Source: "static", FetchedAt: fetchedAt.Add(time.Duration(i) * time.Minute)}
+		if err := r.Store(data, ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// NULLs are equal in the unique constraint, otherwise every fetch added a row
+	if count := countRows(t, r, "carbon_intensities"); count != 1 {
+		t.Errorf("expected readings without measurement time to update one row, got %d rows", count)
+	}
+	stored, err := r.FindById("FR", ports.DefaultEmissionFactor, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CarbonIntensity != 120 || !stored.MeasuredAt.IsZero() {
+		t.Errorf("expected latest reading without measurement time, got %+v", stored)
+	}
+}
+
+func TestRepo_FindLatestPerEmissionFactor(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	marginal := ports.EmissionFactor{Type: ports.TypeMarginal, Scope: ports.ScopeLifecycle}
+
+	readings := []ports.CarbonIntensityData{
+		{Zone: "DE", CarbonIntensity: 300, EmissionFactor: ports.DefaultEmissionFactor, MeasuredAt: start},
+		{Zone: "DE", CarbonIntensity: 280, EmissionFactor: ports.DefaultEmissionFactor, MeasuredAt: start.Add(time.Hour)},
+		{Zone: "DE", CarbonIntensity: 500, EmissionFactor: marginal, MeasuredAt: start},
+		{Zone: "FR", CarbonIntensity: 40, EmissionFactor: ports.DefaultEmissionFactor, MeasuredAt: start},
+	}
+	for _, data := range readings {
+		data.Source = "electricity-maps"
+		data.FetchedAt = data.MeasuredAt.Add(time.Minute)
+		if err := r.Store(data, ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := countRows(t, r, "carbon_intensities"); count != len(readings) {
+		t.Errorf("expected the history of %d readings, got %d rows", len(readings), count)
+	}
+	if data, err := r.FindById("DE", marginal, ctx); err != nil || data.CarbonIntensity != 500 {
+		t.Errorf("expected marginal intensity 500, got %+v %v", data, err)
+	}
+	if _, err := r.FindById("JP", ports.DefaultEmissionFactor, ctx); err != ports.ErrCarbonIntensityProviderNotFound {
+		t.Errorf("expected not found for unknown zone, got %v", err)
+	}
+
+	all, err := r.FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := map[string]float64{}
+	for _, data := range all {
+		latest[data.Zone+"/"+data.Type] = data.CarbonIntensity
+	}
+	expected := map[string]float64{"DE/average": 280, "DE/marginal": 500, "FR/average": 40}
+	if fmt.Sprint(latest) != fmt.Sprint(expected) {
+		t.Errorf("expected latest readings %v, got %v", expected, latest)
+	}
+}
+
+func TestRepo_Breakdown(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	measuredAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	breakdowns := []ports.PowerBreakdown{
+		{Zone: "DE", RenewablePercentage: 50, FossilFreePercentage: 55, ProductionMix: map[string]float64{"wind": 20000}, MeasuredAt: measuredAt},
+		{Zone: "DE", RenewablePercentage: 52, FossilFreePercentage: 57, ProductionMix: map[string]float64{"wind": 21000}, MeasuredAt: measuredAt},
+		{Zone: "DE", RenewablePercentage: 60, FossilFreePercentage: 65, ProductionMix: map[string]float64{"solar": 30000}, MeasuredAt: measuredAt.Add(time.Hour)},
+	}
+	for i, breakdown := range breakdowns {
+		breakdown.Source = "electricity-maps"
+		breakdown.FetchedAt = measuredAt.Add(time.Duration(i+1) * time.Minute)
+		if err := r.StoreBreakdown(breakdown, ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := countRows(t, r, "carbon_power_breakdowns"); count != 2 {
+		t.Errorf("expected 2 measurements, got %d rows", count)
+	}
+	latest, err := r.FindBreakdown("DE", ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.RenewablePercentage != 60 || latest.ProductionMix["solar"] != 30000 || !latest.MeasuredAt.Equal(measuredAt.Add(time.Hour)) {
+		t.Errorf("expected latest breakdown, got %+v", latest)
+	}
+	if _, err := r.FindBreakdown("FR", ctx); err != ports.ErrCarbonIntensityProviderNotFound {
+		t.Errorf("expected not found for zone without breakdown, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package zonemetadata
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// zones is the metadata of common Electricity Maps zones, used if no ZONE_METADATA file is set
+//
+//go:embed zones.json
+var zones []byte
+
+// Default returns the built-in metadata
+func Default() []ports.Zone {
+	var metadata []ports.Zone
+	if err := json.Unmarshal(zones, &metadata); err != nil {
+		panic(fmt.Sprintf("invalid built-in zone metadata: %v", err))
+	}
+	return metadata
+}
+
+// Load reads the metadata from a JSON file, a list of zones like the one of GET /carbon-intensity/zones
+func Load(file string) ([]ports.Zone, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var metadata []ports.Zone
+	if err := json.NewDecoder(f).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return metadata, nil
+}
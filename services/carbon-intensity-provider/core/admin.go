@@ -0,0 +1,244 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// OverrideSource is the source of data that was set manually with the admin API
+const OverrideSource = "override"
+
+var _ ports.ZoneAdmin = (*CarbonIntensityService)(nil)
+
+// LoadZones applies the zone configurations of the repo to the sources, stores the zones of the
+// sources with the added and without the removed ones and returns them. Call it after SetSources
+// and SetZoneMetadata.
+func (s *CarbonIntensityService) LoadZones(ctx context.Context) ([]ports.Zone, error) {
+	configs, err := s.repo.GetZoneConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.zoneConfigs = make(map[string]ports.ZoneConfig, len(configs))
+	for _, config := range configs {
+		s.zoneConfigs[config.Code] = config
+		s.applyToken(config)
+	}
+	s.mu.Unlock()
+
+	zones := s.SourceZones(ctx)
+	for _, config := range configs {
+		zones = withZoneConfig(zones, config)
+	}
+	return s.storeZones(zones, ctx)
+}
+
+func (s *CarbonIntensityService) GetZoneConfigs(ctx context.Context) ([]ports.ZoneConfig, error) {
+	configs, err := s.repo.GetZoneConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(configs, func(a, b ports.ZoneConfig) int { return strings.Compare(a.Code, b.Code) })
+	return configs, nil
+}
+
+func (s *CarbonIntensityService) AddZone(zone ports.Zone, token string, ctx context.Context) error {
+	zone.Code = strings.TrimSpace(zone.Code)
+	if zone.Code == "" || strings.ContainsAny(zone.Code, " ,/") {
+		return fmt.Errorf("%w: invalid zone code %q", ports.ErrInvalidZoneConfig, zone.Code)
+	}
+	if token != "" && !s.usesTokens() {
+		return fmt.Errorf("%w: no carbon source uses tokens", ports.ErrInvalidZoneConfig)
+	}
+
+	config := s.zoneConfig(zone.Code)
+	config.Removed = false
+	if zone.Name != "" {
+		config.Name = zone.Name
+	} else if config.Name == "" {
+		config.Name = zone.Code
+	}
+	if token != "" && hashToken(token) != config.TokenHash {
+		if err := s.sealToken(&config, token); err != nil {
+			return err
+		}
+	}
+	if err := s.storeZoneConfig(config, ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.startFetching(zone.Code)
+	s.mu.Unlock()
+	logging.From(ctx).Debug("Zone added", "zone", zone.Code, "token", token != "")
+	return nil
+}
+
+// RemoveZone stops fetching the zone and removes its token and override. The removal is kept,
+// so the zone does not come back from the environment after a restart.
+func (s *CarbonIntensityService) RemoveZone(code string, ctx context.Context) error {
+	if !s.isAvailable(code, ctx) {
+		return fmt.Errorf("%w: %s", ports.ErrZoneNotFound, code)
+	}
+
+	config := s.zoneConfig(code)
+	config.Removed = true
+	config.TokenHash = ""
+	config.SealedToken = ""
+	config.Override = nil
+	if err := s.storeZoneConfig(config, ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.stopFetching(code)
+	s.mu.Unlock()
+	logging.From(ctx).Debug("Zone removed", "zone", code)
+	return nil
+}
+
+// RotateToken replaces the token of the zone and fetches it right away, so a zone waiting after a
+// rejected token does not wait for its next interval. The same token again is only fetched.
+func (s *CarbonIntensityService) RotateToken(code string, token string, ctx context.Context) error {
+	if token == "" {
+		return fmt.Errorf("%w: token required", ports.ErrInvalidZoneConfig)
+	}
+	if !s.usesTokens() {
+		return fmt.Errorf("%w: no carbon source uses tokens", ports.ErrInvalidZoneConfig)
+	}
+	if !s.isAvailable(code, ctx) {
+		return fmt.Errorf("%w: %s", ports.ErrZoneNotFound, code)
+	}
+
+	config := s.zoneConfig(code)
+	if hashToken(token) != config.TokenHash {
+		if err := s.sealToken(&config, token); err != nil {
+			return err
+		}
+		if err := s.storeZoneConfig(config, ctx); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	if s.fetchRun != nil {
+		s.stopFetching(code)
+		s.startFetching(code)
+	}
+	s.mu.Unlock()
+	logging.From(ctx).Debug("Zone token rotated", "zone", code)
+	return nil
+}
+
+func (s *CarbonIntensityService) SetOverride(code string, intensity *float64, ctx context.Context) error {
+	if intensity != nil && *intensity < 0 {
+		return fmt.Errorf("%w: carbon intensity must not be negative", ports.ErrInvalidZoneConfig)
+	}
+	if !s.isAvailable(code, ctx) {
+		return fmt.Errorf("%w: %s", ports.ErrZoneNotFound, code)
+	}
+
+	config := s.zoneConfig(code)
+	config.Override = nil
+	if intensity != nil {
+		config.Override = &ports.Override{CarbonIntensity: *intensity, SetAt: time.Now()}
+	}
+	if err := s.storeZoneConfig(config, ctx); err != nil {
+		return err
+	}
+	logging.From(ctx).Debug("Zone override set", "zone", code, "override", config.Override)
+	return nil
+}
+
+// zoneConfig returns the configuration of the zone, a new one if it was not changed before
+func (s *CarbonIntensityService) zoneConfig(code string) ports.ZoneConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	config, ok := s.zoneConfigs[code]
+	if !ok {
+		return ports.ZoneConfig{Code: code}
+	}
+	return config
+}
+
+// storeZoneConfig persists the configuration, applies it and updates the stored zones
+func (s *CarbonIntensityService) storeZoneConfig(config ports.ZoneConfig, ctx context.Context) error {
+	if err := s.repo.StoreZoneConfig(config, ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.zoneConfigs == nil {
+		s.zoneConfigs = map[string]ports.ZoneConfig{}
+	}
+	s.zoneConfigs[config.Code] = config
+	s.applyToken(config)
+	s.mu.Unlock()
+
+	_, err := s.storeZones(withZoneConfig(s.repo.GetZones(ctx), config), ctx)
+	return err
+}
+
+// applyToken sets or removes the token of the zone in the token sources, s.mu must be held
+func (s *CarbonIntensityService) applyToken(config ports.ZoneConfig) {
+	for _, source := range s.sources {
+		tokenSource, ok := source.(ports.TokenSource)
+		if !ok {
+			continue
+		}
+		if config.Removed {
+			tokenSource.RemoveToken(config.Code)
+		} else if config.SealedToken != "" {
+			token, err := s.openToken(config)
+			if err != nil {
+				logging.Warn("Token of the zone not applied", "zone", config.Code, "error", err)
+				return
+			}
+			tokenSource.SetToken(config.Code, token)
+		}
+	}
+}
+
+// usesTokens reports whether a configured source takes tokens per zone
+func (s *CarbonIntensityService) usesTokens() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, source := range s.sources {
+		if _, ok := source.(ports.TokenSource); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *CarbonIntensityService) isAvailable(code string, ctx context.Context) bool {
+	return slices.ContainsFunc(s.repo.GetZones(ctx), func(z ports.Zone) bool { return z.Code == code })
+}
+
+// withZoneConfig returns the zones with the zone of the configuration added, renamed or removed.
+// A configuration without name keeps the name the zone has.
+func withZoneConfig(zones []ports.Zone, config ports.ZoneConfig) []ports.Zone {
+	name := config.Name
+	if i := slices.IndexFunc(zones, func(z ports.Zone) bool { return z.Code == config.Code }); i >= 0 {
+		if name == "" {
+			name = zones[i].Name
+		}
+		zones = slices.Delete(slices.Clone(zones), i, i+1)
+	}
+	if config.Removed {
+		return zones
+	}
+	if name == "" {
+		name = config.Code
+	}
+	return append(zones, ports.Zone{Code: config.Code, Name: name})
+}
@@ -0,0 +1,247 @@
+package core_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/core"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// MockTokenSource is a MockSource that only supports the zones it has a token for
+type MockTokenSource struct {
+	MockSource
+	tokens map[string]string
+}
+
+func (m *MockTokenSource) SetToken(zone string, token string) {
+	m.tokens[zone] = token
+}
+
+func (m *MockTokenSource) RemoveToken(zone string) {
+	delete(m.tokens, zone)
+}
+
+func (m *MockTokenSource) Zones(ctx context.Context) ([]ports.Zone, error) {
+	var zones []ports.Zone
+	for _, zone := range slices.Sorted(maps.Keys(m.tokens)) {
+		zones = append(zones, ports.Zone{Code: zone, Name: m.data[zone].Zone})
+	}
+	return zones, nil
+}
+
+var testTokenKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestLoadZones_AppliesStoredConfigs(t *testing.T) {
+	newSource := func() *MockTokenSource {
+		return &MockTokenSource{
+			MockSource: MockSource{name: "tokens", data: map[string]ports.CarbonIntensityData{
+				"DE": {Zone: "Germany"}, "FR": {Zone: "France"}, "PL": {Zone: "Poland"},
+			}},
+			tokens: map[string]string{"DE": "env", "FR": "env"},
+		}
+	}
+	newService := func(repo *MockRepo, source *MockTokenSource) *core.CarbonIntensityService {
+		service := core.NewCarbonIntensityService(repo)
+		if err := service.SetSources([]ports.CarbonSource{source}, core.SourcePriority{Default: []string{"tokens"}}, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.SetTokenKey(testTokenKey); err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+
+	// the configs are changed by one replica and loaded by another one
+	repo := &MockRepo{}
+	admin := newService(repo, newSource())
+	ctx := context.Background()
+	if _, err := admin.LoadZones(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.AddZone(ports.Zone{Code: "PL", Name: "Poland"}, "admin", ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.RotateToken("DE", "rotated", ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.RemoveZone("FR", ctx); err != nil {
+		t.Fatal(err)
+	}
+	for code, config := range repo.configs {
+		if strings.Contains(config.SealedToken, "admin") || strings.Contains(config.SealedToken, "rotated") {
+			t.Errorf("expected the token of %s to be encrypted, got %q", code, config.SealedToken)
+		}
+	}
+	if repo.configs["PL"].TokenHash != hashOf("admin") || repo.configs["DE"].TokenHash != hashOf("rotated") {
+		t.Errorf("expected the hashes of the tokens, got %+v", repo.configs)
+	}
+
+	source := newSource()
+	zones, err := newService(repo, source).LoadZones(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ports.Zone{{Code: "DE", Name: "Germany"}, {Code: "PL", Name: "Poland"}}
+	slices.SortFunc(zones, func(a, b ports.Zone) int { return strings.Compare(a.Code, b.Code) })
+	if !reflect.DeepEqual(zones, expected) {
+		t.Errorf("expected zones %v, got %v", expected, zones)
+	}
+	if source.tokens["DE"] != "rotated" || source.tokens["PL"] != "admin" || source.tokens["FR"] != "" {
+		t.Errorf("expected tokens of the configs, got %v", source.tokens)
+	}
+}
+
+func TestAdmin_TokensRequireKey(t *testing.T) {
+	repo := &MockRepo{zones: []ports.Zone{{Code: "DE", Name: "Germany"}}}
+	service := core.NewCarbonIntensityService(repo)
+	source := &MockTokenSource{MockSource: MockSource{name: "tokens"}, tokens: map[string]string{}}
+	if err := service.SetSources([]ports.CarbonSource{source}, core.SourcePriority{Default: []string{"tokens"}}, 0); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := service.RotateToken("DE", "token", ctx); !errors.Is(err, ports.ErrInvalidZoneConfig) {
+		t.Errorf("expected ErrInvalidZoneConfig without a token key, got %v", err)
+	}
+	if err := service.AddZone(ports.Zone{Code: "PL"}, "token", ctx); !errors.Is(err, ports.ErrInvalidZoneConfig) {
+		t.Errorf("expected ErrInvalidZoneConfig without a token key, got %v", err)
+	}
+	if len(repo.configs) != 0 || len(source.tokens) != 0 {
+		t.Errorf("expected no token to be stored, got %+v, %v", repo.configs, source.tokens)
+	}
+	if err := service.SetTokenKey([]byte("short")); err == nil {
+		t.Error("expected a key of less than 32 bytes to be refused")
+	}
+}
+
+// hashOf is the SHA-256 the repo keeps of a token
+func hashOf(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSetOverrideAndRemoveZone(t *testing.T) {
+	measured := time.Now().Add(-time.Minute)
+	repo := &MockRepo{
+		storage: map[string]ports.CarbonIntensityData{"DE": {Zone: "DE", CarbonIntensity: 300, Source: "primary", MeasuredAt: measured}},
+		zones:   []ports.Zone{{Code: "DE", Name: "Germany"}},
+	}
+	service := core.NewCarbonIntensityService(repo)
+	ctx := context.Background()
+
+	intensity := 42.0
+	if err := service.SetOverride("DE", &intensity, ctx); err != nil {
+		t.Fatal(err)
+	}
+	data, err := service.GetCarbonIntensityByZone("DE", ports.DefaultEmissionFactor, ctx)
+	if err != nil || data.CarbonIntensity != 42 || data.Source != core.OverrideSource || data.Stale {
+		t.Errorf("expected the override, got %+v, %v", data, err)
+	}
+	if repo.configs["DE"].Override == nil || repo.zones[0].Name != "Germany" {
+		t.Errorf("expected the override to be persisted and the name kept, got %+v, %v", repo.configs["DE"], repo.zones)
+	}
+
+	if err := service.SetOverride("DE", nil, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := service.GetCarbonIntensityByZone("DE", ports.DefaultEmissionFactor, ctx); data.CarbonIntensity != 300 {
+		t.Errorf("expected the stored data after removing the override, got %+v", data)
+	}
+
+	negative := -1.0
+	if err := service.SetOverride("DE", &negative, ctx); !errors.Is(err, ports.ErrInvalidZoneConfig) {
+		t.Errorf("expected ErrInvalidZoneConfig for a negative intensity, got %v", err)
+	}
+
+	if err := service.RemoveZone("DE", ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.GetCarbonIntensityByZone("DE", ports.DefaultEmissionFactor, ctx); !errors.Is(err, ports.ErrCarbonIntensityProviderNotFound) {
+		t.Errorf("expected no data for the removed zone, got %v", err)
+	}
+	if len(service.GetAvailableZones(ctx)) != 0 || !repo.configs["DE"].Removed {
+		t.Errorf("expected the removal to be persisted, got %v, %+v", repo.zones, repo.configs["DE"])
+	}
+	if err := service.SetOverride("DE", &intensity, ctx); !errors.Is(err, ports.ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound for the removed zone, got %v", err)
+	}
+	if err := service.RotateToken("DE", "token", ctx); !errors.Is(err, ports.ErrInvalidZoneConfig) {
+		t.Errorf("expected ErrInvalidZoneConfig without a token source, got %v", err)
+	}
+}
+
+func TestAdmin_ChangesZonesOfRunningFetcher(t *testing.T) {
+	service, requests := electricityMapsStandIn(t, map[string]http.HandlerFunc{
+		"DE": func(w http.ResponseWriter, r *http.Request) { writeIntensity(w, 100) },
+		"FR": func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("auth-token") != "rotated" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			writeIntensity(w, 50)
+		},
+	})
+	if err := service.SetTokenKey(testTokenKey); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := service.AddZone(ports.Zone{Code: "DE", Name: "Germany"}, "", ctx); err != nil {
+		t.Fatal(err)
+	}
+	go service.RunFetcher(ctx, []string{"DE"}, core.FetchConfig{
+		Interval:      20 * time.Millisecond,
+		ZoneIntervals: map[string]time.Duration{"FR": time.Hour},
+		MinBackoff:    time.Hour,
+		MaxBackoff:    time.Hour,
+	})
+	waitFor(service, ports.FetchOK, time.Second)
+
+	// an added zone is fetched without a restart
+	if err := service.AddZone(ports.Zone{Code: "FR", Name: "France"}, "", ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if health := service.GetFetchHealth(ctx); len(health) != 2 || health[1].Status != ports.FetchFailing {
+		t.Fatalf("expected FR to be fetched with the rejected token, got %+v", health)
+	}
+
+	// the rotated token is used right away instead of after the interval of an hour
+	if err := service.RotateToken("FR", "rotated", ctx); err != nil {
+		t.Fatal(err)
+	}
+	health := waitFor(service, ports.FetchOK, time.Second)
+	if len(health) != 2 || health[1].Status != ports.FetchOK {
+		t.Errorf("expected FR to be fetched with the rotated token, got %+v", health)
+	}
+	if data, err := service.GetCarbonIntensityByZone("FR", ports.DefaultEmissionFactor, ctx); err != nil || data.CarbonIntensity != 50 {
+		t.Errorf("expected data of FR, got %+v, %v", data, err)
+	}
+
+	// a removed zone is no longer fetched
+	if err := service.RemoveZone("DE", ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	n := len(requests("DE"))
+	time.Sleep(100 * time.Millisecond)
+	if len(requests("DE")) != n {
+		t.Errorf("expected DE not to be fetched after its removal")
+	}
+	if health := service.GetFetchHealth(ctx); len(health) != 1 || health[0].Zone != "FR" {
+		t.Errorf("expected only FR to be fetched, got %+v", health)
+	}
+	if len(requests("FR")) != 2 {
+		t.Errorf("expected FR to be fetched once per token, got %d requests", len(requests("FR")))
+	}
+}
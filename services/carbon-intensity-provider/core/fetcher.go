@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// Defaults of the FetchConfig
+const (
+	DefaultFetchInterval    = 60 * time.Second
+	DefaultFetchConcurrency = 4
+	DefaultFetchTimeout     = 10 * time.Second
+	DefaultMinBackoff       = 5 * time.Second
+	DefaultMaxBackoff       = 10 * time.Minute
+)
+
+// FetchConfig configures how often and how many zones are fetched at once. Zero values use the defaults.
+type FetchConfig struct {
+	Interval      time.Duration            // for all zones without an own interval
+	ZoneIntervals map[string]time.Duration // per zone, e.g. a zone whose token has a low rate limit
+	Concurrency   int                      // zones fetched at the same time
+	Timeout       time.Duration            // per fetch of a zone
+	MinBackoff    time.Duration            // first wait after a rate limited or failing API, doubled per failure
+	MaxBackoff    time.Duration
+}
+
+func (c FetchConfig) withDefaults() FetchConfig {
+	if c.Interval <= 0 {
+		c.Interval = DefaultFetchInterval
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = DefaultFetchConcurrency
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultFetchTimeout
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = DefaultMinBackoff
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		c.MaxBackoff = max(DefaultMaxBackoff, c.MinBackoff)
+	}
+	return c
+}
+
+func (c FetchConfig) intervalOf(zone string) time.Duration {
+	if interval, ok := c.ZoneIntervals[zone]; ok && interval > 0 {
+		return interval
+	}
+	return c.Interval
+}
+
+// fetchRun is the state of a running fetcher, zones added or removed at runtime start or stop their loop in it
+type fetchRun struct {
+	ctx    context.Context
+	config FetchConfig
+	slots  chan struct{}
+	wg     sync.WaitGroup
+	cancel map[string]context.CancelFunc
+}
+
+// RunFetcher fetches every zone at its interval until ctx is done. A zone does not wait for the others,
+// at most Concurrency zones are fetched at the same time. A rate limited (429) or failing (5xx) API and
+// timeouts are retried with exponential backoff and jitter, a Retry-After of the API is honoured.
+func (s *CarbonIntensityService) RunFetcher(ctx context.Context, zones []string, config FetchConfig) {
+	config = config.withDefaults()
+	run := &fetchRun{
+		ctx:    ctx,
+		config: config,
+		slots:  make(chan struct{}, config.Concurrency),
+		cancel: make(map[string]context.CancelFunc, len(zones)),
+	}
+
+	s.mu.Lock()
+	s.fetchRun = run
+	s.fetchHealth = make(map[string]*ports.ZoneFetchHealth, len(zones))
+	for _, zone := range zones {
+		s.startFetching(zone)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	s.mu.Lock()
+	s.fetchRun = nil // no zone is started after this
+	s.mu.Unlock()
+	run.wg.Wait()
+}
+
+// startFetching starts the loop of the zone if the fetcher runs, s.mu must be held
+func (s *CarbonIntensityService) startFetching(zone string) {
+	run := s.fetchRun
+	if run == nil || run.cancel[zone] != nil {
+		return
+	}
+	s.fetchHealth[zone] = &ports.ZoneFetchHealth{
+		Zone:            zone,
+		Status:          ports.FetchPending,
+		IntervalSeconds: run.config.intervalOf(zone).Seconds(),
+	}
+
+	ctx, cancel := context.WithCancel(run.ctx)
+	run.cancel[zone] = cancel
+	run.wg.Add(1)
+	go func() {
+		defer run.wg.Done()
+		s.fetchLoop(ctx, zone, run.config, run.slots)
+	}()
+}
+
+// stopFetching stops the loop of the zone and drops its health, s.mu must be held
+func (s *CarbonIntensityService) stopFetching(zone string) {
+	if run := s.fetchRun; run != nil && run.cancel[zone] != nil {
+		run.cancel[zone]()
+		delete(run.cancel, zone)
+	}
+	delete(s.fetchHealth, zone)
+}
+
+func (s *CarbonIntensityService) fetchLoop(ctx context.Context, zone string, config FetchConfig, slots chan struct{}) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case slots <- struct{}{}:
+		}
+		fetchCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+		_, err := s.FetchZone(zone, fetchCtx)
+		cancel()
+		<-slots
+
+		if ctx.Err() != nil {
+			return
+		}
+		timer.Reset(s.recordFetch(ctx, zone, err, config))
+	}
+}
+
+// recordFetch updates the health of the zone and returns the wait until its next fetch
+func (s *CarbonIntensityService) recordFetch(ctx context.Context, zone string, err error, config FetchConfig) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := config.intervalOf(zone)
+	health, ok := s.fetchHealth[zone]
+	if !ok {
+		return wait // removed while it was fetched
+	}
+	now := time.Now()
+	health.LastAttempt = now
+
+	if err == nil {
+		health.Status = ports.FetchOK
+		health.LastSuccess = now
+		health.LastError = ""
+		health.ConsecutiveFailures = 0
+	} else {
+		health.Status = ports.FetchFailing
+		health.LastError = err.Error()
+		health.ConsecutiveFailures++
+
+		var statusErr *ports.StatusError
+		if !errors.As(err, &statusErr) || statusErr.Retryable() {
+			wait = backoff(health.ConsecutiveFailures, config)
+			if statusErr != nil {
+				wait = max(wait, statusErr.RetryAfter)
+			}
+		}
+		logging.From(ctx).Warn("Fetching zone failed", "zone", zone, "failures", health.ConsecutiveFailures, "retryIn", wait, "error", err)
+	}
+	health.NextFetch = now.Add(wait)
+	return wait
+}
+
+// backoff doubles the wait per failure up to MaxBackoff, the jitter spreads it over its upper half
+// so zones that failed together are not retried together
+func backoff(failures int, config FetchConfig) time.Duration {
+	wait := config.MinBackoff
+	for i := 1; i < failures && wait < config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, config.MaxBackoff)
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// GetFetchHealth returns the fetch health of the zones ordered by zone, nil before the fetcher runs
+func (s *CarbonIntensityService) GetFetchHealth(ctx context.Context) []ports.ZoneFetchHealth {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []ports.ZoneFetchHealth
+	for _, health := range s.fetchHealth {
+		result = append(result, *health)
+	}
+	slices.SortFunc(result, func(a, b ports.ZoneFetchHealth) int {
+		return strings.Compare(a.Zone, b.Zone)
+	})
+	return result
+}
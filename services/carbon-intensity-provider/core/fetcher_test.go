@@ -0,0 +1,185 @@
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	electricitymaps "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/electricity-maps"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/core"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// electricityMapsStandIn serves /latest?zone=... with the handler of the zone and records the requests
+func electricityMapsStandIn(t *testing.T, handlers map[string]http.HandlerFunc) (*core.CarbonIntensityService, func(zone string) []time.Time) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := map[string][]time.Time{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zone := r.URL.Query().Get("zone")
+		mu.Lock()
+		requests[zone] = append(requests[zone], time.Now())
+		mu.Unlock()
+		handlers[zone](w, r)
+	}))
+	t.Cleanup(server.Close)
+	electricitymaps.FetchURL = server.URL + "/latest?zone=%s"
+
+	fetcher := electricitymaps.NewWithClient(server.Client())
+	for zone := range handlers {
+		fetcher.TokenByZone[zone] = "token"
+	}
+	service := core.NewCarbonIntensityService(&MockRepo{storage: map[string]ports.CarbonIntensityData{}})
+	if err := service.SetSources([]ports.CarbonSource{fetcher}, core.SourcePriority{Default: []string{electricitymaps.Name}}, 0); err != nil {
+		t.Fatal(err)
+	}
+	return service, func(zone string) []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), requests[zone]...)
+	}
+}
+
+func writeIntensity(w http.ResponseWriter, intensity float64) {
+	fmt.Fprintf(w, `{"carbonIntensity":%g,"datetime":"%s"}`, intensity, time.Now().UTC().Format(time.RFC3339))
+}
+
+// failFirst answers the first n requests with the status, the following ones with data
+func failFirst(n int, status int, retryAfter string) http.HandlerFunc {
+	var calls atomic.Int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= n {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		writeIntensity(w, 100)
+	}
+}
+
+// waitFor polls the fetch health until all zones are in the status or the timeout is reached
+func waitFor(service *core.CarbonIntensityService, status string, timeout time.Duration) []ports.ZoneFetchHealth {
+	deadline := time.Now().Add(timeout)
+	for {
+		health := service.GetFetchHealth(context.Background())
+		done := len(health) > 0
+		for _, zone := range health {
+			done = done && zone.Status == status
+		}
+		if done || time.Now().After(deadline) {
+			return health
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunFetcher_BackoffAndRetryAfter(t *testing.T) {
+	service, requests := electricityMapsStandIn(t, map[string]http.HandlerFunc{
+		"DE": failFirst(1, http.StatusTooManyRequests, "1"),
+		"FR": failFirst(2, http.StatusServiceUnavailable, ""),
+		"GB": failFirst(1, http.StatusUnauthorized, ""),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.RunFetcher(ctx, []string{"DE", "FR", "GB"}, core.FetchConfig{
+		Interval:      time.Hour,
+		ZoneIntervals: map[string]time.Duration{"GB": 300 * time.Millisecond},
+		MinBackoff:    20 * time.Millisecond,
+		MaxBackoff:    100 * time.Millisecond,
+	})
+
+	health := waitFor(service, ports.FetchOK, 3*time.Second)
+	for _, zone := range health {
+		if zone.Status != ports.FetchOK || zone.ConsecutiveFailures != 0 || zone.LastSuccess.IsZero() {
+			t.Errorf("%s: expected healthy zone, got %+v", zone.Zone, zone)
+		}
+	}
+
+	// the rate limited zone waits for Retry-After instead of the short backoff
+	de := requests("DE")
+	if len(de) != 2 || de[1].Sub(de[0]) < 900*time.Millisecond {
+		t.Errorf("expected retry of DE after 1s, got %v", de)
+	}
+	// the failing API is retried with backoff
+	fr := requests("FR")
+	if len(fr) != 3 || fr[2].Sub(fr[0]) > 500*time.Millisecond {
+		t.Errorf("expected 3 quick requests for FR, got %v", fr)
+	}
+	// a rejected token is not retried with backoff but at the interval of the zone
+	gb := requests("GB")
+	if len(gb) < 2 || gb[1].Sub(gb[0]) < 250*time.Millisecond {
+		t.Errorf("expected retry of GB at its interval, got %v", gb)
+	}
+}
+
+func TestRunFetcher_SlowZoneAndConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	fast := func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		writeIntensity(w, 50)
+	}
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}
+	service, requests := electricityMapsStandIn(t, map[string]http.HandlerFunc{"SLOW": slow, "A": fast, "B": fast, "C": fast})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.RunFetcher(ctx, []string{"SLOW", "A", "B", "C"}, core.FetchConfig{
+			Interval:    10 * time.Millisecond,
+			Concurrency: 2,
+			Timeout:     50 * time.Millisecond,
+			MinBackoff:  time.Hour,
+			MaxBackoff:  time.Hour,
+		})
+		close(done)
+	}()
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected fetcher to stop with the context")
+	}
+
+	// the slow zone times out and does not hold up the others
+	if n := len(requests("A")); n < 5 {
+		t.Errorf("expected A to be fetched at its interval, got %d requests", n)
+	}
+	if n := len(requests("SLOW")); n != 1 {
+		t.Errorf("expected SLOW to wait for its backoff, got %d requests", n)
+	}
+	if n := maxInFlight.Load(); n > 2 {
+		t.Errorf("expected at most 2 fetches at the same time, got %d", n)
+	}
+
+	for _, zone := range service.GetFetchHealth(context.Background()) {
+		if zone.Zone == "SLOW" {
+			if zone.Status != ports.FetchFailing || !strings.Contains(zone.LastError, "deadline exceeded") || zone.NextFetch.Before(time.Now().Add(30*time.Minute)) {
+				t.Errorf("expected SLOW to fail with a timeout and back off, got %+v", zone)
+			}
+		} else if zone.Status != ports.FetchOK {
+			t.Errorf("%s: expected healthy zone, got %+v", zone.Zone, zone)
+		}
+	}
+}
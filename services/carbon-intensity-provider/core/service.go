@@ -2,36 +2,125 @@ package core
 
 import (
 	"context"
+	"crypto/cipher"
+	"errors"
+	"sync"
+	"time"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
 )
 
 type CarbonIntensityService struct {
 	repo ports.Repo
+
+	mu        sync.RWMutex
+	sources   map[string]ports.CarbonSource
+	priority  SourcePriority
+	maxAge    time.Duration
+	factors   []ports.EmissionFactor
+	breakdown bool // fetch the power breakdown of the zones
+
+	fetchHealth map[string]*ports.ZoneFetchHealth
+	fetchRun    *fetchRun
+	zoneConfigs map[string]ports.ZoneConfig
+	tokenKey    cipher.AEAD // encrypts the tokens of the zone configs, nil if no key is set
+
+	zoneMetadata map[string]ports.Zone // by code
+	aliases      map[string]string     // code of the zone by alias
 }
 
 func NewCarbonIntensityService(repo ports.Repo) *CarbonIntensityService {
 	return &CarbonIntensityService{
-		repo: repo,
+		repo:    repo,
+		maxAge:  DefaultMaxAge,
+		factors: DefaultEmissionFactors,
 	}
 }
 
-func (s *CarbonIntensityService) GetCarbonIntensityByZone(zone string, ctx context.Context) (ports.CarbonIntensityData, error) {
-	data, err := s.repo.FindById(zone, ctx)
-	if err != nil {
-		return ports.CarbonIntensityData{}, err
+func (s *CarbonIntensityService) GetCarbonIntensityByZone(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	return s.readZone(zone, factor, ctx)
+}
+
+func (s *CarbonIntensityService) GetCarbonIntensities(zones []string, factor ports.EmissionFactor, ctx context.Context) ([]ports.CarbonIntensityData, error) {
+	result := make([]ports.CarbonIntensityData, 0, len(zones))
+	for _, zone := range zones {
+		data, err := s.readZone(zone, factor, ctx)
+		if errors.Is(err, ports.ErrCarbonIntensityProviderNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
 	}
+	return result, nil
+}
+
+// readZone returns the override of the zone or its stored data in the emission factor, both with the
+// latest power breakdown of the zone. The override is served for every emission factor. A removed zone
+// has no data. An alias is served with the data of its zone under the alias.
+func (s *CarbonIntensityService) readZone(requested string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	zone := s.resolveAlias(requested)
+	s.mu.RLock()
+	config := s.zoneConfigs[zone]
+	s.mu.RUnlock()
+
+	if config.Removed {
+		return ports.CarbonIntensityData{}, ports.ErrCarbonIntensityProviderNotFound
+	}
+	var data ports.CarbonIntensityData
+	if config.Override != nil {
+		data = ports.CarbonIntensityData{
+			Zone:            zone,
+			CarbonIntensity: config.Override.CarbonIntensity,
+			EmissionFactor:  factor,
+			Source:          OverrideSource,
+			MeasuredAt:      config.Override.SetAt,
+			FetchedAt:       config.Override.SetAt,
+		}
+	} else {
+		var err error
+		if data, err = s.repo.FindById(zone, factor, ctx); err != nil {
+			return ports.CarbonIntensityData{}, err
+		}
+		data.EmissionFactor = data.EmissionFactor.OrDefault() // stored before there were emission factors
+		data = s.markStale(data)
+	}
+
+	breakdown, err := s.repo.FindBreakdown(zone, ctx)
+	if err == nil {
+		data.PowerBreakdown = &breakdown
+	} else if !errors.Is(err, ports.ErrCarbonIntensityProviderNotFound) {
+		logging.From(ctx).Warn("Reading power breakdown failed", "zone", zone, "error", err)
+	}
+	data.Zone = requested
 	return data, nil
 }
 
+// markStale flags data that was measured longer than the max age ago. Data without a
+// measurement time is stale as well, its age is unknown.
+func (s *CarbonIntensityService) markStale(data ports.CarbonIntensityData) ports.CarbonIntensityData {
+	s.mu.RLock()
+	maxAge := s.maxAge
+	s.mu.RUnlock()
+
+	data.Stale = data.MeasuredAt.IsZero() || time.Since(data.MeasuredAt) > maxAge
+	return data
+}
+
 func (s *CarbonIntensityService) GetAvailableZones(ctx context.Context) []ports.Zone {
 	return s.repo.GetZones(ctx)
 }
 
 func (s *CarbonIntensityService) AddOrUpdateZone(zone string, intensity float64, ctx context.Context) error {
+	now := time.Now()
 	provider := ports.CarbonIntensityData{
 		Zone:            zone,
 		CarbonIntensity: intensity,
+		EmissionFactor:  ports.DefaultEmissionFactor,
+		MeasuredAt:      now,
+		FetchedAt:       now,
 	}
 
 	if err := s.repo.Store(provider, ctx); err != nil {
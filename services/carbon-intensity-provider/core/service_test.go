@@ -2,7 +2,9 @@ package core_test
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/core"
 	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
@@ -10,27 +12,42 @@ import (
 
 // MockRepo implements ports.Repo
 type MockRepo struct {
-	storage  map[string]ports.CarbonIntensityData
-	zones    []ports.Zone
-	storeErr error
+	mu         sync.Mutex
+	storage    map[string]ports.CarbonIntensityData
+	zones      []ports.Zone
+	configs    map[string]ports.ZoneConfig
+	breakdowns map[string]ports.PowerBreakdown
+	storeErr   error
 }
 
 func (m *MockRepo) Store(data ports.CarbonIntensityData, ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.storage == nil {
 		m.storage = make(map[string]ports.CarbonIntensityData)
 	}
 	if m.storeErr != nil {
 		return m.storeErr
 	}
-	m.storage[data.Zone] = data
+	m.storage[mockKey(data.Zone, data.EmissionFactor.OrDefault())] = data
 	return nil
 }
 
-func (m *MockRepo) FindById(id string, ctx context.Context) (ports.CarbonIntensityData, error) {
+// mockKey keys the default emission factor by zone, the others by zone/type/scope
+func mockKey(zone string, factor ports.EmissionFactor) string {
+	if factor == ports.DefaultEmissionFactor {
+		return zone
+	}
+	return zone + "/" + factor.String()
+}
+
+func (m *MockRepo) FindById(id string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.storage == nil {
 		return ports.CarbonIntensityData{}, ports.ErrCarbonIntensityProviderNotFound
 	}
-	data, ok := m.storage[id]
+	data, ok := m.storage[mockKey(id, factor)]
 	if !ok {
 		return ports.CarbonIntensityData{}, ports.ErrCarbonIntensityProviderNotFound
 	}
@@ -45,15 +62,59 @@ func (m *MockRepo) FindAll(ctx context.Context) ([]ports.CarbonIntensityData, er
 	return result, nil
 }
 
+func (m *MockRepo) StoreBreakdown(breakdown ports.PowerBreakdown, ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.breakdowns == nil {
+		m.breakdowns = make(map[string]ports.PowerBreakdown)
+	}
+	m.breakdowns[breakdown.Zone] = breakdown
+	return nil
+}
+
+func (m *MockRepo) FindBreakdown(zone string, ctx context.Context) (ports.PowerBreakdown, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	breakdown, ok := m.breakdowns[zone]
+	if !ok {
+		return ports.PowerBreakdown{}, ports.ErrCarbonIntensityProviderNotFound
+	}
+	return breakdown, nil
+}
+
 func (m *MockRepo) StoreZones(zones []ports.Zone, ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.zones = zones
 	return nil
 }
 
 func (m *MockRepo) GetZones(ctx context.Context) []ports.Zone {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.zones
 }
 
+func (m *MockRepo) StoreZoneConfig(config ports.ZoneConfig, ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.configs == nil {
+		m.configs = make(map[string]ports.ZoneConfig)
+	}
+	m.configs[config.Code] = config
+	return nil
+}
+
+func (m *MockRepo) GetZoneConfigs(ctx context.Context) ([]ports.ZoneConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []ports.ZoneConfig
+	for _, config := range m.configs {
+		result = append(result, config)
+	}
+	return result, nil
+}
+
 // MockNotifier implements ports.Notifier
 func TestAddOrUpdateZone_Success(t *testing.T) {
 	repo := &MockRepo{}
@@ -77,7 +138,7 @@ func TestGetCarbonIntensityByZone_Found(t *testing.T) {
 	}
 	service := core.NewCarbonIntensityService(repo)
 
-	data, err := service.GetCarbonIntensityByZone("FR", context.Background())
+	data, err := service.GetCarbonIntensityByZone("FR", ports.DefaultEmissionFactor, context.Background())
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -90,7 +151,7 @@ func TestGetCarbonIntensityByZone_NotFound(t *testing.T) {
 	repo := &MockRepo{}
 	service := core.NewCarbonIntensityService(repo)
 
-	_, err := service.GetCarbonIntensityByZone("NOPE", context.Background())
+	_, err := service.GetCarbonIntensityByZone("NOPE", ports.DefaultEmissionFactor, context.Background())
 	if err == nil {
 		t.Error("expected error for unknown zone")
 	}
@@ -110,3 +171,49 @@ func TestGetAvailableZones(t *testing.T) {
 		t.Errorf("expected 2 zones, got %d", len(zones))
 	}
 }
+
+func TestGetCarbonIntensities_SkipsUnknownZones(t *testing.T) {
+	repo := &MockRepo{
+		storage: map[string]ports.CarbonIntensityData{
+			"DE": {Zone: "DE", CarbonIntensity: 140.0},
+			"FR": {Zone: "FR", CarbonIntensity: 90.0},
+		},
+	}
+	service := core.NewCarbonIntensityService(repo)
+
+	data, err := service.GetCarbonIntensities([]string{"DE", "NOPE", "FR"}, ports.DefaultEmissionFactor, context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(data) != 2 || data[0].Zone != "DE" || data[1].Zone != "FR" {
+		t.Errorf("expected data for DE and FR, got %+v", data)
+	}
+}
+
+func TestGetCarbonIntensities_MarksStale(t *testing.T) {
+	now := time.Now()
+	repo := &MockRepo{
+		storage: map[string]ports.CarbonIntensityData{
+			"DE": {Zone: "DE", CarbonIntensity: 140.0, MeasuredAt: now.Add(-30 * time.Minute)},
+			"FR": {Zone: "FR", CarbonIntensity: 90.0, MeasuredAt: now.Add(-3 * time.Hour)},
+			"GB": {Zone: "GB", CarbonIntensity: 200.0}, // unknown age
+		},
+	}
+	service := core.NewCarbonIntensityService(repo)
+
+	data, err := service.GetCarbonIntensities([]string{"DE", "FR", "GB"}, ports.DefaultEmissionFactor, context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if data[0].Stale || !data[1].Stale || !data[2].Stale {
+		t.Errorf("expected only FR and GB to be stale, got %+v", data)
+	}
+
+	// a longer max age makes FR fresh again
+	if err := service.SetSources(nil, core.SourcePriority{}, 4*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if fr, _ := service.GetCarbonIntensityByZone("FR", ports.DefaultEmissionFactor, context.Background()); fr.Stale {
+		t.Errorf("expected FR to be fresh with a max age of 4h, got %+v", fr)
+	}
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"slices"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+var _ ports.Simulator = (*CarbonIntensityService)(nil)
+
+// SetScenario replaces the scenario of the simulation source. Its zones are added to the available
+// zones and fetched right away, so the data of the scenario is served once SetScenario returns.
+func (s *CarbonIntensityService) SetScenario(scenario ports.Scenario, ctx context.Context) error {
+	simulation := s.simulation()
+	if simulation == nil {
+		return ports.ErrSimulationDisabled
+	}
+	if err := simulation.SetScenario(scenario); err != nil {
+		return err
+	}
+
+	zones, _ := simulation.Zones(ctx)
+	known := s.repo.GetZones(ctx)
+	for _, zone := range zones {
+		if s.zoneConfig(zone.Code).Removed {
+			continue
+		}
+		if !slices.ContainsFunc(known, func(z ports.Zone) bool { return z.Code == zone.Code }) {
+			known = append(known, zone)
+		}
+
+		s.mu.Lock()
+		s.startFetching(zone.Code)
+		s.mu.Unlock()
+		if _, err := s.FetchZone(zone.Code, ctx); err != nil {
+			logging.From(ctx).Warn("Fetching simulated zone failed", "zone", zone.Code, "error", err)
+		}
+	}
+	if _, err := s.storeZones(known, ctx); err != nil {
+		return err
+	}
+	logging.From(ctx).Debug("Simulation scenario set", "zones", len(zones), "speed", scenario.Speed, "start", scenario.Start)
+	return nil
+}
+
+func (s *CarbonIntensityService) GetSimulation(ctx context.Context) (ports.SimulationStatus, error) {
+	simulation := s.simulation()
+	if simulation == nil {
+		return ports.SimulationStatus{}, ports.ErrSimulationDisabled
+	}
+	return simulation.Status(), nil
+}
+
+// simulation returns the configured simulation source, nil if there is none
+func (s *CarbonIntensityService) simulation() ports.SimulationSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, source := range s.sources {
+		if simulation, ok := source.(ports.SimulationSource); ok {
+			return simulation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/simulation"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/core"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+func TestSetScenario(t *testing.T) {
+	repo := &MockRepo{zones: []ports.Zone{{Code: "DE", Name: "Germany"}}}
+	service := core.NewCarbonIntensityService(repo)
+	ctx := context.Background()
+
+	if err := service.SetScenario(ports.Scenario{}, ctx); !errors.Is(err, ports.ErrSimulationDisabled) {
+		t.Errorf("expected ErrSimulationDisabled without simulation source, got %v", err)
+	}
+
+	err := service.SetSources([]ports.CarbonSource{simulation.New()}, core.SourcePriority{Default: []string{simulation.Name}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = service.SetScenario(ports.Scenario{Points: []ports.ScenarioPoint{
+		{Zone: "DE", At: "0h", CarbonIntensity: 300},
+		{Zone: "PL", Name: "Poland", At: "0h", CarbonIntensity: 700},
+	}}, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the data of the scenario is served right away
+	data, err := service.GetCarbonIntensities([]string{"DE", "PL"}, ports.DefaultEmissionFactor, ctx)
+	if err != nil || len(data) != 2 || data[0].CarbonIntensity != 300 || data[1].CarbonIntensity != 700 || data[1].Source != simulation.Name {
+		t.Errorf("expected the scenario values, got %+v, %v", data, err)
+	}
+	zones := service.GetAvailableZones(ctx)
+	if len(zones) != 2 || zones[0].Name != "Germany" || !reflect.DeepEqual(zones[1], ports.Zone{Code: "PL", Name: "Poland"}) {
+		t.Errorf("expected PL to be added to the zones, got %v", zones)
+	}
+
+	status, err := service.GetSimulation(ctx)
+	if err != nil || len(status.Scenario.Points) != 2 || status.Offset != "0s" {
+		t.Errorf("expected the paused scenario, got %+v, %v", status, err)
+	}
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// DefaultMaxAge is the age after which data is stale. The next source is asked for stale data
+// of a source, and stored data is returned with stale set.
+const DefaultMaxAge = 2 * time.Hour
+
+// DefaultEmissionFactors are fetched if no others are set. Electricity Maps has the average lifecycle
+// intensity and WattTime the marginal direct one, so no source makes an extra request per zone.
+var DefaultEmissionFactors = []ports.EmissionFactor{
+	ports.DefaultEmissionFactor,
+	{Type: ports.TypeMarginal, Scope: ports.ScopeDirect},
+}
+
+// SourcePriority orders the sources by name, the first one that has fresh data for a zone is used
+type SourcePriority struct {
+	Default []string            // for all zones without an own order
+	Zones   map[string][]string // per zone, e.g. GB prefers the UK Carbon Intensity API
+}
+
+// SetSources configures the sources the zones are fetched from. maxAge <= 0 uses DefaultMaxAge,
+// it is also the age after which stored data is returned as stale.
+func (s *CarbonIntensityService) SetSources(sources []ports.CarbonSource, priority SourcePriority, maxAge time.Duration) error {
+	byName := make(map[string]ports.CarbonSource, len(sources))
+	for _, source := range sources {
+		byName[source.Name()] = source
+	}
+	for _, names := range append([][]string{priority.Default}, slices.Collect(maps.Values(priority.Zones))...) {
+		for _, name := range names {
+			if _, ok := byName[name]; !ok {
+				return fmt.Errorf("unknown carbon source %q", name)
+			}
+		}
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources = byName
+	s.priority = priority
+	s.maxAge = maxAge
+	return nil
+}
+
+// SetEmissionFactors sets the emission factors every zone is fetched in, each one from the sources that have it.
+// An empty type or scope is the one of the default emission factor.
+func (s *CarbonIntensityService) SetEmissionFactors(factors []ports.EmissionFactor) error {
+	if len(factors) == 0 {
+		return fmt.Errorf("%w: no emission factor", ports.ErrInvalidEmissionFactor)
+	}
+	parsed := make([]ports.EmissionFactor, 0, len(factors))
+	for _, factor := range factors {
+		factor, err := ports.ParseEmissionFactor(factor.Type, factor.Scope)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(parsed, factor) {
+			parsed = append(parsed, factor)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.factors = parsed
+	return nil
+}
+
+// SetPowerBreakdown enables fetching the power breakdown of every zone from the first of its sources
+// that has one. It is off by default, Electricity Maps needs a request of its own for it.
+func (s *CarbonIntensityService) SetPowerBreakdown(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakdown = enabled
+}
+
+// sourcesOf returns the sources of the zone in the order they are asked
+func (s *CarbonIntensityService) sourcesOf(zone string) []ports.CarbonSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names, ok := s.priority.Zones[zone]
+	if !ok {
+		names = s.priority.Default
+	}
+	sources := make([]ports.CarbonSource, 0, len(names))
+	for _, name := range names {
+		sources = append(sources, s.sources[name])
+	}
+	return sources
+}
+
+// FetchZone fetches every emission factor of the zone and returns the data of the first one that was
+// fetched. It fails if a factor that a source has could not be fetched, or if no source has the zone.
+// The power breakdown is fetched as well if it is enabled, it does not fail the fetch.
+func (s *CarbonIntensityService) FetchZone(zone string, ctx context.Context) (ports.CarbonIntensityData, error) {
+	s.mu.RLock()
+	factors := s.factors
+	breakdown := s.breakdown
+	s.mu.RUnlock()
+
+	if breakdown {
+		s.fetchBreakdown(zone, ctx)
+	}
+
+	var first *ports.CarbonIntensityData
+	var errs []error
+	for _, factor := range factors {
+		data, err := s.fetchFactor(zone, factor, ctx)
+		if errors.Is(err, ports.ErrZoneNotSupported) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", factor, err))
+		} else if first == nil {
+			first = &data
+		}
+	}
+
+	if first == nil && len(errs) == 0 {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: no source for zone %s", ports.ErrZoneNotSupported, zone)
+	}
+	if first == nil {
+		return ports.CarbonIntensityData{}, errors.Join(errs...)
+	}
+	return *first, errors.Join(errs...)
+}
+
+// fetchFactor asks the sources of the zone in order of priority and stores the first fresh data in the
+// emission factor. A source that fails or only has stale data falls back to the next one. If no source
+// has fresh data, the newest stale data is stored.
+func (s *CarbonIntensityService) fetchFactor(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	s.mu.RLock()
+	maxAge := s.maxAge
+	s.mu.RUnlock()
+
+	var stale *ports.CarbonIntensityData
+	var errs []error
+	for _, source := range s.sourcesOf(zone) {
+		data, err := source.Fetch(zone, factor, ctx)
+		if errors.Is(err, ports.ErrZoneNotSupported) {
+			continue
+		}
+		data.FetchedAt = time.Now()
+		if err != nil {
+			logging.From(ctx).Warn("Carbon source failed", "source", source.Name(), "zone", zone, "factor", factor, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			continue
+		}
+		if time.Since(data.MeasuredAt) > maxAge {
+			logging.From(ctx).Warn("Carbon source has stale data", "source", source.Name(), "zone", zone, "measuredAt", data.MeasuredAt)
+			if stale == nil || data.MeasuredAt.After(stale.MeasuredAt) {
+				stale = &data
+			}
+			continue
+		}
+		return data, s.repo.Store(data, ctx)
+	}
+
+	if stale != nil {
+		return *stale, s.repo.Store(*stale, ctx)
+	}
+	if len(errs) == 0 {
+		return ports.CarbonIntensityData{}, fmt.Errorf("%w: no source for zone %s %s", ports.ErrZoneNotSupported, zone, factor)
+	}
+	return ports.CarbonIntensityData{}, errors.Join(errs...)
+}
+
+// fetchBreakdown stores the power breakdown of the first source of the zone that has one
+func (s *CarbonIntensityService) fetchBreakdown(zone string, ctx context.Context) {
+	for _, source := range s.sourcesOf(zone) {
+		breakdownSource, ok := source.(ports.BreakdownSource)
+		if !ok {
+			continue
+		}
+		breakdown, err := breakdownSource.FetchBreakdown(zone, ctx)
+		if errors.Is(err, ports.ErrZoneNotSupported) {
+			continue
+		}
+		if err != nil {
+			logging.From(ctx).Warn("Fetching power breakdown failed", "source", source.Name(), "zone", zone, "error", err)
+			continue
+		}
+		breakdown.FetchedAt = time.Now()
+		if err := s.repo.StoreBreakdown(breakdown, ctx); err != nil {
+			logging.From(ctx).Warn("Storing power breakdown failed", "zone", zone, "error", err)
+		}
+		return
+	}
+}
+
+// SourceZones returns the zones of all sources, a zone of several sources is named by the first one
+func (s *CarbonIntensityService) SourceZones(ctx context.Context) []ports.Zone {
+	s.mu.RLock()
+	names := slices.Clone(s.priority.Default)
+	for _, zoneNames := range s.priority.Zones {
+		names = append(names, zoneNames...)
+	}
+	sources := s.sources
+	s.mu.RUnlock()
+
+	var zones []ports.Zone
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		sourceZones, err := sources[name].Zones(ctx)
+		if err != nil {
+			logging.From(ctx).Warn("Reading zones of carbon source failed", "source", name, "error", err)
+		}
+		for _, zone := range sourceZones {
+			if !slices.ContainsFunc(zones, func(z ports.Zone) bool { return z.Code == zone.Code }) {
+				zones = append(zones, zone)
+			}
+		}
+	}
+	return zones
+}
@@ -0,0 +1,242 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/core"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+func init() {
+	logging.Init("carbon-intensity-provider-test")
+}
+
+// MockSource implements ports.CarbonSource with fixed data per zone in one emission factor,
+// the default one if factor is not set
+type MockSource struct {
+	name   string
+	data   map[string]ports.CarbonIntensityData
+	err    error
+	factor ports.EmissionFactor
+}
+
+func (m *MockSource) Name() string {
+	return m.name
+}
+
+func (m *MockSource) Fetch(zone string, factor ports.EmissionFactor, ctx context.Context) (ports.CarbonIntensityData, error) {
+	if factor != m.factor.OrDefault() {
+		return ports.CarbonIntensityData{}, ports.ErrZoneNotSupported
+	}
+	if m.err != nil {
+		return ports.CarbonIntensityData{}, m.err
+	}
+	data, ok := m.data[zone]
+	if !ok {
+		return ports.CarbonIntensityData{}, ports.ErrZoneNotSupported
+	}
+	data.Zone = zone
+	data.Source = m.name
+	data.EmissionFactor = factor
+	return data, nil
+}
+
+func (m *MockSource) Zones(ctx context.Context) ([]ports.Zone, error) {
+	var zones []ports.Zone
+	for zone := range m.data {
+		zones = append(zones, ports.Zone{Code: zone, Name: zone})
+	}
+	return zones, nil
+}
+
+func TestFetchZone_Fallback(t *testing.T) {
+	now := time.Now()
+	primary := &MockSource{name: "primary", data: map[string]ports.CarbonIntensityData{
+		"DE": {CarbonIntensity: 100, MeasuredAt: now},
+		"FR": {CarbonIntensity: 50, MeasuredAt: now.Add(-3 * time.Hour)}, // stale
+	}}
+	secondary := &MockSource{name: "secondary", data: map[string]ports.CarbonIntensityData{
+		"DE": {CarbonIntensity: 110, MeasuredAt: now},
+		"FR": {CarbonIntensity: 60, MeasuredAt: now},
+		"GB": {CarbonIntensity: 200, MeasuredAt: now},
+	}}
+	repo := &MockRepo{}
+	service := core.NewCarbonIntensityService(repo)
+	err := service.SetSources([]ports.CarbonSource{primary, secondary}, core.SourcePriority{
+		Default: []string{"primary", "secondary"},
+		Zones:   map[string][]string{"GB": {"secondary"}},
+	}, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		zone     string
+		expected string
+	}{
+		{"DE", "primary"},   // first source with fresh data
+		{"FR", "secondary"}, // primary is stale
+		{"GB", "secondary"}, // own order, primary has no data
+	}
+	for _, tc := range tests {
+		data, err := service.FetchZone(tc.zone, context.Background())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.zone, err)
+		}
+		if data.Source != tc.expected || repo.storage[tc.zone].Source != tc.expected || repo.storage[tc.zone].FetchedAt.IsZero() {
+			t.Errorf("%s: expected data of %s to be stored, got %+v", tc.zone, tc.expected, repo.storage[tc.zone])
+		}
+	}
+
+	// a failing source falls back as well
+	primary.err = errors.New("API returned status: 500")
+	data, err := service.FetchZone("DE", context.Background())
+	if err != nil || data.Source != "secondary" {
+		t.Errorf("expected fallback to secondary, got %+v, %v", data, err)
+	}
+}
+
+func TestFetchZone_StaleAndFailed(t *testing.T) {
+	now := time.Now()
+	older := &MockSource{name: "older", data: map[string]ports.CarbonIntensityData{"DE": {CarbonIntensity: 100, MeasuredAt: now.Add(-5 * time.Hour)}}}
+	newer := &MockSource{name: "newer", data: map[string]ports.CarbonIntensityData{"DE": {CarbonIntensity: 90, MeasuredAt: now.Add(-3 * time.Hour)}}}
+	failing := &MockSource{name: "failing", err: errors.New("connection refused")}
+
+	service := core.NewCarbonIntensityService(&MockRepo{})
+	if err := service.SetSources([]ports.CarbonSource{older, newer}, core.SourcePriority{Default: []string{"older", "newer"}}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// no fresh data, the newest stale data is better than none
+	data, err := service.FetchZone("DE", context.Background())
+	if err != nil || data.Source != "newer" {
+		t.Errorf("expected stale data of newer, got %+v, %v", data, err)
+	}
+
+	if _, err := service.FetchZone("FR", context.Background()); !errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected ErrZoneNotSupported for zone without source, got %v", err)
+	}
+
+	// only failing sources
+	if err := service.SetSources([]ports.CarbonSource{failing}, core.SourcePriority{Default: []string{"failing"}}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.FetchZone("DE", context.Background()); err == nil || errors.Is(err, ports.ErrZoneNotSupported) {
+		t.Errorf("expected error of the failing source, got %v", err)
+	}
+}
+
+func TestSetSources_UnknownSource(t *testing.T) {
+	service := core.NewCarbonIntensityService(&MockRepo{})
+	err := service.SetSources([]ports.CarbonSource{&MockSource{name: "primary"}}, core.SourcePriority{
+		Default: []string{"primary"},
+		Zones:   map[string][]string{"GB": {"unknown"}},
+	}, 0)
+	if err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+func TestFetchZone_EmissionFactors(t *testing.T) {
+	now := time.Now()
+	marginal := ports.EmissionFactor{Type: ports.TypeMarginal, Scope: ports.ScopeDirect}
+	average := &MockSource{name: "average", data: map[string]ports.CarbonIntensityData{"DE": {CarbonIntensity: 300, MeasuredAt: now}}}
+	moer := &MockSource{name: "moer", factor: marginal, data: map[string]ports.CarbonIntensityData{"DE": {CarbonIntensity: 700, MeasuredAt: now}}}
+
+	repo := &MockRepo{}
+	service := core.NewCarbonIntensityService(repo)
+	if err := service.SetSources([]ports.CarbonSource{average, moer}, core.SourcePriority{Default: []string{"average", "moer"}}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// every factor is fetched from the source that has it
+	data, err := service.FetchZone("DE", context.Background())
+	if err != nil || data.CarbonIntensity != 300 {
+		t.Fatalf("expected the average intensity first, got %+v, %v", data, err)
+	}
+	for factor, expected := range map[ports.EmissionFactor]float64{ports.DefaultEmissionFactor: 300, marginal: 700} {
+		data, err := service.GetCarbonIntensityByZone("DE", factor, context.Background())
+		if err != nil || data.CarbonIntensity != expected || data.EmissionFactor != factor {
+			t.Errorf("%s: expected %g, got %+v, %v", factor, expected, data, err)
+		}
+	}
+	direct := ports.EmissionFactor{Type: ports.TypeAverage, Scope: ports.ScopeDirect}
+	if _, err := service.GetCarbonIntensityByZone("DE", direct, context.Background()); !errors.Is(err, ports.ErrCarbonIntensityProviderNotFound) {
+		t.Errorf("expected no data for a factor that is not fetched, got %v", err)
+	}
+
+	// a failing factor fails the fetch, the other factors are stored anyway
+	moer.err = errors.New("API returned status: 500")
+	average.data["DE"] = ports.CarbonIntensityData{CarbonIntensity: 250, MeasuredAt: now}
+	if data, err := service.FetchZone("DE", context.Background()); err == nil || data.CarbonIntensity != 250 {
+		t.Errorf("expected the average intensity with the error of the marginal one, got %+v, %v", data, err)
+	}
+
+	if err := service.SetEmissionFactors([]ports.EmissionFactor{{Type: "peak"}}); !errors.Is(err, ports.ErrInvalidEmissionFactor) {
+		t.Errorf("expected ErrInvalidEmissionFactor, got %v", err)
+	}
+}
+
+// MockBreakdownSource is a MockSource with a power breakdown per zone
+type MockBreakdownSource struct {
+	MockSource
+	breakdowns map[string]ports.PowerBreakdown
+	calls      int
+}
+
+func (m *MockBreakdownSource) FetchBreakdown(zone string, ctx context.Context) (ports.PowerBreakdown, error) {
+	m.calls++
+	if m.err != nil {
+		return ports.PowerBreakdown{}, m.err
+	}
+	breakdown, ok := m.breakdowns[zone]
+	if !ok {
+		return ports.PowerBreakdown{}, ports.ErrZoneNotSupported
+	}
+	breakdown.Zone = zone
+	breakdown.Source = m.name
+	return breakdown, nil
+}
+
+func TestFetchZone_PowerBreakdown(t *testing.T) {
+	now := time.Now()
+	primary := &MockSource{name: "primary", data: map[string]ports.CarbonIntensityData{"DE": {CarbonIntensity: 300, MeasuredAt: now}}}
+	secondary := &MockBreakdownSource{
+		MockSource: MockSource{name: "secondary", data: map[string]ports.CarbonIntensityData{"DE": {CarbonIntensity: 310, MeasuredAt: now}}},
+		breakdowns: map[string]ports.PowerBreakdown{"DE": {RenewablePercentage: 55, FossilFreePercentage: 60, ProductionMix: map[string]float64{"wind": 20000}}},
+	}
+	repo := &MockRepo{}
+	service := core.NewCarbonIntensityService(repo)
+	if err := service.SetSources([]ports.CarbonSource{primary, secondary}, core.SourcePriority{Default: []string{"primary", "secondary"}}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// off by default
+	if _, err := service.FetchZone("DE", context.Background()); err != nil || secondary.calls != 0 {
+		t.Fatalf("expected no breakdown request, got %d, %v", secondary.calls, err)
+	}
+
+	// the breakdown comes from the first source that has one, the intensity is still the one of primary
+	service.SetPowerBreakdown(true)
+	if _, err := service.FetchZone("DE", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := service.GetCarbonIntensityByZone("DE", ports.DefaultEmissionFactor, context.Background())
+	if err != nil || data.Source != "primary" || data.PowerBreakdown == nil || data.PowerBreakdown.RenewablePercentage != 55 ||
+		data.PowerBreakdown.Source != "secondary" || data.PowerBreakdown.FetchedAt.IsZero() {
+		t.Errorf("expected intensity of primary with breakdown of secondary, got %+v, %v", data, err)
+	}
+
+	// a failing breakdown does not fail the fetch, the last breakdown is kept
+	secondary.err = errors.New("API returned status: 500")
+	if _, err := service.FetchZone("DE", context.Background()); err != nil {
+		t.Errorf("expected the fetch to succeed without breakdown, got %v", err)
+	}
+	if data, _ := service.GetCarbonIntensityByZone("DE", ports.DefaultEmissionFactor, context.Background()); data.PowerBreakdown == nil {
+		t.Errorf("expected the last breakdown, got %+v", data)
+	}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// SetTokenKey sets the 32 byte key the tokens of the admin API are encrypted with before they are stored.
+// The sources send the tokens to their APIs, so the repo cannot keep a hash only. Without a key the admin
+// API does not accept tokens.
+func (s *CarbonIntensityService) SetTokenKey(key []byte) error {
+	if len(key) != 32 {
+		return errors.New("token key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenKey = aead
+	return nil
+}
+
+// sealToken stores the token encrypted and its hash in the configuration, s.mu must not be held
+func (s *CarbonIntensityService) sealToken(config *ports.ZoneConfig, token string) error {
+	s.mu.RLock()
+	aead := s.tokenKey
+	s.mu.RUnlock()
+	if aead == nil {
+		return fmt.Errorf("%w: tokens cannot be stored without ZONE_TOKEN_KEY", ports.ErrInvalidZoneConfig)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	// the zone is authenticated with the token, a sealed token cannot be moved to another zone
+	config.SealedToken = base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(token), []byte(config.Code)))
+	config.TokenHash = hashToken(token)
+	return nil
+}
+
+// openToken decrypts the token of the configuration, s.mu must be held
+func (s *CarbonIntensityService) openToken(config ports.ZoneConfig) (string, error) {
+	if s.tokenKey == nil {
+		return "", errors.New("ZONE_TOKEN_KEY is not set")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(config.SealedToken)
+	if err != nil || len(sealed) < s.tokenKey.NonceSize() {
+		return "", errors.New("invalid sealed token")
+	}
+	nonce, ciphertext := sealed[:s.tokenKey.NonceSize()], sealed[s.tokenKey.NonceSize():]
+	token, err := s.tokenKey.Open(nil, nonce, ciphertext, []byte(config.Code))
+	if err != nil {
+		return "", fmt.Errorf("decrypting token failed, ZONE_TOKEN_KEY changed? %w", err)
+	}
+	return string(token), nil
+}
+
+// hashToken identifies a token without revealing it, e.g. to tell if a rotated token is a new one
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+// SetZoneMetadata sets the metadata the available zones are stored with. An alias of a zone is
+// served like the zone itself, e.g. UK for GB.
+func (s *CarbonIntensityService) SetZoneMetadata(zones []ports.Zone) error {
+	metadata := make(map[string]ports.Zone, len(zones))
+	aliases := map[string]string{}
+	for _, zone := range zones {
+		if strings.TrimSpace(zone.Code) == "" {
+			return fmt.Errorf("%w: zone without code", ports.ErrInvalidZoneMetadata)
+		}
+		if _, ok := metadata[zone.Code]; ok {
+			return fmt.Errorf("%w: zone %s set twice", ports.ErrInvalidZoneMetadata, zone.Code)
+		}
+		if c := zone.Coordinates; c != nil && (c.Latitude < -90 || c.Latitude > 90 || c.Longitude < -180 || c.Longitude > 180) {
+			return fmt.Errorf("%w: invalid coordinates of zone %s", ports.ErrInvalidZoneMetadata, zone.Code)
+		}
+		metadata[zone.Code] = zone
+		for _, alias := range zone.Aliases {
+			if code, ok := aliases[alias]; ok {
+				return fmt.Errorf("%w: alias %s of zone %s and %s", ports.ErrInvalidZoneMetadata, alias, code, zone.Code)
+			}
+			aliases[alias] = zone.Code
+		}
+	}
+	for alias, code := range aliases {
+		if _, ok := metadata[alias]; ok {
+			return fmt.Errorf("%w: alias %s of zone %s is a zone itself", ports.ErrInvalidZoneMetadata, alias, code)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zoneMetadata = metadata
+	s.aliases = aliases
+	return nil
+}
+
+// resolveAlias returns the code of the zone the alias stands for, other codes are returned as they are
+func (s *CarbonIntensityService) resolveAlias(zone string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if code, ok := s.aliases[zone]; ok {
+		return code
+	}
+	return zone
+}
+
+// storeZones stores the zones with their metadata and returns them. The metadata names a zone that is
+// only named by its code.
+func (s *CarbonIntensityService) storeZones(zones []ports.Zone, ctx context.Context) ([]ports.Zone, error) {
+	s.mu.RLock()
+	enriched := make([]ports.Zone, 0, len(zones))
+	for _, zone := range zones {
+		if metadata, ok := s.zoneMetadata[zone.Code]; ok {
+			zone.ZoneMetadata = metadata.ZoneMetadata
+			if (zone.Name == "" || zone.Name == zone.Code) && metadata.Name != "" {
+				zone.Name = metadata.Name
+			}
+		}
+		enriched = append(enriched, zone)
+	}
+	s.mu.RUnlock()
+
+	return enriched, s.repo.StoreZones(enriched, ctx)
+}
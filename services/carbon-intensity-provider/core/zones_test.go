@@ -0,0 +1,65 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	zonemetadata "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/zone-metadata"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/core"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
+)
+
+func TestSetZoneMetadata(t *testing.T) {
+	now := time.Now()
+	source := &MockSource{name: "primary", data: map[string]ports.CarbonIntensityData{
+		"GB": {CarbonIntensity: 180, MeasuredAt: now},
+		"XX": {CarbonIntensity: 400, MeasuredAt: now},
+	}}
+	repo := &MockRepo{}
+	service := core.NewCarbonIntensityService(repo)
+	if err := service.SetSources([]ports.CarbonSource{source}, core.SourcePriority{Default: []string{"primary"}}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.SetZoneMetadata(zonemetadata.Default()); err != nil {
+		t.Fatalf("expected valid built-in metadata, got %v", err)
+	}
+
+	// zones are stored with their metadata, a zone without metadata as it is
+	zones, err := service.LoadZones(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(repo.zones, func(z ports.Zone) bool { return z.Code == "GB" })
+	if len(zones) != 2 || i < 0 || repo.zones[i].Name != "Great Britain" || repo.zones[i].Continent != "Europe" ||
+		repo.zones[i].Coordinates == nil || !slices.Contains(repo.zones[i].Neighbours, "FR") {
+		t.Errorf("expected GB with its metadata, got %+v", repo.zones)
+	}
+	if i := slices.IndexFunc(repo.zones, func(z ports.Zone) bool { return z.Code == "XX" }); i < 0 || repo.zones[i].Name != "XX" || repo.zones[i].Country != "" {
+		t.Errorf("expected XX without metadata, got %+v", repo.zones)
+	}
+
+	// an alias is served with the data of its zone
+	if _, err := service.FetchZone("GB", context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := service.GetCarbonIntensityByZone("UK", ports.DefaultEmissionFactor, context.Background())
+	if err != nil || data.Zone != "UK" || data.CarbonIntensity != 180 {
+		t.Errorf("expected the data of GB for UK, got %+v, %v", data, err)
+	}
+
+	invalid := [][]ports.Zone{
+		{{Code: ""}},
+		{{Code: "DE"}, {Code: "DE"}},
+		{{Code: "DE", ZoneMetadata: ports.ZoneMetadata{Coordinates: &ports.Coordinates{Latitude: 91}}}},
+		{{Code: "GB", ZoneMetadata: ports.ZoneMetadata{Aliases: []string{"UK"}}}, {Code: "UK"}},
+		{{Code: "GB", ZoneMetadata: ports.ZoneMetadata{Aliases: []string{"UK"}}}, {Code: "IE", ZoneMetadata: ports.ZoneMetadata{Aliases: []string{"UK"}}}},
+	}
+	for _, metadata := range invalid {
+		if err := service.SetZoneMetadata(metadata); !errors.Is(err, ports.ErrInvalidZoneMetadata) {
+			t.Errorf("%+v: expected ErrInvalidZoneMetadata, got %v", metadata, err)
+		}
+	}
+}
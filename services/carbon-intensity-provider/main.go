@@ -2,18 +2,28 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/auth"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/tracing/tracing"
 	handler "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/handler-http"
 	electricitymaps "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/electricity-maps"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/simulation"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/static"
+	ukcarbonintensity "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/uk-carbon-intensity"
+	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/provider/watttime"
 	repo "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/repo-in-memory"
+	repo_postgres "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/repo-postgres"
+	zonemetadata "github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/adapters/zone-metadata"
 	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/core"
 	"github.com/informatik-mannheim/cmg-ss2025/services/carbon-intensity-provider/ports"
 )
@@ -36,72 +46,55 @@ func main() {
 	rootCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	r := repo.NewRepo()
+	r := newRepo(rootCtx)
 	s := core.NewCarbonIntensityService(r)
 
-	if os.Getenv("USE_LIVE") == "true" {
-		logging.Debug("[Mode] Live fetch enabled")
-		fetcher := electricitymaps.NewFromEnv()
+	sources, priority := carbonSources()
+	maxAge, _ := time.ParseDuration(os.Getenv("SOURCE_MAX_AGE"))
+	if err := s.SetSources(sources, priority, maxAge); err != nil {
+		logging.Error("Failed to configure carbon sources: " + err.Error())
+		os.Exit(1)
+	}
+	logging.Debug("Carbon sources configured", "sources", priority.Default, "zones", priority.Zones)
 
-		detailedZones, err := fetcher.AllElectricityMapZones(rootCtx)
-		if err != nil {
-			errormessage := "Failed to fetch zone metadata: " + err.Error()
-			logging.Error(errormessage)
+	if factors := os.Getenv("EMISSION_FACTORS"); factors != "" {
+		if err := s.SetEmissionFactors(emissionFactors(factors)); err != nil {
+			logging.Error("Failed to configure emission factors: " + err.Error())
+			os.Exit(1)
 		}
+	}
 
-		// Filter to only zones we have a token for
-		zones := make([]ports.Zone, 0)
-		for _, z := range detailedZones {
-			if _, ok := fetcher.TokenByZone[z.Code]; ok {
-				zones = append(zones, ports.Zone{Code: z.Code, Name: z.Name})
-			}
-		}
+	s.SetPowerBreakdown(os.Getenv("POWER_BREAKDOWN") == "true")
 
-		if err := r.StoreZones(zones, rootCtx); err != nil {
-			logging.Error("Failed to store filtered zone metadata: " + err.Error())
-		}
+	if err := s.SetZoneMetadata(zoneMetadata()); err != nil {
+		logging.Error("Failed to configure zone metadata: " + err.Error())
+		os.Exit(1)
+	}
 
-		configuredZones, err := fetcher.GetConfiguredZones(rootCtx)
+	if key := os.Getenv("ZONE_TOKEN_KEY"); key != "" {
+		decoded, err := hex.DecodeString(key)
+		if err == nil {
+			err = s.SetTokenKey(decoded)
+		}
 		if err != nil {
-			logging.Error("Failed to get configured zones: " + err.Error())
+			logging.Error("Invalid ZONE_TOKEN_KEY, expected 64 hex characters: " + err.Error())
+			os.Exit(1)
 		}
+	}
 
-		// Context-aware fetch loop
-		go func(ctx context.Context) {
-			ticker := time.NewTicker(60 * time.Second)
-			defer ticker.Stop()
-
-			for {
-				select {
-				case <-ctx.Done():
-					logging.Debug("Fetcher loop stopped due to context cancellation")
-					return
-				case <-ticker.C:
-					for _, zone := range configuredZones {
-						data, err := fetcher.Fetch(zone, ctx)
-						if err != nil {
-							logging.Error("Error fetching data for zone " + zone + ": " + err.Error())
-							continue
-						}
-						s.AddOrUpdateZone(data.Zone, data.CarbonIntensity, ctx)
-					}
-				}
-			}
-		}(rootCtx)
-
-	} else {
-		logging.Debug("[Mode] Using static offline test data")
-		s.AddOrUpdateZone("DE", 140.5, rootCtx)
-		s.AddOrUpdateZone("FR", 135.2, rootCtx)
-		s.AddOrUpdateZone("US-NY-NYIS", 128.9, rootCtx)
-
-		offlineZones := []ports.Zone{
-			{Code: "DE", Name: "Germany"},
-			{Code: "FR", Name: "France"},
-			{Code: "US-NY-NYIS", Name: "New York ISO"},
-		}
-		_ = r.StoreZones(offlineZones, rootCtx)
+	zones, err := s.LoadZones(rootCtx)
+	if err != nil {
+		logging.Error("Failed to load zones: " + err.Error())
+	}
+
+	codes := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		codes = append(codes, zone.Code)
 	}
+	go func(ctx context.Context) {
+		s.RunFetcher(ctx, codes, fetchConfig())
+		logging.Debug("Fetcher stopped due to context cancellation")
+	}(rootCtx)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -119,7 +112,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	httpHandler := handler.NewHandler(s)
+	httpHandler := handler.NewHandler(s, s, s)
 	protectedHandler := auth.AuthMiddleware(httpHandler)
 	tracingHandler := tracing.Middleware(protectedHandler)
 	http.Handle("/", tracingHandler)
@@ -146,3 +139,188 @@ func main() {
 
 	logging.Debug("Server exited gracefully")
 }
+
+// newRepo creates the repo chosen by CARBON_REPO_TYPE, "postgres" or the in-memory repo with its JSON files
+func newRepo(ctx context.Context) ports.Repo {
+	if os.Getenv("CARBON_REPO_TYPE") != "postgres" {
+		return repo.NewRepo()
+	}
+
+	sslMode, err := strconv.ParseBool(os.Getenv("SSL_MODE"))
+	if err != nil {
+		logging.Warn("Invalid SSL_MODE value, defaulting to false")
+		sslMode = false
+	}
+
+	retries := 10
+	for range retries {
+		r, err := repo_postgres.NewRepo(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), sslMode, ctx)
+		if err == nil {
+			return r
+		}
+		logging.Warn("Postgres not ready, retrying...", "error", err)
+		time.Sleep(2 * time.Second)
+	}
+	logging.Error("Failed to connect to Postgres")
+	os.Exit(1)
+	return nil
+}
+
+// fetchConfig reads FETCH_INTERVAL, FETCH_INTERVAL_<ZONE>, FETCH_CONCURRENCY, FETCH_TIMEOUT,
+// FETCH_MIN_BACKOFF and FETCH_MAX_BACKOFF. Durations are given like 90s or 5m, unset values use the defaults.
+func fetchConfig() core.FetchConfig {
+	duration := func(name string) time.Duration {
+		value := os.Getenv(name)
+		if value == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			logging.Warn("Invalid duration, using the default", "env", name, "value", value)
+		}
+		return d
+	}
+
+	config := core.FetchConfig{
+		Interval:      duration("FETCH_INTERVAL"),
+		ZoneIntervals: map[string]time.Duration{},
+		Timeout:       duration("FETCH_TIMEOUT"),
+		MinBackoff:    duration("FETCH_MIN_BACKOFF"),
+		MaxBackoff:    duration("FETCH_MAX_BACKOFF"),
+	}
+	config.Concurrency, _ = strconv.Atoi(os.Getenv("FETCH_CONCURRENCY"))
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if zone, ok := strings.CutPrefix(name, "FETCH_INTERVAL_"); ok {
+			config.ZoneIntervals[strings.ReplaceAll(zone, "_", "-")] = duration(name)
+		}
+	}
+	return config
+}
+
+// offlineProfile is served by the static source if no STATIC_PROFILE is set
+var offlineProfile = []static.Entry{
+	{Zone: "DE", Name: "Germany", CarbonIntensity: 140.5},
+	{Zone: "FR", Name: "France", CarbonIntensity: 135.2},
+	{Zone: "US-NY-NYIS", Name: "New York ISO", CarbonIntensity: 128.9},
+}
+
+// carbonSources creates the sources named in SOURCES, a comma separated list in order of priority.
+// Without it, Electricity Maps is used with USE_LIVE=true and the static profile otherwise.
+// SOURCES_<ZONE> sets another order for a zone, e.g. SOURCES_GB=uk-carbon-intensity,electricity-maps.
+func carbonSources() ([]ports.CarbonSource, core.SourcePriority) {
+	priority := core.SourcePriority{Default: splitList(os.Getenv("SOURCES")), Zones: map[string][]string{}}
+	if len(priority.Default) == 0 {
+		if os.Getenv("USE_LIVE") == "true" {
+			priority.Default = []string{electricitymaps.Name}
+		} else {
+			priority.Default = []string{static.Name}
+		}
+	}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if zone, ok := strings.CutPrefix(name, "SOURCES_"); ok {
+			priority.Zones[strings.ReplaceAll(zone, "_", "-")] = splitList(value)
+		}
+	}
+
+	used := func(name string) bool {
+		if slices.Contains(priority.Default, name) {
+			return true
+		}
+		for _, names := range priority.Zones {
+			if slices.Contains(names, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var sources []ports.CarbonSource
+	if used(electricitymaps.Name) {
+		sources = append(sources, electricitymaps.NewFromEnv())
+	}
+	if used(ukcarbonintensity.Name) {
+		sources = append(sources, ukcarbonintensity.New(http.DefaultClient))
+	}
+	if used(watttime.Name) {
+		sources = append(sources, watttime.NewFromEnv())
+	}
+	if used(static.Name) {
+		profile := static.New(offlineProfile)
+		if file := os.Getenv("STATIC_PROFILE"); file != "" {
+			var err error
+			if profile, err = static.Load(file); err != nil {
+				logging.Error("Failed to load static profile: " + err.Error())
+				os.Exit(1)
+			}
+		}
+		sources = append(sources, profile)
+	}
+	if used(simulation.Name) {
+		sources = append(sources, simulationSource())
+	}
+	return sources, priority
+}
+
+// simulationSource replays SIMULATION_SCENARIO, a JSON file, at its speed or SIMULATION_SPEED if set.
+// Without a file it has no zones until a scenario is set with PUT /admin/simulation.
+func simulationSource() *simulation.Replay {
+	replay := simulation.New()
+	file := os.Getenv("SIMULATION_SCENARIO")
+	if file == "" {
+		return replay
+	}
+
+	scenario, err := simulation.LoadScenario(file)
+	if err != nil {
+		logging.Error("Failed to load simulation scenario: " + err.Error())
+		os.Exit(1)
+	}
+	if speed := os.Getenv("SIMULATION_SPEED"); speed != "" {
+		if scenario.Speed, err = strconv.ParseFloat(speed, 64); err != nil {
+			logging.Error("Invalid SIMULATION_SPEED: " + speed)
+			os.Exit(1)
+		}
+	}
+	if err := replay.SetScenario(scenario); err != nil {
+		logging.Error("Failed to load simulation scenario: " + err.Error())
+		os.Exit(1)
+	}
+	return replay
+}
+
+// zoneMetadata reads ZONE_METADATA, a JSON file, or returns the built-in metadata of common zones
+func zoneMetadata() []ports.Zone {
+	file := os.Getenv("ZONE_METADATA")
+	if file == "" {
+		return zonemetadata.Default()
+	}
+	metadata, err := zonemetadata.Load(file)
+	if err != nil {
+		logging.Error("Failed to load zone metadata: " + err.Error())
+		os.Exit(1)
+	}
+	return metadata
+}
+
+// emissionFactors parses EMISSION_FACTORS, e.g. average/lifecycle,average/direct,marginal/direct
+func emissionFactors(value string) []ports.EmissionFactor {
+	var factors []ports.EmissionFactor
+	for _, item := range splitList(value) {
+		factorType, scope, _ := strings.Cut(item, "/")
+		factors = append(factors, ports.EmissionFactor{Type: factorType, Scope: scope})
+	}
+	return factors
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
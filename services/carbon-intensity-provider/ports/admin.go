@@ -0,0 +1,78 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RoleAdmin is the role claim of the JWT that may use the admin API
+const RoleAdmin = "admin"
+
+var (
+	ErrInvalidZoneConfig = errors.New("invalid zone configuration")
+	ErrZoneNotFound      = errors.New("zone not found")
+)
+
+// ZoneConfig is the configuration of a zone that was changed at runtime with the admin API.
+// It takes precedence over the environment, so it is kept for removed zones as well.
+type ZoneConfig struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Electricity Maps token that replaces TOKEN_<ZONE>. It is never stored in plain text, only its SHA-256
+	// and the token encrypted with ZONE_TOKEN_KEY, which the sources need to call their API.
+	TokenHash   string    `json:"tokenHash,omitempty"`
+	SealedToken string    `json:"sealedToken,omitempty"`
+	Removed     bool      `json:"removed"`
+	Override    *Override `json:"override,omitempty"`
+}
+
+// Override is a manual carbon intensity that is served instead of the fetched data
+type Override struct {
+	CarbonIntensity float64   `json:"carbonIntensity"`
+	SetAt           time.Time `json:"setAt"`
+}
+
+// TokenSource is a CarbonSource that uses an API token per zone, which can be changed at runtime
+type TokenSource interface {
+	CarbonSource
+	SetToken(zone string, token string)
+	RemoveToken(zone string)
+}
+
+// ZoneAdmin manages the zones at runtime, the changes are persisted through the Repo
+type ZoneAdmin interface {
+	GetZoneConfigs(ctx context.Context) ([]ZoneConfig, error)
+	// AddZone adds the zone or updates its name, a token is only set if one is given
+	AddZone(zone Zone, token string, ctx context.Context) error
+	RemoveZone(code string, ctx context.Context) error
+	RotateToken(code string, token string, ctx context.Context) error
+	// SetOverride serves the intensity instead of the fetched data, nil removes the override
+	SetOverride(code string, intensity *float64, ctx context.Context) error
+}
+
+// AdminZone is a zone configuration as returned by the admin API, the token is not shown
+type AdminZone struct {
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	HasToken bool      `json:"hasToken"`
+	Removed  bool      `json:"removed"`
+	Override *Override `json:"override,omitempty"`
+}
+
+type AdminZonesResponse struct {
+	Zones []AdminZone `json:"zones"`
+}
+
+type AddZoneRequest struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+type RotateTokenRequest struct {
+	Token string `json:"token"`
+}
+
+type OverrideRequest struct {
+	CarbonIntensity *float64 `json:"carbonIntensity"`
+}
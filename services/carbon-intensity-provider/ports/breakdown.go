@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// PowerBreakdown is the production mix of a zone, e.g. for users that schedule on the renewable share
+// instead of the carbon intensity. It does not depend on the emission factor.
+type PowerBreakdown struct {
+	Zone                 string             `json:"zone"`
+	RenewablePercentage  float64            `json:"renewablePercentage"`     // share of wind, solar, hydro, biomass and geothermal
+	FossilFreePercentage float64            `json:"fossilFreePercentage"`    // renewable and nuclear share
+	ProductionMix        map[string]float64 `json:"productionMix,omitempty"` // production in MW by type, e.g. wind, solar, gas
+	Source               string             `json:"source,omitempty"`
+	MeasuredAt           time.Time          `json:"measuredAt,omitzero"`
+	FetchedAt            time.Time          `json:"fetchedAt,omitzero"`
+}
+
+// BreakdownSource is a CarbonSource that also delivers the power breakdown of its zones
+type BreakdownSource interface {
+	CarbonSource
+	// FetchBreakdown returns the latest power breakdown of the zone with Source and MeasuredAt set.
+	// A zone the source has no breakdown for is ErrZoneNotSupported.
+	FetchBreakdown(zone string, ctx context.Context) (PowerBreakdown, error)
+}
@@ -0,0 +1,57 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Types of emission factors
+const (
+	TypeAverage  = "average"  // intensity of the whole electricity mix
+	TypeMarginal = "marginal" // intensity of the plants that follow a change of the load
+)
+
+// Scopes of emission factors
+const (
+	ScopeLifecycle = "lifecycle" // including construction, fuel supply and decommissioning of the plants
+	ScopeDirect    = "direct"    // only the emissions of the generation itself
+)
+
+var ErrInvalidEmissionFactor = errors.New("invalid emission factor")
+
+// EmissionFactor is the kind of carbon intensity, a source delivers some of them.
+// Marginal intensities show the impact of shifting load better than average ones.
+type EmissionFactor struct {
+	Type  string `json:"type"`
+	Scope string `json:"scope"`
+}
+
+// DefaultEmissionFactor is what Electricity Maps returns by default, data stored without type and scope has it
+var DefaultEmissionFactor = EmissionFactor{Type: TypeAverage, Scope: ScopeLifecycle}
+
+// ParseEmissionFactor validates the type and scope, empty ones are those of DefaultEmissionFactor
+func ParseEmissionFactor(factorType, scope string) (EmissionFactor, error) {
+	factor := EmissionFactor{Type: factorType, Scope: scope}.OrDefault()
+	if factor.Type != TypeAverage && factor.Type != TypeMarginal {
+		return EmissionFactor{}, fmt.Errorf("%w: type must be %s or %s", ErrInvalidEmissionFactor, TypeAverage, TypeMarginal)
+	}
+	if factor.Scope != ScopeLifecycle && factor.Scope != ScopeDirect {
+		return EmissionFactor{}, fmt.Errorf("%w: scope must be %s or %s", ErrInvalidEmissionFactor, ScopeDirect, ScopeLifecycle)
+	}
+	return factor, nil
+}
+
+// OrDefault fills an empty type or scope with the one of DefaultEmissionFactor
+func (f EmissionFactor) OrDefault() EmissionFactor {
+	if f.Type == "" {
+		f.Type = DefaultEmissionFactor.Type
+	}
+	if f.Scope == "" {
+		f.Scope = DefaultEmissionFactor.Scope
+	}
+	return f
+}
+
+func (f EmissionFactor) String() string {
+	return f.Type + "/" + f.Scope
+}
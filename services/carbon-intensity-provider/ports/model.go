@@ -1,15 +1,69 @@
 package ports
 
+import (
+	"errors"
+	"time"
+)
+
 type CarbonIntensityData struct {
-	Zone            string  `json:"zone"`
-	CarbonIntensity float64 `json:"carbonIntensity"`
+	Zone            string    `json:"zone"`
+	CarbonIntensity float64   `json:"carbonIntensity"`
+	EmissionFactor            // type and scope of the intensity
+	Source          string    `json:"source,omitempty"`    // name of the CarbonSource
+	MeasuredAt      time.Time `json:"measuredAt,omitzero"` // time the source measured or estimated the value for
+	FetchedAt       time.Time `json:"fetchedAt,omitzero"`  // time the value was fetched from the source
+	IsEstimated     bool      `json:"isEstimated"`         // forecast or estimate instead of a measurement
+	Stale           bool      `json:"stale"`               // older than the max age, set when the data is read
+
+	PowerBreakdown *PowerBreakdown `json:"powerBreakdown,omitempty"` // latest breakdown of the zone, set when the data is read
 }
 
 type Zone struct {
 	Code string `json:"code"`
 	Name string `json:"name"`
+	ZoneMetadata
+}
+
+var ErrInvalidZoneMetadata = errors.New("invalid zone metadata")
+
+// ZoneMetadata locates a zone, e.g. to keep jobs in a region. All fields are optional.
+type ZoneMetadata struct {
+	Country     string       `json:"country,omitempty"`     // ISO 3166-1 alpha-2 code, e.g. DE
+	Continent   string       `json:"continent,omitempty"`   // e.g. Europe
+	Regions     []string     `json:"regions,omitempty"`     // political or market regions, e.g. EU
+	Coordinates *Coordinates `json:"coordinates,omitempty"` // centre of the zone
+	Neighbours  []string     `json:"neighbours,omitempty"`  // zones it is interconnected with
+	Aliases     []string     `json:"aliases,omitempty"`     // other codes of the zone, e.g. UK for GB
+}
+
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 type AvailableZonesResponse struct {
 	Zones []Zone `json:"zones"`
 }
+
+type FetchHealthResponse struct {
+	Zones []ZoneFetchHealth `json:"zones"`
+}
+
+// Fetch health states of a zone
+const (
+	FetchPending = "pending" // not fetched yet
+	FetchOK      = "ok"
+	FetchFailing = "failing" // the last fetch failed, the zone is retried with backoff or at its interval
+)
+
+// ZoneFetchHealth describes how fetching the data of a zone went so far
+type ZoneFetchHealth struct {
+	Zone                string    `json:"zone"`
+	Status              string    `json:"status"`
+	IntervalSeconds     float64   `json:"intervalSeconds"`
+	LastAttempt         time.Time `json:"lastAttempt,omitzero"`
+	LastSuccess         time.Time `json:"lastSuccess,omitzero"`
+	LastError           string    `json:"lastError,omitempty"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	NextFetch           time.Time `json:"nextFetch,omitzero"`
+}
@@ -9,9 +9,17 @@ var ErrCarbonIntensityProviderNotFound = errors.New("carbon intensity provider n
 
 type Repo interface {
 	Store(data CarbonIntensityData, ctx context.Context) error
-	FindById(id string, ctx context.Context) (CarbonIntensityData, error)
+	// FindById returns the latest data of the zone in the emission factor
+	FindById(id string, factor EmissionFactor, ctx context.Context) (CarbonIntensityData, error)
 	FindAll(ctx context.Context) ([]CarbonIntensityData, error)
 
+	StoreBreakdown(breakdown PowerBreakdown, ctx context.Context) error
+	// FindBreakdown returns the latest power breakdown of the zone
+	FindBreakdown(zone string, ctx context.Context) (PowerBreakdown, error)
+
 	StoreZones([]Zone, context.Context) error
 	GetZones(context.Context) []Zone
+
+	StoreZoneConfig(config ZoneConfig, ctx context.Context) error
+	GetZoneConfigs(ctx context.Context) ([]ZoneConfig, error)
 }
@@ -6,7 +6,11 @@ import (
 
 // CarbonIntensityProvider defines the service interface for managing carbon intensity data.
 type CarbonIntensityProvider interface {
-	GetCarbonIntensityByZone(zone string, ctx context.Context) (CarbonIntensityData, error)
+	GetCarbonIntensityByZone(zone string, factor EmissionFactor, ctx context.Context) (CarbonIntensityData, error)
+	// GetCarbonIntensities returns the data of the zones that are known, unknown zones are left out
+	GetCarbonIntensities(zones []string, factor EmissionFactor, ctx context.Context) ([]CarbonIntensityData, error)
 	GetAvailableZones(ctx context.Context) []Zone
 	GetStoredZones(ctx context.Context) []Zone
+	// GetFetchHealth returns how fetching each zone from its sources went so far
+	GetFetchHealth(ctx context.Context) []ZoneFetchHealth
 }
@@ -0,0 +1,58 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSimulationDisabled = errors.New("simulation source not configured")
+	ErrInvalidScenario    = errors.New("invalid scenario")
+)
+
+// Scenario is a time series of carbon intensities per zone that the simulation source replays.
+// Offsets are durations like 6h30m from the start of the scenario.
+type Scenario struct {
+	Points []ScenarioPoint `json:"points"`
+	// Speed is the simulated time per real time, 1 replays in real time, 60 an hour per minute and 0 pauses
+	Speed float64 `json:"speed"`
+	// Start is the offset the replay starts at, e.g. 12h to begin at noon
+	Start string `json:"start,omitempty"`
+}
+
+// ScenarioPoint is the carbon intensity of a zone from its offset until the next point of the zone in the
+// same emission factor. An empty type or scope is the one of DefaultEmissionFactor. A point with renewable
+// and fossil-free percentage also sets the power breakdown of the zone until its next point with them.
+type ScenarioPoint struct {
+	Zone                 string   `json:"zone"`
+	Name                 string   `json:"name,omitempty"`
+	At                   string   `json:"at"`
+	CarbonIntensity      float64  `json:"carbonIntensity"`
+	Type                 string   `json:"type,omitempty"`
+	Scope                string   `json:"scope,omitempty"`
+	RenewablePercentage  *float64 `json:"renewablePercentage,omitempty"`
+	FossilFreePercentage *float64 `json:"fossilFreePercentage,omitempty"`
+}
+
+// SimulationStatus is the scenario being replayed and how far the replay is
+type SimulationStatus struct {
+	Scenario  Scenario  `json:"scenario"`
+	StartedAt time.Time `json:"startedAt"`
+	Offset    string    `json:"offset"` // current offset in the scenario
+}
+
+// SimulationSource is a CarbonSource that replays a scenario, which can be replaced at runtime
+type SimulationSource interface {
+	CarbonSource
+	SetScenario(scenario Scenario) error
+	Status() SimulationStatus
+}
+
+// Simulator replaces the scenario of the simulation source, so integration tests can drive the
+// scheduler with reproducible carbon data
+type Simulator interface {
+	// SetScenario replays the scenario from now on, its zones are fetched right away
+	SetScenario(scenario Scenario, ctx context.Context) error
+	GetSimulation(ctx context.Context) (SimulationStatus, error)
+}
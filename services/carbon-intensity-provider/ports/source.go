@@ -0,0 +1,52 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrZoneNotSupported is returned by a CarbonSource for zones it has no data for
+var ErrZoneNotSupported = errors.New("zone not supported by source")
+
+// CarbonSource delivers the current carbon intensity of zones, e.g. Electricity Maps or a static profile.
+type CarbonSource interface {
+	// Name identifies the source in the configuration and in CarbonIntensityData.Source
+	Name() string
+	// Fetch returns the latest data of the zone in the emission factor with Source and MeasuredAt set.
+	// A zone or emission factor the source has no data for is ErrZoneNotSupported.
+	Fetch(zone string, factor EmissionFactor, ctx context.Context) (CarbonIntensityData, error)
+	// Zones returns the zones the source has data for
+	Zones(ctx context.Context) ([]Zone, error)
+}
+
+// StatusError is returned by a CarbonSource whose API answered with an error status.
+// RetryAfter is the wait the API asked for with a Retry-After header, 0 if it did not.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned status: %d", e.StatusCode)
+}
+
+// Retryable reports whether the request may succeed later, i.e. the API is rate limited or failing
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// NewStatusError creates the error of a response with the Retry-After header in seconds or as HTTP date
+func NewStatusError(res *http.Response) *StatusError {
+	err := &StatusError{StatusCode: res.StatusCode}
+	retryAfter := res.Header.Get("Retry-After")
+	if seconds, parseErr := strconv.Atoi(retryAfter); parseErr == nil && seconds > 0 {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+	} else if date, parseErr := http.ParseTime(retryAfter); parseErr == nil {
+		err.RetryAfter = max(time.Until(date), 0)
+	}
+	return err
+}
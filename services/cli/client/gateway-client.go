@@ -92,6 +92,12 @@ func (c *GatewayClient) GetJobById(id string) {
 	body, _ := io.ReadAll(resp.Body)
 	fmt.Println("Status:", resp.Status)
 	fmt.Println("Response:", string(body))
+
+	var job cli.JobResponse
+	if resp.StatusCode == http.StatusOK && json.Unmarshal(body, &job) == nil {
+		fmt.Println("Job status:", job.Status)
+		fmt.Println("Progress:", job.Progress)
+	}
 }
 
 func (c *GatewayClient) GetJobOutcome(id string) {
@@ -115,6 +121,15 @@ func (c *GatewayClient) GetJobOutcome(id string) {
 	fmt.Println("Status:", resp.Status)
 	fmt.Println("Response:", string(body))
 
+	var outcome cli.JobOutcomeResponse
+	if resp.StatusCode == http.StatusOK && json.Unmarshal(body, &outcome) == nil {
+		if outcome.Status == "running" {
+			fmt.Println("Progress:", outcome.Progress)
+		}
+		if outcome.CarbonEmitted >= 0 {
+			fmt.Printf("Energy: %.3f Wh, emitted: %.3f gCO2eq, saved: %.3f gCO2eq\n", outcome.EnergyWh, outcome.CarbonEmitted, outcome.CarbonSaved)
+		}
+	}
 }
 
 func (c *GatewayClient) Login(secret string) {
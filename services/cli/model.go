@@ -1,5 +1,7 @@
 package cli
 
+import "fmt"
+
 type JobStatus string
 
 type CreateJobRequest struct {
@@ -18,13 +20,38 @@ type CreateJobResponse struct {
 }
 
 type JobOutcomeResponse struct {
-	JobName         string    `json:"jobName"`
-	Status          JobStatus `json:"status"`
-	Result          string    `json:"result"`
-	ErrorMessage    string    `json:"errorMessage"`
-	ComputeZone     string    `json:"computeZone"`
-	CarbonIntensity int       `json:"carbonIntensity"`
-	CarbonSavings   int       `json:"carbonSavings"`
+	JobName         string      `json:"jobName"`
+	Status          JobStatus   `json:"status"`
+	Result          string      `json:"result"`
+	ErrorMessage    string      `json:"errorMessage"`
+	ComputeZone     string      `json:"computeZone"`
+	CarbonIntensity int         `json:"carbonIntensity"`
+	CarbonSavings   int         `json:"carbonSavings"`
+	Progress        JobProgress `json:"progress"`
+	EnergyWh        float64     `json:"energyWh"`
+	CarbonEmitted   float64     `json:"carbonEmitted"`
+	CarbonSaved     float64     `json:"carbonSaved"`
+}
+
+type JobResponse struct {
+	ID          string      `json:"id"`
+	JobName     string      `json:"jobName"`
+	Status      JobStatus   `json:"status"`
+	ComputeZone string      `json:"computeZone"`
+	Progress    JobProgress `json:"progress"`
+}
+
+// JobProgress is the last progress reported by the running job container
+type JobProgress struct {
+	Percent int    `json:"percent"`
+	Message string `json:"message"`
+}
+
+func (p JobProgress) String() string {
+	if p.Message == "" {
+		return fmt.Sprintf("%d%%", p.Percent)
+	}
+	return fmt.Sprintf("%d%% (%s)", p.Percent, p.Message)
 }
 
 type ContainerImage struct {
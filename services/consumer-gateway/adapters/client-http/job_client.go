@@ -59,6 +59,39 @@ func (c *JobClient) CreateJob(ctx context.Context, req ports.CreateJobRequest) (
 	return out, nil
 }
 
+func (c *JobClient) GetJob(ctx context.Context, jobID string) (ports.JobResponse, error) {
+	url := fmt.Sprintf("%s/jobs/%s", c.baseURL, jobID)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ports.JobResponse{}, err
+	}
+
+	if auth, ok := ctx.Value("Authorization").(string); ok && auth != "" {
+		httpReq.Header.Set("Authorization", auth)
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return ports.JobResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ports.JobResponse{}, ports.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ports.JobResponse{}, fmt.Errorf("job-service error: %s", resp.Status)
+	}
+
+	var out ports.JobResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return ports.JobResponse{}, err
+	}
+
+	return out, nil
+}
+
 func (c *JobClient) GetJobOutcome(ctx context.Context, jobID string) (ports.JobOutcomeResponse, error) {
 	url := fmt.Sprintf("%s/jobs/%s/outcome", c.baseURL, jobID)
 
@@ -2,8 +2,13 @@ package handler_http
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"sort"
 
 	"github.com/gorilla/mux"
 
@@ -22,6 +27,7 @@ func NewHandler(api ports.Api) *Handler {
 	h := &Handler{api: api, rtr: r}
 
 	r.HandleFunc("/jobs", h.HandleCreateJobRequest).Methods("POST")
+	r.HandleFunc("/jobs/{job-id}", h.HandleGetJobRequest).Methods("GET")
 	r.HandleFunc("/jobs/{job-id}/outcome", h.HandleGetJobOutcomeRequest).Methods("GET")
 	r.HandleFunc("/auth/login", h.HandleLoginRequest).Methods("POST")
 
@@ -32,14 +38,27 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.rtr.ServeHTTP(w, r) //delegate
 }
 
+// maxUploadSize limits the size of multipart job requests including their input files.
+const maxUploadSize = 8 << 20
+
+// maxJSONRequestSize limits the size of JSON job requests, whose input files are base64 encoded.
+const maxJSONRequestSize = 12 << 20
+
 /*
 Creates a new job using the provided data by the client.
 The parameter req: contains the fields (imageID, zone) defined
-in the CreateJobRequest struct
+in the CreateJobRequest struct.
+The request is either plain JSON or multipart/form-data with the JSON
+in the "job" field and the uploaded input files as file parts.
 */
 func (h *Handler) HandleCreateJobRequest(w http.ResponseWriter, r *http.Request) {
-	var req ports.CreateJobRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	req, err := decodeCreateJobRequest(w, r)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, `{"error":"request too large"}`, http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
 		http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
 		return
 	}
@@ -61,6 +80,27 @@ func (h *Handler) HandleCreateJobRequest(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(resp)
 }
 
+/*
+Returns a job including its status and the progress of the running container.
+*/
+func (h *Handler) HandleGetJobRequest(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["job-id"]
+
+	ctx := context.WithValue(r.Context(), "Authorization", r.Header.Get("Authorization"))
+
+	job, err := h.api.GetJob(ctx, jobID)
+	if err == ports.ErrNotFound {
+		http.Error(w, `{"error":"job not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
 /*
 Returns a job result that was requested by client.
 The parameter vars: is a map that extracts the path parameters from client request.
@@ -97,3 +137,52 @@ func (h *Handler) HandleLoginRequest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
+
+/*
+Decodes the CreateJobRequest from a JSON or multipart/form-data body.
+Uploaded files are added as base64 encoded input files named after the
+uploaded file, the checksum is computed by the job service.
+*/
+func decodeCreateJobRequest(w http.ResponseWriter, r *http.Request) (ports.CreateJobRequest, error) {
+	var req ports.CreateJobRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxJSONRequestSize)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		return req, err
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		return req, err
+	}
+	if err := json.Unmarshal([]byte(r.FormValue("job")), &req); err != nil {
+		return req, err
+	}
+
+	fields := make([]string, 0, len(r.MultipartForm.File))
+	for field := range r.MultipartForm.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		for _, header := range r.MultipartForm.File[field] {
+			file, err := header.Open()
+			if err != nil {
+				return req, err
+			}
+			content, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return req, err
+			}
+			req.InputFiles = append(req.InputFiles, ports.InputFile{
+				Name:    header.Filename,
+				Content: base64.StdEncoding.EncodeToString(content),
+			})
+		}
+	}
+	return req, nil
+}
@@ -1,7 +1,14 @@
 package handler_http
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
 
 	"github.com/informatik-mannheim/cmg-ss2025/services/consumer-gateway/ports"
 )
@@ -10,7 +17,9 @@ type contextKey string
 
 const userContextKey contextKey = "user"
 
-type FakeService struct{}
+type FakeService struct {
+	created *ports.CreateJobRequest // last request passed to CreateJob
+}
 
 func (f *FakeService) Login(ctx context.Context, req ports.ConsumerLoginRequest) (ports.LoginResponse, error) {
 	if req.Secret == "Secret" {
@@ -23,14 +32,31 @@ func (f *FakeService) CreateJob(ctx context.Context, req ports.CreateJobRequest)
 	if req.CreationZone == "" || req.Parameters == nil {
 		return ports.CreateJobResponse{}, ports.ErrInvalidInput
 	}
+	f.created = &req
+	var files []ports.InputFileInfo
+	for _, file := range req.InputFiles {
+		files = append(files, ports.InputFileInfo{Name: file.Name, Checksum: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"})
+	}
 	return ports.CreateJobResponse{
 		JobName:      "job-123",
 		CreationZone: req.CreationZone,
 		Parameters:   req.Parameters,
+		InputFiles:   files,
 		Status:       "queued",
 	}, nil
 }
 
+func (f *FakeService) GetJob(ctx context.Context, jobID string) (ports.JobResponse, error) {
+	if jobID == "job-123" {
+		return ports.JobResponse{
+			ID:       "job-123",
+			Status:   "running",
+			Progress: ports.JobProgress{Percent: 42, Message: "epoch 3/10"},
+		}, nil
+	}
+	return ports.JobResponse{}, ports.ErrNotFound
+}
+
 func (f *FakeService) GetJobOutcome(ctx context.Context, jobID string) (ports.JobOutcomeResponse, error) {
 	user := ctx.Value(userContextKey).(string)
 	if user == "alice" && jobID == "job-123" {
@@ -50,3 +76,76 @@ func (f *FakeService) GetZone(ctx context.Context, req ports.ZoneRequest) (ports
 	}
 	return ports.ZoneResponse{Zone: req.Zone}, nil
 }
+
+func TestHandleCreateJobRequest_MultipartUpload(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("job", `{"jobName":"job-123","creationZone":"DE","parameters":{"-p":"80"}}`)
+	file, _ := form.CreateFormFile("input", "hello.txt")
+	file.Write([]byte("hello"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), "role", "consumer"))
+	rec := httptest.NewRecorder()
+
+	service := &FakeService{}
+	NewHandler(service).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if files := service.created.InputFiles; len(files) != 1 || files[0].Name != "hello.txt" || files[0].Content != "aGVsbG8=" {
+		t.Errorf("unexpected uploaded input files: %+v", files)
+	}
+
+	// the response only names the files, the content is not sent back
+	if strings.Contains(rec.Body.String(), "aGVsbG8=") {
+		t.Errorf("expected no content in the response, got %s", rec.Body.String())
+	}
+	var resp ports.CreateJobResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.InputFiles) != 1 || resp.InputFiles[0].Name != "hello.txt" || resp.InputFiles[0].Checksum == "" {
+		t.Errorf("unexpected input files: %+v", resp.InputFiles)
+	}
+}
+
+func TestHandleCreateJobRequest_TooLarge(t *testing.T) {
+	body := `{"jobName":"job-123","inputFiles":[{"name":"a","content":"` + strings.Repeat("A", maxJSONRequestSize) + `"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(context.WithValue(req.Context(), "role", "consumer"))
+	rec := httptest.NewRecorder()
+
+	NewHandler(&FakeService{}).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleGetJobRequest(t *testing.T) {
+	handler := NewHandler(&FakeService{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/job-123", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var job ports.JobResponse
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if job.Progress.Percent != 42 || job.Progress.Message != "epoch 3/10" {
+		t.Errorf("unexpected progress: %+v", job.Progress)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+}
@@ -29,6 +29,14 @@ func (s *ConsumerGatewayService) CreateJob(ctx context.Context, req ports.Create
 	return resp, nil
 }
 
+func (s *ConsumerGatewayService) GetJob(ctx context.Context, jobID string) (ports.JobResponse, error) {
+	resp, err := s.job.GetJob(ctx, jobID)
+	if err != nil {
+		return ports.JobResponse{}, err
+	}
+	return resp, nil
+}
+
 func (s *ConsumerGatewayService) GetJobOutcome(ctx context.Context, jobID string) (ports.JobOutcomeResponse, error) {
 	resp, err := s.job.GetJobOutcome(ctx, jobID)
 	if err != nil {
@@ -30,6 +30,13 @@ func (m *mockJobClient) CreateJob(ctx context.Context, req ports.CreateJobReques
 	}, nil
 }
 
+func (m *mockJobClient) GetJob(ctx context.Context, jobID string) (ports.JobResponse, error) {
+	if m.failOutcome {
+		return ports.JobResponse{}, ports.ErrNotFound
+	}
+	return ports.JobResponse{ID: jobID, Status: ports.JobStatus("running"), Progress: ports.JobProgress{Percent: 42}}, nil
+}
+
 func (m *mockJobClient) GetJobOutcome(ctx context.Context, jobID string) (ports.JobOutcomeResponse, error) {
 	m.getOutcomeCalled = true
 	if m.failOutcome {
@@ -99,6 +106,19 @@ func TestConsumerGatewayService_GetJobOutcome(t *testing.T) {
 	}
 }
 
+func TestConsumerGatewayService_GetJob(t *testing.T) {
+	jobMock := &mockJobClient{}
+	service := core.NewConsumerService(jobMock, &mockZoneClient{}, &mockLoginClient{})
+
+	resp, err := service.GetJob(context.Background(), "job-123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.ID != "job-123" || resp.Progress.Percent != 42 {
+		t.Errorf("unexpected job: %+v", resp)
+	}
+}
+
 func TestConsumerGatewayService_GetZone(t *testing.T) {
 	service := core.NewConsumerService(&mockJobClient{}, &mockZoneClient{}, &mockLoginClient{})
 
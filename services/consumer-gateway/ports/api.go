@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 var ErrNotFound = errors.New("not found")
@@ -15,6 +16,12 @@ type CreateJobRequest struct {
 	CreationZone string            `json:"creationZone"`
 	ImageID      ContainerImage    `json:"image"`
 	Parameters   map[string]string `json:"parameters"`
+	InputFiles   []InputFile       `json:"inputFiles,omitempty"`
+	// carbon-intensity (default), renewable or fossil-free, validated by the job service
+	OptimisationTarget string `json:"optimisationTarget,omitempty"`
+	// zones and regions (e.g. EU) the job may run in, anywhere if both are empty
+	AllowedZones   []string `json:"allowedZones,omitempty"`
+	AllowedRegions []string `json:"allowedRegions,omitempty"`
 }
 
 type CreateJobResponse struct {
@@ -22,21 +29,39 @@ type CreateJobResponse struct {
 	JobName      string            `json:"jobName"`
 	CreationZone string            `json:"creationZone"`
 	Parameters   map[string]string `json:"parameters"`
+	InputFiles   []InputFileInfo   `json:"inputFiles"`
 	Status       string            `json:"status"`
+
+	OptimisationTarget string   `json:"optimisationTarget"`
+	AllowedZones       []string `json:"allowedZones"`
+	AllowedRegions     []string `json:"allowedRegions"`
 }
 
 // Returns a singular job
-type GetJob struct {
+type JobResponse struct {
+	ID           string         `json:"id"`
+	JobName      string         `json:"jobName"`
+	Image        ContainerImage `json:"image"`
+	CreationZone string         `json:"creationZone"`
+	ComputeZone  string         `json:"computeZone"`
+	Status       JobStatus      `json:"status"`
+	Progress     JobProgress    `json:"progress"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
 }
 
 type JobOutcomeResponse struct {
-	JobName         string    `json:"jobName"`
-	Status          JobStatus `json:"status"`
-	Result          string    `json:"result"`
-	ErrorMessage    string    `json:"errorMessage"`
-	ComputeZone     string    `json:"computeZone"`
-	CarbonIntensity int       `json:"carbonIntensity"`
-	CarbonSavings   int       `json:"carbonSavings"`
+	JobName         string      `json:"jobName"`
+	Status          JobStatus   `json:"status"`
+	Result          string      `json:"result"`
+	ErrorMessage    string      `json:"errorMessage"`
+	ComputeZone     string      `json:"computeZone"`
+	CarbonIntensity int         `json:"carbonIntensity"`
+	CarbonSavings   int         `json:"carbonSavings"`
+	Progress        JobProgress `json:"progress"`
+	EnergyWh        float64     `json:"energyWh"`
+	CarbonEmitted   float64     `json:"carbonEmitted"`
+	CarbonSaved     float64     `json:"carbonSaved"`
 }
 
 type ConsumerLoginRequest struct {
@@ -58,6 +83,7 @@ type ZoneResponse struct {
 
 type Api interface {
 	CreateJob(ctx context.Context, req CreateJobRequest) (CreateJobResponse, error)
+	GetJob(ctx context.Context, jobID string) (JobResponse, error)
 	GetJobOutcome(ctx context.Context, jobID string) (JobOutcomeResponse, error)
 	GetZone(ctx context.Context, req ZoneRequest) (ZoneResponse, error)
 	Login(ctx context.Context, req ConsumerLoginRequest) (LoginResponse, error)
@@ -19,6 +19,7 @@ type LoginClient interface {
 }
 
 type JobClient interface {
+	GetJob(ctx context.Context, jobID string) (JobResponse, error)
 	GetJobOutcome(ctx context.Context, jobID string) (JobOutcomeResponse, error)
 	CreateJob(ctx context.Context, req CreateJobRequest) (CreateJobResponse, error)
 }
@@ -9,6 +9,30 @@ type Consumer struct {
 type JobStatus string
 
 type ContainerImage struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Digest    string `json:"digest,omitempty"`    // pins the image, e.g. "sha256:..."
+	Signature string `json:"signature,omitempty"` // required if the job service has an image policy with public keys
+}
+
+// JobProgress is the last progress the running job container reported
+type JobProgress struct {
+	Percent int    `json:"percent"`
+	Message string `json:"message"`
+}
+
+// InputFile is staged into the job container under /input. Either URL or the
+// base64 encoded Content must be set, Checksum is the hex encoded SHA-256.
+type InputFile struct {
+	Name     string `json:"name"`
+	URL      string `json:"url,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// InputFileInfo is an input file of a created job. The content stays with the job service,
+// which serves it to the worker daemon.
+type InputFileInfo struct {
+	Name     string `json:"name"`
+	Checksum string `json:"checksum"`
 }
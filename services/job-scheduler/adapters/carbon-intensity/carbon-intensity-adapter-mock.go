@@ -36,3 +36,10 @@ func (adapter *CarbonIntensityAdapterMock) GetCarbonIntensities(zones []string)
 
 	return response, nil
 }
+
+func (adapter *CarbonIntensityAdapterMock) GetZones() ([]ports.Zone, error) {
+	if adapter.shouldGetCarbonsFail {
+		return nil, fmt.Errorf("some zones get error")
+	}
+	return MockZones, nil
+}
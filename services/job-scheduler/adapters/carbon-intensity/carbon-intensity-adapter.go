@@ -1,20 +1,56 @@
 package carbonintensity
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/utils"
 )
 
-func GetCarbonEndpoint(base, zone string) string {
-	return fmt.Sprintf("%s/carbon-intensity/%s", base, zone)
+func GetCarbonEndpoint(base, zone string, factor ports.EmissionFactor) string {
+	params := factorParams(factor)
+	if len(params) == 0 {
+		return fmt.Sprintf("%s/carbon-intensity/%s", base, zone)
+	}
+	return fmt.Sprintf("%s/carbon-intensity/%s?%s", base, zone, params.Encode())
+}
+
+func GetZonesEndpoint(base string) string {
+	return fmt.Sprintf("%s/carbon-intensity/zones", base)
+}
+
+func GetCarbonsEndpoint(base string, zones []string, factor ports.EmissionFactor) string {
+	params := factorParams(factor)
+	params.Add("zones", strings.Join(zones, ","))
+
+	return fmt.Sprintf("%s/carbon-intensity?%s", base, params.Encode())
+}
+
+// factorParams returns the query parameters of the set fields of the factor
+func factorParams(factor ports.EmissionFactor) url.Values {
+	params := url.Values{}
+	if factor.Type != "" {
+		params.Add("type", factor.Type)
+	}
+	if factor.Scope != "" {
+		params.Add("scope", factor.Scope)
+	}
+	return params
 }
 
 type CarbonIntensityAdapter struct {
 	baseUrl string
 	client  http.Client
+
+	// Factor is the emission factor the intensities are requested in, the provider's default if empty.
+	// Zones the provider has no data of in this factor are left out.
+	Factor ports.EmissionFactor
 }
 
 var _ ports.CarbonIntensityAdapter = (*CarbonIntensityAdapter)(nil)
@@ -26,21 +62,64 @@ func NewCarbonIntensityAdapter(client http.Client, baseUrl string) *CarbonIntens
 	}
 }
 
+// GetCarbonIntensities fetches all zones with one request. If that fails, the zones are fetched one by one.
+// Zones without data are left out, so jobs can be scheduled with the remaining zones. An error is only
+// returned if no zone could be fetched.
 func (adapter *CarbonIntensityAdapter) GetCarbonIntensities(zones []string) (ports.CarbonIntensityResponse, error) {
-	// For now its kept simple and return an error as soon as it gets one, changes in Phase 3
-	responses := make([]ports.CarbonIntensityData, len(zones))
+	if len(zones) == 0 {
+		return ports.CarbonIntensityResponse{}, nil
+	}
 
+	endpoint := GetCarbonsEndpoint(adapter.baseUrl, zones, adapter.Factor)
+	data, _, err := utils.GetRequest[ports.CarbonIntensityResponse](&adapter.client, endpoint)
+	if err == nil {
+		// only requested zones, each once
+		responses := make(ports.CarbonIntensityResponse, 0, len(data))
+		for _, carbon := range data {
+			if slices.Contains(zones, carbon.Zone) && !slices.ContainsFunc(responses, func(c ports.CarbonIntensityData) bool {
+				return c.Zone == carbon.Zone
+			}) {
+				responses = append(responses, carbon)
+			}
+		}
+		logMissingZones(zones, responses)
+		return responses, nil
+	}
+	logging.Warn(fmt.Sprintf("Fetching carbon intensity of zones %v failed, fetching them one by one: %v", zones, err))
+
+	responses := make(ports.CarbonIntensityResponse, 0, len(zones))
+	var errs []error
 	for _, zone := range zones {
-		endpoint := GetCarbonEndpoint(adapter.baseUrl, zone)
+		endpoint := GetCarbonEndpoint(adapter.baseUrl, url.PathEscape(zone), adapter.Factor)
 
-		// StatusCode is not relevant yet
 		data, _, err := utils.GetRequest[ports.CarbonIntensityData](&adapter.client, endpoint)
 		if err != nil {
-			return nil, err
+			errs = append(errs, fmt.Errorf("zone %s: %w", zone, err))
+			continue
 		}
-
 		responses = append(responses, data)
 	}
 
+	if len(responses) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	logMissingZones(zones, responses)
 	return responses, nil
 }
+
+// GetZones fetches the available zones with their metadata
+func (adapter *CarbonIntensityAdapter) GetZones() ([]ports.Zone, error) {
+	data, _, err := utils.GetRequest[ports.ZonesResponse](&adapter.client, GetZonesEndpoint(adapter.baseUrl))
+	if err != nil {
+		return nil, err
+	}
+	return data.Zones, nil
+}
+
+func logMissingZones(zones []string, responses ports.CarbonIntensityResponse) {
+	for _, zone := range zones {
+		if !slices.ContainsFunc(responses, func(carbon ports.CarbonIntensityData) bool { return carbon.Zone == zone }) {
+			logging.Warn(fmt.Sprintf("No carbon intensity data for zone %s, its jobs and workers are not scheduled", zone))
+		}
+	}
+}
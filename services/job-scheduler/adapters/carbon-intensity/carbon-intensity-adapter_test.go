@@ -0,0 +1,178 @@
+package carbonintensity_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	carbonintensity "github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/carbon-intensity"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
+)
+
+func init() {
+	logging.Init("job-scheduler")
+}
+
+// providerMock answers like the carbon intensity provider, zones in failing answer with 500
+func providerMock(t *testing.T, batch bool, failing ...string) (*httptest.Server, *int) {
+	t.Helper()
+	carbons := map[string]float64{"DE": 100, "FR": 20, "JP": 50}
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/carbon-intensity" {
+			if !batch {
+				http.NotFound(w, r)
+				return
+			}
+			response := ports.CarbonIntensityResponse{}
+			for _, zone := range strings.Split(r.URL.Query().Get("zones"), ",") {
+				if carbon, ok := carbons[zone]; ok {
+					response = append(response, ports.CarbonIntensityData{Zone: zone, CarbonIntensity: carbon})
+				}
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		zone := strings.TrimPrefix(r.URL.Path, "/carbon-intensity/")
+		carbon, ok := carbons[zone]
+		for _, failingZone := range failing {
+			if zone == failingZone {
+				http.Error(w, "provider error", http.StatusInternalServerError)
+				return
+			}
+		}
+		if !ok {
+			http.Error(w, "Zone not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(ports.CarbonIntensityData{Zone: zone, CarbonIntensity: carbon})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func zonesOf(carbons ports.CarbonIntensityResponse) []string {
+	zones := []string{}
+	for _, carbon := range carbons {
+		zones = append(zones, carbon.Zone)
+	}
+	return zones
+}
+
+// No table driven tests since each case needs another provider
+func TestGetCarbonIntensities_Batch(t *testing.T) {
+	server, requests := providerMock(t, true)
+	adapter := carbonintensity.NewCarbonIntensityAdapter(*server.Client(), server.URL)
+
+	result, err := adapter.GetCarbonIntensities([]string{"DE", "FR", "XX"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *requests != 1 {
+		t.Errorf("Expected one request for all zones, got %d", *requests)
+	}
+	// the unknown zone is left out, it must not show up with intensity 0
+	if zones := zonesOf(result); len(zones) != 2 || zones[0] != "DE" || zones[1] != "FR" {
+		t.Errorf("Expected data for DE and FR, got %v", result)
+	}
+}
+
+func TestGetCarbonIntensities_PartialFailure(t *testing.T) {
+	server, _ := providerMock(t, false, "FR")
+	adapter := carbonintensity.NewCarbonIntensityAdapter(*server.Client(), server.URL)
+
+	result, err := adapter.GetCarbonIntensities([]string{"DE", "FR", "JP", "XX"})
+	if err != nil {
+		t.Fatalf("Expected partial data without error, got %v", err)
+	}
+	if zones := zonesOf(result); len(zones) != 2 || zones[0] != "DE" || zones[1] != "JP" {
+		t.Errorf("Expected data for DE and JP, got %v", result)
+	}
+	for _, carbon := range result {
+		if carbon.CarbonIntensity == 0 {
+			t.Errorf("Expected no zero-valued entries, got %v", result)
+		}
+	}
+}
+
+func TestGetCarbonIntensities_AllFailed(t *testing.T) {
+	server, _ := providerMock(t, false, "DE", "FR")
+	adapter := carbonintensity.NewCarbonIntensityAdapter(*server.Client(), server.URL)
+
+	result, err := adapter.GetCarbonIntensities([]string{"DE", "FR"})
+	if err == nil {
+		t.Errorf("Expected error if no zone could be fetched, got %v", result)
+	}
+
+	server.Close()
+	if _, err := adapter.GetCarbonIntensities([]string{"DE"}); err == nil {
+		t.Error("Expected error if the provider is not reachable")
+	}
+}
+
+func TestGetCarbonIntensities_NoZones(t *testing.T) {
+	server, requests := providerMock(t, true)
+	adapter := carbonintensity.NewCarbonIntensityAdapter(*server.Client(), server.URL)
+
+	result, err := adapter.GetCarbonIntensities(nil)
+	if err != nil || len(result) != 0 || *requests != 0 {
+		t.Errorf("Expected no request and no data, got %v, %v after %d requests", result, err, *requests)
+	}
+}
+
+func TestGetCarbonIntensities_EmissionFactor(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("type")+"/"+r.URL.Query().Get("scope"))
+		if r.URL.Path == "/carbon-intensity" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ports.CarbonIntensityData{
+			Zone:            "DE",
+			CarbonIntensity: 700,
+			EmissionFactor:  ports.EmissionFactor{Type: ports.EmissionTypeMarginal, Scope: ports.EmissionScopeDirect},
+		})
+	}))
+	t.Cleanup(server.Close)
+	adapter := carbonintensity.NewCarbonIntensityAdapter(*server.Client(), server.URL)
+	adapter.Factor = ports.EmissionFactor{Type: ports.EmissionTypeMarginal, Scope: ports.EmissionScopeDirect}
+
+	result, err := adapter.GetCarbonIntensities([]string{"DE"})
+	if err != nil || len(result) != 1 || result[0].Type != ports.EmissionTypeMarginal {
+		t.Fatalf("Expected marginal data of DE, got %v, %v", result, err)
+	}
+	// the batch request and the fallback both ask for the factor
+	if len(queries) != 2 || queries[0] != "marginal/direct" || queries[1] != "marginal/direct" {
+		t.Errorf("Expected both requests with type and scope, got %v", queries)
+	}
+}
+
+func TestGetZones(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/carbon-intensity/zones" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"zones": [
+			{"code": "DE", "name": "Germany", "country": "DE", "continent": "Europe", "regions": ["EU"], "coordinates": {"latitude": 51.2, "longitude": 10.4}},
+			{"code": "XX", "name": "XX"}
+		]}`))
+	}))
+	t.Cleanup(server.Close)
+	adapter := carbonintensity.NewCarbonIntensityAdapter(*server.Client(), server.URL)
+
+	zones, err := adapter.GetZones()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(zones) != 2 || zones[0].Continent != "Europe" || len(zones[0].Regions) != 1 || zones[1].Country != "" {
+		t.Errorf("Expected DE with metadata and XX without, got %+v", zones)
+	}
+}
@@ -2,6 +2,14 @@ package carbonintensity
 
 import "github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
 
+var MockZones = []ports.Zone{
+	{Code: "DE", Name: "Germany", Country: "DE", Continent: "Europe", Regions: []string{"EU", "EEA"}},
+	{Code: "US", Name: "United States", Country: "US", Continent: "North America"},
+	{Code: "JP", Name: "Japan", Country: "JP", Continent: "Asia"},
+	{Code: "FR", Name: "France", Country: "FR", Continent: "Europe", Regions: []string{"EU", "EEA"}},
+	{Code: "CH", Name: "Switzerland", Country: "CH", Continent: "Europe"},
+}
+
 var MockCarbons = []ports.CarbonIntensityData{
 	{
 		Zone:            "DE",
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
@@ -63,6 +64,7 @@ func (adapter *JobAdapter) AssignJob(update ports.UpdateJob) error {
 		ComputeZone:     update.ComputeZone,
 		CarbonIntensity: int(update.CarbonIntensity),
 		CarbonSaving:    int(update.CarbonSavings),
+		CarbonDataAge:   CarbonDataAge(update.CarbonMeasured, time.Now()),
 		Status:          ports.JobStatusScheduled, // Hardcoded because nothing else is possible
 	}
 
@@ -74,3 +76,12 @@ func (adapter *JobAdapter) AssignJob(update ports.UpdateJob) error {
 
 	return nil
 }
+
+// CarbonDataAge returns the seconds between the measurement of the carbon intensity and now,
+// -1 if the measurement time is unknown
+func CarbonDataAge(measured time.Time, now time.Time) int {
+	if measured.IsZero() {
+		return -1
+	}
+	return int(now.Sub(measured).Seconds())
+}
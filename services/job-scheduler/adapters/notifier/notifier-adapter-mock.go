@@ -0,0 +1,35 @@
+package notifier
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
+)
+
+type NotifierAdapterMock struct {
+	shouldNotifyFail bool
+}
+
+var _ ports.Notifier = (*NotifierAdapterMock)(nil)
+
+func NewNotifierAdapterMock(shouldNotifyFail bool) *NotifierAdapterMock {
+	return &NotifierAdapterMock{
+		shouldNotifyFail: shouldNotifyFail,
+	}
+}
+
+func (adapter *NotifierAdapterMock) NotifyAssignment(jobId, workerId uuid.UUID) error {
+	if adapter.shouldNotifyFail {
+		return fmt.Errorf("some notify error")
+	}
+	return nil
+}
+
+func (adapter *NotifierAdapterMock) NotifyWorkerAssignmentFailed(jobId, workerId uuid.UUID) error {
+	return nil
+}
+
+func (adapter *NotifierAdapterMock) NotifyAssignmentCorrection(jobId, workerId uuid.UUID) error {
+	return adapter.NotifyAssignment(jobId, workerId)
+}
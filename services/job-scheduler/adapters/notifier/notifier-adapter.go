@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/utils"
+)
+
+func PostAssignmentEndpoint(base string) string {
+	return fmt.Sprintf("%s/worker/assignments", base)
+}
+
+// NotifierAdapter tells the worker-gateway about assignments, so it can push them
+// to the waiting worker instead of the worker finding them with its next poll.
+type NotifierAdapter struct {
+	baseUrl string
+	client  http.Client
+}
+
+var _ ports.Notifier = (*NotifierAdapter)(nil)
+
+func NewNotifierAdapter(client http.Client, baseUrl string) *NotifierAdapter {
+	return &NotifierAdapter{
+		baseUrl: baseUrl,
+		client:  client,
+	}
+}
+
+func (adapter *NotifierAdapter) NotifyAssignment(jobId, workerId uuid.UUID) error {
+	endpoint := PostAssignmentEndpoint(adapter.baseUrl)
+
+	payload := ports.AssignmentPayload{
+		JobID:    jobId,
+		WorkerID: workerId,
+	}
+
+	// every status outside of 2xx is returned as RequestError, e.g. 403 if the token lacks the job scheduler role
+	_, statusCode, err := utils.PostRequest[ports.AssignmentPayload, any](&adapter.client, endpoint, payload)
+	if err != nil {
+		logging.Warn(fmt.Sprintf("Worker-gateway rejected assignment of job %s to worker %s with status %d", jobId, workerId, statusCode))
+		return fmt.Errorf("failed to notify assignment: %w", err)
+	}
+
+	return nil
+}
+
+// The worker never got the job, so there is nothing to push
+func (adapter *NotifierAdapter) NotifyWorkerAssignmentFailed(jobId, workerId uuid.UUID) error {
+	return nil
+}
+
+// The worker got the job after all, so it is pushed like a new assignment
+func (adapter *NotifierAdapter) NotifyAssignmentCorrection(jobId, workerId uuid.UUID) error {
+	return adapter.NotifyAssignment(jobId, workerId)
+}
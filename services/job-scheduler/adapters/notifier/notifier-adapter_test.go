@@ -0,0 +1,66 @@
+package notifier_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/notifier"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/utils"
+)
+
+func init() {
+	logging.Init("job-scheduler")
+}
+
+func TestNotifyAssignment(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		expected int // status of the RequestError, 0 if no error is expected
+	}{
+		{"accepted", http.StatusAccepted, 0},
+		{"forbidden", http.StatusForbidden, http.StatusForbidden},
+		{"unauthorized", http.StatusUnauthorized, http.StatusUnauthorized},
+		{"gateway error", http.StatusInternalServerError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/worker/assignments" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			adapter := notifier.NewNotifierAdapter(*server.Client(), server.URL)
+			err := adapter.NotifyAssignment(uuid.New(), uuid.New())
+
+			if tt.expected == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			var requestErr *utils.RequestError
+			if !errors.As(err, &requestErr) || requestErr.Code != tt.expected {
+				t.Fatalf("expected request error with status %d, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestNotifyAssignment_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	adapter := notifier.NewNotifierAdapter(http.Client{}, server.URL)
+	if err := adapter.NotifyAssignment(uuid.New(), uuid.New()); err == nil {
+		t.Fatal("expected error for an unreachable worker-gateway")
+	}
+}
@@ -1,9 +1,13 @@
 package core
 
 import (
+	"cmp"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/utils"
 )
@@ -25,15 +29,15 @@ func GetAlreadyAssigned(jobs []ports.Job, workers []ports.Worker) []ports.Job {
 }
 
 func GetAllUnassigned(jobs, unassignedJobs []ports.Job, workers []ports.Worker) ([]ports.Job, []ports.Worker) {
-	unassignedJobsMap := make(map[ports.Job]struct{})
+	unassignedJobsMap := make(map[uuid.UUID]struct{})
 	for _, job := range unassignedJobs {
-		unassignedJobsMap[job] = struct{}{}
+		unassignedJobsMap[job.ID] = struct{}{}
 	}
 
 	assignedWorkersMap := make(map[uuid.UUID]struct{})
 
 	jobResult := utils.Filter(jobs, func(job ports.Job) bool {
-		_, exists := unassignedJobsMap[job]
+		_, exists := unassignedJobsMap[job.ID]
 		isJobUnassigned := job.Status == ports.JobStatusQueued || (job.Status == ports.JobStatusScheduled && exists)
 
 		if !isJobUnassigned && job.Status == ports.JobStatusScheduled {
@@ -75,12 +79,68 @@ func GetCarbonZones(unassignedJobs []ports.Job, unassignedWorkers []ports.Worker
 }
 
 // meant are: jobs = unassigned jobs, workers = unassigned workers
+// The jobs of each optimisation target are distributed in the order of ports.Targets, the workers
+// left over are used for the next target. Zones without data for a target are left out for its jobs.
+// If no zone has data for a target, e.g. the provider runs without POWER_BREAKDOWN, its jobs are
+// distributed by carbon intensity instead of staying queued.
+// Within a target, jobs restricted to some zones get workers first, the others may run anywhere.
 func DistributeJobs(jobs []ports.Job, workers []ports.Worker, carbons []ports.CarbonIntensityData) []ports.UpdateJob {
+	jobUpdates := make([]ports.UpdateJob, 0)
+	for _, target := range ports.Targets {
+		scoreTarget := target
+		targetCarbons := utils.Filter(carbons, func(carbon ports.CarbonIntensityData) bool {
+			_, ok := carbon.Score(target)
+			return ok
+		})
+		if len(targetCarbons) == 0 && len(carbons) > 0 && slices.ContainsFunc(jobs, func(job ports.Job) bool { return TargetOf(job) == target }) {
+			logging.Warn("No zone has data for the optimisation target, is POWER_BREAKDOWN enabled? Scheduling its jobs by carbon intensity",
+				"target", target)
+			scoreTarget = ports.TargetCarbonIntensity
+			targetCarbons = carbons
+		}
+		for _, restricted := range []bool{true, false} {
+			targetJobs := utils.Filter(jobs, func(job ports.Job) bool {
+				return TargetOf(job) == target && IsRestricted(job) == restricted
+			})
+			if len(targetJobs) == 0 {
+				continue
+			}
+
+			updates := distributeJobs(targetJobs, workers, targetCarbons, scoreTarget)
+			jobUpdates = append(jobUpdates, updates...)
+			workers = utils.Filter(workers, func(worker ports.Worker) bool {
+				return !slices.ContainsFunc(updates, func(update ports.UpdateJob) bool { return update.WorkerID == worker.Id })
+			})
+		}
+	}
+	return jobUpdates
+}
+
+// TargetOf returns the optimisation target of the job, TargetCarbonIntensity for an empty or unknown one
+func TargetOf(job ports.Job) string {
+	if slices.Contains(ports.Targets, job.OptimisationTarget) {
+		return job.OptimisationTarget
+	}
+	return ports.TargetCarbonIntensity
+}
+
+// distributeJobs moves each job to a worker in a zone with a better score for the target than its own zone
+func distributeJobs(jobs []ports.Job, workers []ports.Worker, carbons []ports.CarbonIntensityData, target string) []ports.UpdateJob {
 	// small -> big
-	sortedCarbons := SortCabonData(carbons)
+	sortedCarbons := SortCarbonDataBy(carbons, target)
 
 	sortedJobs, sortedWorkers, carbonsMap := PrepareDistributionData(jobs, workers, sortedCarbons)
 
+	// workers are chosen by the weighted score, so a down-weighted zone has to save more
+	scoreMap := make(map[string]float64, len(carbons))
+	weightedMap := make(map[string]float64, len(carbons))
+	measuredMap := make(map[string]time.Time, len(carbons))
+	for _, carbon := range carbons {
+		scoreMap[carbon.Zone], _ = carbon.Score(target)
+		weightedMap[carbon.Zone], _ = carbon.WeightedScore(target)
+		measuredMap[carbon.Zone] = carbon.MeasuredAt
+	}
+
 	jobsIndex := len(sortedJobs) - 1
 	workersIndex := len(sortedWorkers) - 1
 	jobUpdates := make([]ports.UpdateJob, 0)
@@ -89,20 +149,44 @@ func DistributeJobs(jobs []ports.Job, workers []ports.Worker, carbons []ports.Ca
 		job := sortedJobs[jobsIndex]
 		worker := sortedWorkers[workersIndex]
 
-		jobCarbons := carbonsMap[job.CreationZone]
-		workerCarbons := carbonsMap[worker.Zone]
+		jobScore := scoreMap[job.CreationZone]
+		workerScore := weightedMap[worker.Zone]
 
-		if workerCarbons >= jobCarbons {
+		if workerScore >= jobScore {
 			workersIndex--
 			continue
 		}
 
+		// all remaining workers are better than the job's zone, one in an allowed zone is taken. One
+		// that has the image cached is preferred, the carbon order decides between them
+		chosenIndex := -1
+		for i := workersIndex; i >= 0; i-- {
+			if !IsAllowed(job, sortedWorkers[i].Zone) {
+				continue
+			}
+			if chosenIndex < 0 {
+				chosenIndex = i
+			}
+			if HasImage(sortedWorkers[i], job.Image) {
+				chosenIndex = i
+				break
+			}
+		}
+		if chosenIndex < 0 {
+			// no better worker in the allowed zones, the job stays queued
+			jobsIndex--
+			continue
+		}
+		worker = sortedWorkers[chosenIndex]
+		sortedWorkers = slices.Delete(sortedWorkers, chosenIndex, chosenIndex+1)
+
 		jobUpdate := ports.UpdateJob{
 			ID:              job.ID,
 			WorkerID:        worker.Id,
 			ComputeZone:     worker.Zone,
 			CarbonIntensity: carbonsMap[worker.Zone],
 			CarbonSavings:   carbonsMap[job.CreationZone] - carbonsMap[worker.Zone],
+			CarbonMeasured:  measuredMap[worker.Zone],
 		}
 		jobUpdates = append(jobUpdates, jobUpdate)
 
@@ -113,16 +197,81 @@ func DistributeJobs(jobs []ports.Job, workers []ports.Worker, carbons []ports.Ca
 	return jobUpdates
 }
 
+// IsRestricted reports whether the job may only run in some zones
+func IsRestricted(job ports.Job) bool {
+	return len(job.AllowedZones) > 0 || len(job.AllowedRegions) > 0
+}
+
+// IsAllowed reports whether the job may run in the zone. The zones of the allowed regions must have
+// been added with ResolveAllowedZones.
+func IsAllowed(job ports.Job, zone string) bool {
+	return !IsRestricted(job) || slices.Contains(job.AllowedZones, zone)
+}
+
+// ResolveAllowedZones returns the jobs with the zones of their allowed regions added to the allowed zones.
+// A region matches the regions, continent or country of a zone, ignoring case. An allowed alias of a zone
+// allows the zone as well.
+func ResolveAllowedZones(jobs []ports.Job, zones []ports.Zone) []ports.Job {
+	result := make([]ports.Job, len(jobs))
+	for i, job := range jobs {
+		if IsRestricted(job) {
+			allowed := slices.Clone(job.AllowedZones)
+			for _, zone := range zones {
+				if slices.Contains(allowed, zone.Code) {
+					continue
+				}
+				if InRegion(zone, job.AllowedRegions) || slices.ContainsFunc(zone.Aliases, func(alias string) bool {
+					return slices.Contains(job.AllowedZones, alias)
+				}) {
+					allowed = append(allowed, zone.Code)
+				}
+			}
+			job.AllowedZones = allowed
+		}
+		result[i] = job
+	}
+	return result
+}
+
+// InRegion reports whether the zone is in one of the regions, continents or countries
+func InRegion(zone ports.Zone, regions []string) bool {
+	for _, region := range regions {
+		if strings.EqualFold(zone.Country, region) || strings.EqualFold(zone.Continent, region) ||
+			slices.ContainsFunc(zone.Regions, func(r string) bool { return strings.EqualFold(r, region) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasImage reports whether the worker has the image cached. Images that are not pinned
+// by digest are matched by tag, "latest" if the version is empty.
+func HasImage(worker ports.Worker, image ports.ContainerImage) bool {
+	if image.Name == "" {
+		return false
+	}
+	if image.Digest != "" {
+		return slices.Contains(worker.CachedImages, image.Name+"@"+image.Digest)
+	}
+	version := image.Version
+	if version == "" {
+		version = "latest"
+	}
+	return slices.Contains(worker.CachedImages, image.Name+":"+version)
+}
+
 func SortCabonData(carbons []ports.CarbonIntensityData) []ports.CarbonIntensityData {
+	return SortCarbonDataBy(carbons, ports.TargetCarbonIntensity)
+}
+
+// SortCarbonDataBy returns a copy of the carbons ordered by their weighted score for the target, best first
+func SortCarbonDataBy(carbons []ports.CarbonIntensityData, target string) []ports.CarbonIntensityData {
 	copyCarbons := make([]ports.CarbonIntensityData, len(carbons))
 	copy(copyCarbons, carbons)
 	slices.SortFunc(copyCarbons, func(i, j ports.CarbonIntensityData) int {
-		if i.CarbonIntensity < j.CarbonIntensity {
-			return -1
-		} else if i.CarbonIntensity > j.CarbonIntensity {
-			return 1
-		}
-		return 0
+		iScore, _ := i.WeightedScore(target)
+		jScore, _ := j.WeightedScore(target)
+		return cmp.Compare(iScore, jScore)
 	})
 	return copyCarbons
 }
@@ -145,3 +294,30 @@ func PrepareDistributionData(jobs []ports.Job, workers []ports.Worker, sortedCar
 	}
 	return sortedJobs, sortedWorkers, carbonsMap
 }
+
+// StalePolicy decides how zones are scheduled whose carbon data the provider marked as stale
+type StalePolicy struct {
+	Skip    bool    // leave the zones out of the cycle, otherwise they are down-weighted
+	Penalty float64 // factor on the intensity of a stale zone when workers are chosen
+}
+
+// DefaultStalePenalty makes a stale zone look 50% dirtier when workers are chosen
+const DefaultStalePenalty = 1.5
+
+// ApplyStalePolicy leaves out the stale zones or sets their weight to the penalty of the policy.
+// The carbon intensity itself is kept, it is still recorded on the job.
+func ApplyStalePolicy(carbons []ports.CarbonIntensityData, policy StalePolicy) []ports.CarbonIntensityData {
+	if policy.Skip {
+		return utils.Filter(carbons, func(carbon ports.CarbonIntensityData) bool {
+			return !carbon.Stale
+		})
+	}
+	result := make([]ports.CarbonIntensityData, len(carbons))
+	for i, carbon := range carbons {
+		if carbon.Stale {
+			carbon.Weight = policy.Penalty
+		}
+		result[i] = carbon
+	}
+	return result
+}
@@ -1,8 +1,11 @@
 package core_test
 
 import (
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	carbonintensity "github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/carbon-intensity"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/job"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/worker"
@@ -307,3 +310,230 @@ func TestDistributeJobs(t *testing.T) {
 		}
 	}
 }
+
+func TestDistributeJobs_PrefersCachedImage(t *testing.T) {
+	image := ports.ContainerImage{Name: "ghcr.io/org/app", Version: "1.0"}
+	jobs := []ports.Job{{ID: utils.Uuid1, CreationZone: "DE", Image: image, Status: ports.JobStatusQueued}}
+	carbons := []ports.CarbonIntensityData{{Zone: "DE", CarbonIntensity: 100}, {Zone: "JP", CarbonIntensity: 50}, {Zone: "FR", CarbonIntensity: 20}}
+
+	workers := []ports.Worker{
+		{Id: utils.Uuid2, Status: ports.WorkerStatusAvailable, Zone: "JP"},
+		{Id: utils.Uuid3, Status: ports.WorkerStatusAvailable, Zone: "FR", CachedImages: []string{"ghcr.io/org/app:1.0"}},
+	}
+	result := core.DistributeJobs(jobs, workers, carbons)
+	if len(result) != 1 || result[0].WorkerID != utils.Uuid3 || result[0].CarbonSavings != 80 {
+		t.Errorf("Expected job to be assigned to the worker with the cached image, got %v", result)
+	}
+
+	// without a cached image the carbon order decides
+	workers[1].CachedImages = nil
+	result = core.DistributeJobs(jobs, workers, carbons)
+	if len(result) != 1 || result[0].WorkerID != utils.Uuid2 {
+		t.Errorf("Expected job to be assigned by carbon order, got %v", result)
+	}
+
+	// a worker with the image in a dirtier zone than the job's zone is not used
+	workers = []ports.Worker{{Id: utils.Uuid4, Status: ports.WorkerStatusAvailable, Zone: "DE", CachedImages: []string{"ghcr.io/org/app:1.0"}}}
+	result = core.DistributeJobs(jobs, workers, carbons)
+	if len(result) != 0 {
+		t.Errorf("Expected no job update, got %v", result)
+	}
+}
+
+func TestDistributeJobs_PartialCarbonData(t *testing.T) {
+	jobs := []ports.Job{
+		{ID: utils.Uuid1, CreationZone: "DE", Status: ports.JobStatusQueued},
+		{ID: utils.Uuid2, CreationZone: "JP", Status: ports.JobStatusQueued},
+	}
+	workers := []ports.Worker{
+		{Id: utils.Uuid3, Status: ports.WorkerStatusAvailable, Zone: "FR"},
+		{Id: utils.Uuid4, Status: ports.WorkerStatusAvailable, Zone: "US"},
+	}
+	// no data for JP and US, the provider left them out
+	carbons := []ports.CarbonIntensityData{{Zone: "DE", CarbonIntensity: 100}, {Zone: "FR", CarbonIntensity: 20}}
+
+	result := core.DistributeJobs(jobs, workers, carbons)
+	if len(result) != 1 || result[0].ID != utils.Uuid1 || result[0].WorkerID != utils.Uuid3 || result[0].CarbonSavings != 80 {
+		t.Errorf("Expected only the job from DE to be moved to FR, got %v", result)
+	}
+}
+
+func TestDistributeJobs_StaleCarbonData(t *testing.T) {
+	measured := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	jobs := []ports.Job{{ID: utils.Uuid1, CreationZone: "DE", Status: ports.JobStatusQueued}}
+	workers := []ports.Worker{{Id: utils.Uuid2, Status: ports.WorkerStatusAvailable, Zone: "FR"}}
+	carbons := []ports.CarbonIntensityData{
+		{Zone: "DE", CarbonIntensity: 100},
+		{Zone: "FR", CarbonIntensity: 20, MeasuredAt: measured, Stale: true},
+	}
+
+	// skipped like a zone without data
+	result := core.DistributeJobs(jobs, workers, core.ApplyStalePolicy(carbons, core.StalePolicy{Skip: true}))
+	if len(result) != 0 {
+		t.Errorf("Expected no job update, got %v", result)
+	}
+
+	// down-weighted, FR is still greener with 30 and the real intensity is recorded
+	result = core.DistributeJobs(jobs, workers, core.ApplyStalePolicy(carbons, core.StalePolicy{Penalty: 1.5}))
+	if len(result) != 1 || result[0].WorkerID != utils.Uuid2 || result[0].CarbonIntensity != 20 || result[0].CarbonSavings != 80 || !result[0].CarbonMeasured.Equal(measured) {
+		t.Errorf("Expected job to be assigned to the worker in FR, got %v", result)
+	}
+
+	// a stale zone that is only slightly greener is not used
+	carbons[1].CarbonIntensity = 80
+	result = core.DistributeJobs(jobs, workers, core.ApplyStalePolicy(carbons, core.StalePolicy{Penalty: 1.5}))
+	if len(result) != 0 {
+		t.Errorf("Expected no job update, got %v", result)
+	}
+}
+
+func TestDistributeJobs_OptimisationTarget(t *testing.T) {
+	jobs := []ports.Job{
+		{ID: utils.Uuid1, CreationZone: "DE", OptimisationTarget: ports.TargetRenewable, Status: ports.JobStatusQueued},
+		{ID: utils.Uuid2, CreationZone: "PL", OptimisationTarget: ports.TargetRenewable, Status: ports.JobStatusQueued},
+		{ID: utils.Uuid5, CreationZone: "DE", OptimisationTarget: ports.TargetFossilFree, Status: ports.JobStatusQueued},
+	}
+	workers := []ports.Worker{
+		{Id: utils.Uuid3, Status: ports.WorkerStatusAvailable, Zone: "FR"},
+		{Id: utils.Uuid4, Status: ports.WorkerStatusAvailable, Zone: "NO"},
+	}
+	carbons := []ports.CarbonIntensityData{
+		{Zone: "DE", CarbonIntensity: 100, PowerBreakdown: &ports.PowerBreakdown{RenewablePercentage: 40, FossilFreePercentage: 45}},
+		{Zone: "FR", CarbonIntensity: 20, PowerBreakdown: &ports.PowerBreakdown{RenewablePercentage: 25, FossilFreePercentage: 92}},
+		{Zone: "NO", CarbonIntensity: 30, PowerBreakdown: &ports.PowerBreakdown{RenewablePercentage: 98, FossilFreePercentage: 98}},
+		{Zone: "PL", CarbonIntensity: 600}, // no breakdown
+	}
+
+	// FR has less renewables than DE despite its low intensity, the job from PL cannot be compared
+	// and the fossil-free job takes the worker left over
+	result := core.DistributeJobs(jobs, workers, carbons)
+	expected := []ports.UpdateJob{
+		{ID: utils.Uuid1, WorkerID: utils.Uuid4, ComputeZone: "NO", CarbonIntensity: 30, CarbonSavings: 70},
+		{ID: utils.Uuid5, WorkerID: utils.Uuid3, ComputeZone: "FR", CarbonIntensity: 20, CarbonSavings: 80},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d job updates, got %v", len(expected), result)
+	}
+	for i, jobUpdate := range result {
+		if jobUpdate != expected[i] {
+			t.Errorf("Expected job update %v, got %v", expected[i], jobUpdate)
+		}
+	}
+
+	// an unknown target is scheduled by carbon intensity
+	if target := core.TargetOf(ports.Job{OptimisationTarget: "cheapest"}); target != ports.TargetCarbonIntensity {
+		t.Errorf("Expected %s, got %s", ports.TargetCarbonIntensity, target)
+	}
+}
+
+func TestDistributeJobs_OptimisationTargetWithoutBreakdown(t *testing.T) {
+	jobs := []ports.Job{
+		{ID: utils.Uuid1, CreationZone: "DE", OptimisationTarget: ports.TargetRenewable, Status: ports.JobStatusQueued},
+		{ID: utils.Uuid2, CreationZone: "PL", OptimisationTarget: ports.TargetFossilFree, Status: ports.JobStatusQueued},
+	}
+	workers := []ports.Worker{
+		{Id: utils.Uuid3, Status: ports.WorkerStatusAvailable, Zone: "FR"},
+		{Id: utils.Uuid4, Status: ports.WorkerStatusAvailable, Zone: "NO"},
+	}
+	// the provider runs without POWER_BREAKDOWN
+	carbons := []ports.CarbonIntensityData{
+		{Zone: "DE", CarbonIntensity: 100},
+		{Zone: "FR", CarbonIntensity: 20},
+		{Zone: "NO", CarbonIntensity: 30},
+		{Zone: "PL", CarbonIntensity: 600},
+	}
+
+	// the jobs do not stay queued, they are distributed by carbon intensity and a warning is logged
+	logging.Init("job-scheduler")
+	result := core.DistributeJobs(jobs, workers, carbons)
+	expected := []ports.UpdateJob{
+		{ID: utils.Uuid1, WorkerID: utils.Uuid4, ComputeZone: "NO", CarbonIntensity: 30, CarbonSavings: 70},
+		{ID: utils.Uuid2, WorkerID: utils.Uuid3, ComputeZone: "FR", CarbonIntensity: 20, CarbonSavings: 580},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d job updates, got %v", len(expected), result)
+	}
+	for i, jobUpdate := range result {
+		if jobUpdate != expected[i] {
+			t.Errorf("Expected job update %v, got %v", expected[i], jobUpdate)
+		}
+	}
+}
+
+func TestDistributeJobs_AllowedZones(t *testing.T) {
+	jobs := []ports.Job{
+		{ID: utils.Uuid1, CreationZone: "DE", AllowedRegions: []string{"EU"}, Status: ports.JobStatusQueued},
+		{ID: utils.Uuid2, CreationZone: "DE", AllowedZones: []string{"JP"}, Status: ports.JobStatusQueued},
+		{ID: utils.Uuid5, CreationZone: "DE", Status: ports.JobStatusQueued},
+	}
+	workers := []ports.Worker{
+		{Id: utils.Uuid3, Status: ports.WorkerStatusAvailable, Zone: "CH"},
+		{Id: utils.Uuid4, Status: ports.WorkerStatusAvailable, Zone: "US"},
+		{Id: utils.Uuid6, Status: ports.WorkerStatusAvailable, Zone: "FR"},
+	}
+	jobs = core.ResolveAllowedZones(jobs, carbonintensity.MockZones)
+
+	// the EU job gets the only EU worker before the unrestricted job, which would take it otherwise.
+	// JP has no better worker, the job stays queued.
+	result := core.DistributeJobs(jobs, workers, carbonintensity.MockCarbons)
+	expected := []ports.UpdateJob{
+		{ID: utils.Uuid1, WorkerID: utils.Uuid6, ComputeZone: "FR", CarbonIntensity: 20, CarbonSavings: 80},
+		{ID: utils.Uuid5, WorkerID: utils.Uuid4, ComputeZone: "US", CarbonIntensity: 10, CarbonSavings: 90},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d job updates, got %v", len(expected), result)
+	}
+	for i, jobUpdate := range result {
+		if jobUpdate != expected[i] {
+			t.Errorf("Expected job update %v, got %v", expected[i], jobUpdate)
+		}
+	}
+}
+
+func TestResolveAllowedZones(t *testing.T) {
+	zones := []ports.Zone{
+		{Code: "DE", Country: "DE", Continent: "Europe", Regions: []string{"EU"}},
+		{Code: "GB", Country: "GB", Continent: "Europe", Aliases: []string{"UK"}},
+		{Code: "US-NY-NYIS", Country: "US", Continent: "North America"},
+	}
+	jobs := core.ResolveAllowedZones([]ports.Job{
+		{ID: utils.Uuid1, AllowedRegions: []string{"eu"}},
+		{ID: utils.Uuid2, AllowedZones: []string{"UK"}, AllowedRegions: []string{"US"}},
+		{ID: utils.Uuid3, AllowedRegions: []string{"Europe"}},
+		{ID: utils.Uuid4},
+	}, zones)
+
+	expected := [][]string{{"DE"}, {"UK", "GB", "US-NY-NYIS"}, {"DE", "GB"}, nil}
+	for i, job := range jobs {
+		if !slices.Equal(job.AllowedZones, expected[i]) {
+			t.Errorf("Expected allowed zones %v, got %v", expected[i], job.AllowedZones)
+		}
+	}
+	if core.IsAllowed(jobs[0], "GB") || !core.IsAllowed(jobs[0], "DE") || !core.IsAllowed(jobs[3], "GB") {
+		t.Error("Expected unrestricted jobs to be allowed everywhere and restricted ones only in their zones")
+	}
+
+	// without the zones, a job with regions only is not allowed anywhere
+	unresolved := core.ResolveAllowedZones([]ports.Job{{ID: utils.Uuid1, AllowedRegions: []string{"EU"}}}, nil)
+	if core.IsAllowed(unresolved[0], "DE") {
+		t.Error("Expected unresolved region not to allow any zone")
+	}
+}
+
+func TestHasImage(t *testing.T) {
+	digest := "sha256:2d1b4e8f9a0c3b5d7e6f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d"
+	worker := ports.Worker{CachedImages: []string{"alpine:latest", "ghcr.io/org/app@" + digest}}
+
+	if !core.HasImage(worker, ports.ContainerImage{Name: "alpine"}) {
+		t.Error("Expected image without version to match the latest tag")
+	}
+	if core.HasImage(worker, ports.ContainerImage{Name: "alpine", Version: "3.20"}) {
+		t.Error("Expected other tag not to match")
+	}
+	if !core.HasImage(worker, ports.ContainerImage{Name: "ghcr.io/org/app", Version: "1.0", Digest: digest}) {
+		t.Error("Expected pinned image to match by digest")
+	}
+	if core.HasImage(worker, ports.ContainerImage{Name: "ghcr.io/org/app", Version: "1.0"}) {
+		t.Error("Expected image without cached tag not to match")
+	}
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
@@ -12,6 +14,8 @@ type JobSchedulerService struct {
 	JobAdapter             ports.JobAdapter
 	WorkerAdapter          ports.WorkerAdapter
 	CarbonIntensityAdapter ports.CarbonIntensityAdapter
+	Notifier               ports.Notifier
+	StalePolicy            StalePolicy
 }
 
 var _ ports.JobScheduler = (*JobSchedulerService)(nil)
@@ -20,11 +24,14 @@ func NewJobSchedulerService(
 	jobAdapter ports.JobAdapter,
 	workerAdapter ports.WorkerAdapter,
 	carbonIntensityAdapter ports.CarbonIntensityAdapter,
+	notifier ports.Notifier,
 ) *JobSchedulerService {
 	return &JobSchedulerService{
 		JobAdapter:             jobAdapter,
 		WorkerAdapter:          workerAdapter,
 		CarbonIntensityAdapter: carbonIntensityAdapter,
+		Notifier:               notifier,
+		StalePolicy:            StalePolicy{Skip: true},
 	}
 }
 
@@ -45,6 +52,7 @@ func (js *JobSchedulerService) ScheduleJob() error {
 	assignedJobs := GetAlreadyAssigned(jobs, workers)
 	unassignedJobs := js.reassignWorkers(assignedJobs)
 	jobs, workers = GetAllUnassigned(jobs, unassignedJobs, workers)
+	jobs = js.resolveAllowedZones(jobs)
 
 	// 3. Get Carbon Intensity Data
 	zones := GetCarbonZones(jobs, workers)
@@ -55,6 +63,7 @@ func (js *JobSchedulerService) ScheduleJob() error {
 	if carbons == nil {
 		return nil // Nothing to schedule, just abort
 	}
+	carbons = js.applyStalePolicy(carbons)
 
 	// 4. Distribute Jobs
 	jobUpdates := DistributeJobs(jobs, workers, carbons)
@@ -106,6 +115,29 @@ func (js *JobSchedulerService) getCarbonIntensities(zones []string) (ports.Carbo
 	return carbons, nil
 }
 
+// resolveAllowedZones adds the zones of the allowed regions to the allowed zones of the jobs. The zones are
+// only fetched if a job is restricted. Without them, jobs stay in their explicitly allowed zones.
+func (js *JobSchedulerService) resolveAllowedZones(jobs []ports.Job) []ports.Job {
+	if !slices.ContainsFunc(jobs, IsRestricted) {
+		return jobs
+	}
+	zones, err := js.CarbonIntensityAdapter.GetZones()
+	if err != nil {
+		logging.Warn(fmt.Sprintf("Error getting zones, jobs with allowed regions only run in their allowed zones: %v", err))
+	}
+	return ResolveAllowedZones(jobs, zones)
+}
+
+func (js *JobSchedulerService) applyStalePolicy(carbons ports.CarbonIntensityResponse) ports.CarbonIntensityResponse {
+	for _, carbon := range carbons {
+		if carbon.Stale {
+			logging.Warn(fmt.Sprintf("Carbon intensity data of zone %s is stale (measured at %s), skip: %t",
+				carbon.Zone, carbon.MeasuredAt.Format(time.RFC3339), js.StalePolicy.Skip))
+		}
+	}
+	return ApplyStalePolicy(carbons, js.StalePolicy)
+}
+
 func (js *JobSchedulerService) assignJobsToWorkers(jobs []ports.UpdateJob) error {
 	for _, job := range jobs {
 		err := js.JobAdapter.AssignJob(job)
@@ -122,6 +154,12 @@ func (js *JobSchedulerService) assignJobsToWorkers(jobs []ports.UpdateJob) error
 			logging.Error(fmt.Sprintf("Error updating worker %s for job %s: %v", job.WorkerID, job.ID, err))
 			return err
 		}
+
+		// The worker also finds the job with its next poll, so a failed notification is not an error
+		err = js.Notifier.NotifyAssignment(job.ID, job.WorkerID)
+		if err != nil {
+			logging.Warn(fmt.Sprintf("Error notifying worker %s about job %s: %v", job.WorkerID, job.ID, err))
+		}
 	}
 	return nil
 }
@@ -142,6 +180,10 @@ func (js *JobSchedulerService) reassignWorkers(jobs []ports.Job) []ports.Job {
 			continue
 		}
 
+		if err := js.Notifier.NotifyAssignmentCorrection(job.ID, uuid); err != nil {
+			logging.Warn(fmt.Sprintf("Error notifying worker %s about job %s: %v", uuid, job.ID, err))
+		}
+
 	}
 
 	return unassignedJobs
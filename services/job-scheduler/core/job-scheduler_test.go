@@ -6,6 +6,7 @@ import (
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	carbonintensity "github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/carbon-intensity"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/job"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/notifier"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/worker"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/core"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
@@ -28,6 +29,7 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(false, false, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            false,
 		},
 		// -------------------------- Jobs --------------------------
@@ -36,6 +38,7 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(true, false, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            true,
 		},
 		{
@@ -43,6 +46,7 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(false, true, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            false,
 		},
 		{
@@ -50,6 +54,7 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(false, false, true),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            true,
 		},
 		// -------------------------- Workers --------------------------
@@ -58,6 +63,7 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(false, false, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(true, false, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            true,
 		},
 		{
@@ -65,6 +71,7 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(false, false, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, true, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            false,
 		},
 		{
@@ -72,14 +79,25 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(false, false, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, true),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            true,
 		},
+		// -------------------------- Notifier --------------------------
+		{
+			Description:            "Test with notify error",
+			JobAdapter:             job.NewJobAdapterMock(false, false, false),
+			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, false),
+			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, false),
+			Notifier:               notifier.NewNotifierAdapterMock(true),
+			ShouldError:            false,
+		},
 		// -------------------------- Carbons --------------------------
 		{
 			Description:            "Test with get carbons error",
 			JobAdapter:             job.NewJobAdapterMock(false, false, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(true, false),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            true,
 		},
 		{
@@ -87,6 +105,7 @@ func TestScheduleJob(t *testing.T) {
 			JobAdapter:             job.NewJobAdapterMock(false, false, false),
 			WorkerAdapter:          worker.NewWorkerAdapterMock(false, false, false),
 			CarbonIntensityAdapter: carbonintensity.NewCarbonIntensityAdapterMock(false, true),
+			Notifier:               notifier.NewNotifierAdapterMock(false),
 			ShouldError:            false,
 		},
 	}
@@ -111,5 +130,6 @@ func createJobSchedulerService(row TestJobScheduleTableRow) *core.JobSchedulerSe
 		row.JobAdapter,
 		row.WorkerAdapter,
 		row.CarbonIntensityAdapter,
+		row.Notifier,
 	)
 }
@@ -17,6 +17,7 @@ import (
 	handler_http "github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/handler-http"
 	interval_runner "github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/interval-runner"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/job"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/notifier"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/adapters/worker"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/core"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job-scheduler/ports"
@@ -29,11 +30,14 @@ type Environments struct {
 	WorkerRegestryUrl          string
 	JobServiceUrl              string
 	CarbonIntensityProviderUrl string
+	WorkerGatewayUrl           string
 	Port                       string
 	UserManagementUrl          string
 	AuthToken                  string
 	OTLPExporterOtlpEndpoint   string // OpenTelemetry endpoint for tracing
 	Secret                     string // Secret for job scheduling
+	StalePolicy                core.StalePolicy
+	EmissionFactor             ports.EmissionFactor // carbon intensity the jobs are scheduled by
 }
 
 func main() {
@@ -60,12 +64,18 @@ func main() {
 
 	var jobAdapter ports.JobAdapter = job.NewJobAdapter(customClient, envs.JobServiceUrl)
 	var workerAdapter ports.WorkerAdapter = worker.NewWorkerAdapter(customClient, envs.WorkerRegestryUrl)
-	var carbonIntensityAdapter ports.CarbonIntensityAdapter = carbonintensity.NewCarbonIntensityAdapter(customClient, envs.CarbonIntensityProviderUrl)
-	var service ports.JobScheduler = core.NewJobSchedulerService(
+	carbonIntensity := carbonintensity.NewCarbonIntensityAdapter(customClient, envs.CarbonIntensityProviderUrl)
+	carbonIntensity.Factor = envs.EmissionFactor
+	var carbonIntensityAdapter ports.CarbonIntensityAdapter = carbonIntensity
+	var notifierAdapter ports.Notifier = notifier.NewNotifierAdapter(customClient, envs.WorkerGatewayUrl)
+	scheduler := core.NewJobSchedulerService(
 		jobAdapter,
 		workerAdapter,
 		carbonIntensityAdapter,
+		notifierAdapter,
 	)
+	scheduler.StalePolicy = envs.StalePolicy
+	var service ports.JobScheduler = scheduler
 
 	// Start the HTTP server
 	srv := &http.Server{Addr: ":" + envs.Port}
@@ -129,6 +139,12 @@ func loadEnvVariables() (Environments, error) {
 	}
 	envs.CarbonIntensityProviderUrl = carbonProvider
 
+	workerGateway, err := utils.LoadEnvRequired("WORKER_GATEWAY")
+	if err != nil || !utils.IsUrlValid(workerGateway) {
+		return envs, err
+	}
+	envs.WorkerGatewayUrl = workerGateway
+
 	port := utils.LoadEnvOrDefault("PORT", "8080")
 	portInt, err := strconv.Atoi(port)
 	if err != nil || !utils.IsPortValid(portInt) {
@@ -160,5 +176,30 @@ func loadEnvVariables() (Environments, error) {
 	}
 	envs.Secret = secret
 
+	stalePolicy := utils.LoadEnvOrDefault("STALE_CARBON_POLICY", "skip")
+	if stalePolicy != "skip" && stalePolicy != "penalize" {
+		return envs, fmt.Errorf("STALE_CARBON_POLICY must be skip or penalize, got %s", stalePolicy)
+	}
+	penalty, err := strconv.ParseFloat(utils.LoadEnvOrDefault("STALE_CARBON_PENALTY", strconv.FormatFloat(core.DefaultStalePenalty, 'f', -1, 64)), 64)
+	if err != nil || penalty < 1 {
+		return envs, fmt.Errorf("STALE_CARBON_PENALTY must be a number of at least 1")
+	}
+	envs.StalePolicy = core.StalePolicy{Skip: stalePolicy == "skip", Penalty: penalty}
+
+	emissionType := utils.LoadEnvOrDefault("CARBON_EMISSION_TYPE", ports.EmissionTypeAverage)
+	if emissionType != ports.EmissionTypeAverage && emissionType != ports.EmissionTypeMarginal {
+		return envs, fmt.Errorf("CARBON_EMISSION_TYPE must be average or marginal, got %s", emissionType)
+	}
+	// marginal intensities are only available as direct emissions
+	defaultScope := ports.EmissionScopeLifecycle
+	if emissionType == ports.EmissionTypeMarginal {
+		defaultScope = ports.EmissionScopeDirect
+	}
+	emissionScope := utils.LoadEnvOrDefault("CARBON_EMISSION_SCOPE", defaultScope)
+	if emissionScope != ports.EmissionScopeLifecycle && emissionScope != ports.EmissionScopeDirect {
+		return envs, fmt.Errorf("CARBON_EMISSION_SCOPE must be lifecycle or direct, got %s", emissionScope)
+	}
+	envs.EmissionFactor = ports.EmissionFactor{Type: emissionType, Scope: emissionScope}
+
 	return envs, nil
 }
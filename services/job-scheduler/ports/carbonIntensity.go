@@ -1,13 +1,107 @@
 package ports
 
+import "time"
+
+// Emission factor types and scopes of the carbon intensity provider
+const (
+	EmissionTypeAverage    = "average"
+	EmissionTypeMarginal   = "marginal"
+	EmissionScopeLifecycle = "lifecycle"
+	EmissionScopeDirect    = "direct"
+)
+
+// EmissionFactor selects the kind of carbon intensity, empty fields use the default of the provider
+type EmissionFactor struct {
+	Type  string `json:"type"`
+	Scope string `json:"scope"`
+}
+
+// Optimisation targets of a job, an empty or unknown target is TargetCarbonIntensity.
+// The shares need the power breakdown, which the provider only fetches with POWER_BREAKDOWN=true.
+const (
+	TargetCarbonIntensity = "carbon-intensity" // lowest carbon intensity
+	TargetRenewable       = "renewable"        // highest renewable share of the production
+	TargetFossilFree      = "fossil-free"      // highest share of renewables and nuclear
+)
+
+// Targets in the order their jobs get workers in a scheduling cycle
+var Targets = []string{TargetCarbonIntensity, TargetRenewable, TargetFossilFree}
+
+// PowerBreakdown is the production mix of a zone, only sent if the provider fetches it
+type PowerBreakdown struct {
+	RenewablePercentage  float64            `json:"renewablePercentage"`
+	FossilFreePercentage float64            `json:"fossilFreePercentage"`
+	ProductionMix        map[string]float64 `json:"productionMix,omitempty"` // MW by production type
+}
+
 type CarbonIntensityData struct {
 	Zone            string  `json:"zone"`
 	CarbonIntensity float64 `json:"carbonIntensity"`
+	EmissionFactor
+	MeasuredAt  time.Time `json:"measuredAt"`  // zero if the provider does not know it
+	FetchedAt   time.Time `json:"fetchedAt"`   // time the provider fetched the value
+	IsEstimated bool      `json:"isEstimated"` // forecast or estimate instead of a measurement
+	Stale       bool      `json:"stale"`       // older than the max age of the provider
+
+	PowerBreakdown *PowerBreakdown `json:"powerBreakdown,omitempty"`
+
+	// set by the job-scheduler, factor on the intensity when workers are chosen, 0 counts as 1
+	Weight float64 `json:"-"`
+}
+
+// WeightedIntensity is the intensity the workers of the zone are chosen by
+func (c CarbonIntensityData) WeightedIntensity() float64 {
+	if c.Weight == 0 {
+		return c.CarbonIntensity
+	}
+	return c.CarbonIntensity * c.Weight
+}
+
+// Score is what the zone is compared by for the target, lower is better. The shares of the power
+// breakdown are scored by the remaining percentage, ok is false for a zone without breakdown.
+func (c CarbonIntensityData) Score(target string) (score float64, ok bool) {
+	switch target {
+	case TargetRenewable, TargetFossilFree:
+		if c.PowerBreakdown == nil {
+			return 0, false
+		}
+		if target == TargetRenewable {
+			return 100 - c.PowerBreakdown.RenewablePercentage, true
+		}
+		return 100 - c.PowerBreakdown.FossilFreePercentage, true
+	default:
+		return c.CarbonIntensity, true
+	}
+}
+
+// WeightedScore is the score the workers of the zone are chosen by
+func (c CarbonIntensityData) WeightedScore(target string) (float64, bool) {
+	score, ok := c.Score(target)
+	if c.Weight == 0 {
+		return score, ok
+	}
+	return score * c.Weight, ok
 }
 
 // CarbonIntensityResponse is the response from the carbon intensity provider
 type CarbonIntensityResponse []CarbonIntensityData
 
+// Zone is an available zone of the carbon intensity provider with the metadata jobs are restricted by
+type Zone struct {
+	Code      string   `json:"code"`
+	Name      string   `json:"name"`
+	Country   string   `json:"country"`
+	Continent string   `json:"continent"`
+	Regions   []string `json:"regions"`
+	Aliases   []string `json:"aliases"`
+}
+
+// ZonesResponse is the response of the zones endpoint of the carbon intensity provider
+type ZonesResponse struct {
+	Zones []Zone `json:"zones"`
+}
+
 type CarbonIntensityAdapter interface {
 	GetCarbonIntensities(zones []string) (CarbonIntensityResponse, error)
+	GetZones() ([]Zone, error)
 }
@@ -1,6 +1,8 @@
 package ports
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -21,7 +23,14 @@ type Job struct {
 	ID uuid.UUID `json:"id"` // generated as UUID
 
 	// set by consumer-cli, theyre not empty by default
-	CreationZone string `json:"creationZone"` // origin of the job creation
+	CreationZone string         `json:"creationZone"` // origin of the job creation
+	Image        ContainerImage `json:"image"`        // workers that have it cached are preferred
+	// OptimisationTarget is what the compute zone is chosen by, see the Target constants
+	OptimisationTarget string `json:"optimisationTarget"`
+	// AllowedZones and AllowedRegions restrict the compute zone, a job without both may run anywhere.
+	// The zones of the regions are added to AllowedZones when the job is scheduled.
+	AllowedZones   []string `json:"allowedZones"`
+	AllowedRegions []string `json:"allowedRegions"` // regions, continents or countries of the zone metadata, e.g. "EU"
 
 	// set by job-scheduler
 	WorkerID        string `json:"workerId"`        // default value is empty string - saved as UUID
@@ -33,6 +42,12 @@ type Job struct {
 	Status JobStatus `json:"status"` // default value is "queued"
 }
 
+type ContainerImage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Digest  string `json:"digest,omitempty"` // e.g. "sha256:...", pins the image
+}
+
 // This struct is used for the get-request to the job service
 type GetJobsResponse []Job
 
@@ -48,6 +63,7 @@ type UpdateJobPayload struct {
 	ComputeZone     string    `json:"computeZone"`     //
 	CarbonIntensity int       `json:"carbonIntensity"` //
 	CarbonSaving    int       `json:"carbonSavings"`   //
+	CarbonDataAge   int       `json:"carbonDataAge"`   // seconds since the carbon intensity was measured, -1 if unknown
 	Status          JobStatus `json:"status"`          // default (and probably only) value is "scheduled"
 }
 
@@ -66,6 +82,7 @@ type UpdateJob struct {
 	ComputeZone     string    `json:"computeZone"`
 	CarbonIntensity float64   `json:"carbonIntensity"`
 	CarbonSavings   float64   `json:"carbonSavings"`
+	CarbonMeasured  time.Time `json:"carbonMeasured"` // measurement time of the compute zone's carbon intensity
 	// No status on this struct, because there is only 1 possible option,
 	// so the function will set it itself.
 }
@@ -9,3 +9,9 @@ type Notifier interface {
 	NotifyWorkerAssignmentFailed(jobId, workerId uuid.UUID) error // for when the job got assigned, but the worker for whatever reason not
 	NotifyAssignmentCorrection(jobId, workerId uuid.UUID) error   // for when an worker gets assigned after it failed in a previous cycle
 }
+
+// This struct is used for the post-request to the worker gateway
+type AssignmentPayload struct {
+	JobID    uuid.UUID `json:"jobId"`
+	WorkerID uuid.UUID `json:"workerId"`
+}
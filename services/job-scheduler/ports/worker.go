@@ -12,9 +12,10 @@ const (
 )
 
 type Worker struct {
-	Id     uuid.UUID    `json:"id"`
-	Status WorkerStatus `json:"status"`
-	Zone   string       `json:"zone"`
+	Id           uuid.UUID    `json:"id"`
+	Status       WorkerStatus `json:"status"`
+	Zone         string       `json:"zone"`
+	CachedImages []string     `json:"cachedImages,omitempty"` // "name:tag" and "name@digest" reported by the worker daemon
 }
 
 type GetWorkersResponse []Worker
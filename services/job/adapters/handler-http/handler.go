@@ -2,6 +2,7 @@ package handler_http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -26,8 +27,10 @@ func NewHandler(service ports.JobService) *Handler {
 	h.rtr.HandleFunc("/jobs", h.CreateJob).Methods("POST")
 	h.rtr.HandleFunc("/jobs/{id}", h.GetJob).Methods("GET")
 	h.rtr.HandleFunc("/jobs/{id}/outcome", h.GetJobOutcome).Methods("GET")
+	h.rtr.HandleFunc("/jobs/{id}/inputs/{name}", h.GetInputFile).Methods("GET")
 	h.rtr.HandleFunc("/jobs/{id}/update-scheduler", h.UpdateJobScheduler).Methods("PATCH")
 	h.rtr.HandleFunc("/jobs/{id}/update-workerdaemon", h.UpdateJobWorkerDaemon).Methods("PATCH")
+	h.rtr.HandleFunc("/jobs/{id}/update-progress", h.UpdateJobProgress).Methods("PATCH")
 	return h
 }
 
@@ -36,7 +39,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.rtr.ServeHTTP(w, r)
 }
 
-// getJobs handles GET requests to retrieve jobs, possibly filtered by status
+// getJobs handles GET requests to retrieve jobs, possibly filtered by status and worker
 func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
 	statusStrings := r.URL.Query()["status"]
 	var statuses []ports.JobStatus
@@ -57,7 +60,13 @@ func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	jobs, err := h.service.GetJobs(r.Context(), statuses)
+	var jobs []ports.Job
+	var err error
+	if workerID := r.URL.Query().Get("workerId"); workerID != "" {
+		jobs, err = h.service.GetJobsByWorker(r.Context(), workerID, statuses)
+	} else {
+		jobs, err = h.service.GetJobs(r.Context(), statuses)
+	}
 	if err != nil {
 		http.Error(w, HTTPErr500, http.StatusInternalServerError)
 		return
@@ -72,10 +81,21 @@ func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(jobs)
 }
 
+// maxCreateJobSize limits the body of POST /jobs, the base64 encoded input files of
+// core.MaxInputContentSize need about 10.7 MiB, the rest is left for the job itself.
+const maxCreateJobSize = 12 << 20
+
 // createJob handles POST requests to create a new job
 func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
 	var job ports.JobCreate
+	r.Body = http.MaxBytesReader(w, r.Body, maxCreateJobSize)
 	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, HTTPErr413InputTooLarge, http.StatusRequestEntityTooLarge)
+			logging.Warn("Request body too large: " + err.Error())
+			return
+		}
 		http.Error(w, HTTPErr400InvalidInputData, http.StatusBadRequest)
 		logging.Warn("Failed to decode request body: " + err.Error())
 		return
@@ -106,6 +126,19 @@ func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(job)
 }
 
+// getInputFile serves the content of an uploaded input file, the worker daemon downloads it before the job runs
+func (h *Handler) GetInputFile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	content, err := h.service.GetInputFile(r.Context(), vars["id"], vars["name"])
+	if CheckAndSetErr(w, err) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(content)
+}
+
 // getJobOutcome retrieves the outcome of a job by its ID
 func (h *Handler) GetJobOutcome(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -164,9 +197,42 @@ func (h *Handler) UpdateJobWorkerDaemon(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(updatedJob)
 }
 
+// updateJobProgress handles PATCH requests to store the progress of a running job
+func (h *Handler) UpdateJobProgress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var progress ports.JobProgress
+	err := json.NewDecoder(r.Body).Decode(&progress)
+	if err != nil {
+		http.Error(w, HTTPErr400InvalidInputData, http.StatusBadRequest)
+		logging.Warn("Failed to decode request body: " + err.Error())
+		return
+	}
+
+	updatedJob, err := h.service.UpdateJobProgress(r.Context(), id, progress)
+	if CheckAndSetErr(w, err) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedJob)
+}
+
 // checkAndSetErr checks for errors and sets the appropriate HTTP response status and message
 func CheckAndSetErr(w http.ResponseWriter, err error) bool {
 	if err != nil {
+		// policy errors carry the rejected registry or repository
+		switch {
+		case errors.Is(err, ports.ErrImageNotAllowed):
+			http.Error(w, HTTPErr403ImageNotAllowed, http.StatusForbidden)
+			logging.Warn(err.Error())
+			return true
+		case errors.Is(err, ports.ErrImageSignatureInvalid):
+			http.Error(w, HTTPErr403ImageSignature, http.StatusForbidden)
+			logging.Warn(err.Error())
+			return true
+		}
 		switch err {
 		case ports.ErrNotExistingID:
 			http.Error(w, HTTPErr400MissId, http.StatusBadRequest)
@@ -180,9 +246,33 @@ func CheckAndSetErr(w http.ResponseWriter, err error) bool {
 		case ports.ErrNotExistingJobName, ports.ErrNotExistingImageName:
 			http.Error(w, HTTPErr400FieldEmpty, http.StatusBadRequest)
 			logging.Warn(err.Error())
+		case ports.ErrImageDigestIsInvalid:
+			http.Error(w, HTTPErr400InvalidDigest, http.StatusBadRequest)
+			logging.Warn(err.Error())
+		case ports.ErrOptimisationTarget:
+			http.Error(w, HTTPErr400InvalidTarget, http.StatusBadRequest)
+			logging.Warn(err.Error())
+		case ports.ErrAllowedZoneEmpty:
+			http.Error(w, HTTPErr400InvalidAllowedZone, http.StatusBadRequest)
+			logging.Warn(err.Error())
 		case ports.ErrImageVersionIsInvalid, ports.ErrParamKeyValueEmpty:
 			http.Error(w, HTTPErr400InvalidInputData, http.StatusBadRequest)
 			logging.Warn(err.Error())
+		case ports.ErrInputFileName, ports.ErrInputFileSource, ports.ErrInputFileChecksum:
+			http.Error(w, HTTPErr400InvalidInputFile, http.StatusBadRequest)
+			logging.Warn(err.Error())
+		case ports.ErrInputFileNotFound:
+			http.Error(w, HTTPErr404InputFileNotFound, http.StatusNotFound)
+			logging.Warn(err.Error())
+		case ports.ErrInputFileTooLarge:
+			http.Error(w, HTTPErr413InputTooLarge, http.StatusRequestEntityTooLarge)
+			logging.Warn(err.Error())
+		case ports.ErrProgressOutOfRange, ports.ErrEnergyIsNegative:
+			http.Error(w, HTTPErr400InvalidInputData, http.StatusBadRequest)
+			logging.Warn(err.Error())
+		case ports.ErrJobAlreadyFinished:
+			http.Error(w, HTTPErr409JobFinished, http.StatusConflict)
+			logging.Warn(err.Error())
 		case ports.ErrNotExistingStatus:
 			http.Error(w, HTTPErr400StatusEmpty, http.StatusBadRequest)
 			logging.Warn(err.Error())
@@ -1,11 +1,20 @@
 package handler_http
 
 var (
-	HTTPErr400MissId           = `{"error": "Bad Request","message": "The job ID must be provided"}`
-	HTTPErr400InvalidId        = `{"error": "Bad Request","message": "The job ID format is invalid. Expected a UUID format."}`
-	HTTPErr400JobNotFound      = `{"error": "Not Found","message": "A job with the specified ID does not exist. Please verify the ID."}`
-	HTTPErr400FieldEmpty       = `{"error": "Bad Request","message": "jobname and imagename must not be empty"}`
-	HTTPErr400StatusEmpty      = `{"error": "Bad Request","message": "job status must not be empty"}`
-	HTTPErr400InvalidInputData = `{"error": "Bad Request","message": "Invalid input data"}`
-	HTTPErr500                 = `{"error": "Internal Server Error","message": "The server encountered an unexpected condition"}`
+	HTTPErr400MissId             = `{"error": "Bad Request","message": "The job ID must be provided"}`
+	HTTPErr400InvalidId          = `{"error": "Bad Request","message": "The job ID format is invalid. Expected a UUID format."}`
+	HTTPErr400JobNotFound        = `{"error": "Not Found","message": "A job with the specified ID does not exist. Please verify the ID."}`
+	HTTPErr400FieldEmpty         = `{"error": "Bad Request","message": "jobname and imagename must not be empty"}`
+	HTTPErr400StatusEmpty        = `{"error": "Bad Request","message": "job status must not be empty"}`
+	HTTPErr400InvalidInputData   = `{"error": "Bad Request","message": "Invalid input data"}`
+	HTTPErr400InvalidInputFile   = `{"error": "Bad Request","message": "Input files need a unique name and either a http(s) url with a SHA-256 checksum or base64 content"}`
+	HTTPErr400InvalidDigest      = `{"error": "Bad Request","message": "The image digest must have the form sha256:<hex>"}`
+	HTTPErr400InvalidTarget      = `{"error": "Bad Request","message": "The optimisation target must be carbon-intensity, renewable or fossil-free"}`
+	HTTPErr400InvalidAllowedZone = `{"error": "Bad Request","message": "Allowed zones and regions must not be empty"}`
+	HTTPErr403ImageNotAllowed    = `{"error": "Forbidden","message": "The image is not allowed by the image policy"}`
+	HTTPErr403ImageSignature     = `{"error": "Forbidden","message": "The image signature is missing or does not match a trusted key"}`
+	HTTPErr404InputFileNotFound  = `{"error": "Not Found","message": "The job has no uploaded input file with the specified name"}`
+	HTTPErr409JobFinished        = `{"error": "Conflict","message": "The job has already finished"}`
+	HTTPErr413InputTooLarge      = `{"error": "Request Entity Too Large","message": "Uploaded input files are too large, reference them by url instead"}`
+	HTTPErr500                   = `{"error": "Internal Server Error","message": "The server encountered an unexpected condition"}`
 )
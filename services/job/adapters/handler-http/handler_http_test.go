@@ -12,6 +12,8 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	handler_http "github.com/informatik-mannheim/cmg-ss2025/services/job/adapters/handler-http"
+	repo_in_memory "github.com/informatik-mannheim/cmg-ss2025/services/job/adapters/repo-in-memory"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job/core"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job/ports"
 )
 
@@ -30,6 +32,13 @@ func (m *MockJobService) GetJobs(_ context.Context, status []ports.JobStatus) ([
 	return []ports.Job{{Id: "123", JobName: "mockJob"}}, nil
 }
 
+func (m *MockJobService) GetJobsByWorker(_ context.Context, workerID string, status []ports.JobStatus) ([]ports.Job, error) {
+	if workerID != "worker-1" {
+		return nil, nil
+	}
+	return []ports.Job{{Id: "123", JobName: "mockJob", WorkerID: workerID}}, nil
+}
+
 func (m *MockJobService) CreateJob(_ context.Context, jobCreate ports.JobCreate) (ports.Job, error) {
 	if jobCreate.JobName == "" {
 		return ports.Job{}, ports.ErrNotExistingJobName
@@ -51,6 +60,16 @@ func (m *MockJobService) GetJobOutcome(_ context.Context, id string) (ports.JobO
 	return ports.JobOutcome{}, ports.ErrJobNotFound
 }
 
+func (m *MockJobService) GetInputFile(_ context.Context, id string, name string) ([]byte, error) {
+	if id != "123" {
+		return nil, ports.ErrJobNotFound
+	}
+	if name != "hello.txt" {
+		return nil, ports.ErrInputFileNotFound
+	}
+	return []byte("hello"), nil
+}
+
 func (m *MockJobService) UpdateJobScheduler(_ context.Context, id string, data ports.SchedulerUpdateData) (ports.Job, error) {
 	if id == "123" {
 		return ports.Job{Id: "123"}, nil
@@ -65,6 +84,16 @@ func (m *MockJobService) UpdateJobWorkerDaemon(_ context.Context, id string, dat
 	return ports.Job{}, ports.ErrJobNotFound
 }
 
+func (m *MockJobService) UpdateJobProgress(_ context.Context, id string, data ports.JobProgress) (ports.Job, error) {
+	if data.Percent > 100 {
+		return ports.Job{}, ports.ErrProgressOutOfRange
+	}
+	if id == "123" {
+		return ports.Job{Id: "123", Progress: data}, nil
+	}
+	return ports.Job{}, ports.ErrJobNotFound
+}
+
 func TestHandler_GetJobs(t *testing.T) {
 	mockService := &MockJobService{}
 	handler := handler_http.NewHandler(mockService)
@@ -77,6 +106,8 @@ func TestHandler_GetJobs(t *testing.T) {
 		{"No Status Filter", "", http.StatusNoContent},
 		{"Valid Status", "?status=queued", http.StatusOK},
 		{"Invalid Status", "?status=invalid", http.StatusBadRequest},
+		{"Worker Filter", "?status=scheduled&workerId=worker-1", http.StatusOK},
+		{"Worker Without Jobs", "?status=scheduled&workerId=worker-2", http.StatusNoContent},
 	}
 
 	for _, tt := range tests {
@@ -104,6 +135,7 @@ func TestHandler_CreateJob(t *testing.T) {
 		{"Valid Job", `{"JobName":"New Job", "CreationZone":"DE", "Image":{"Name":"test-image", "Version":"1.0"}}`, http.StatusCreated},
 		{"Empty Job Name", `{"JobName":"", "CreationZone":"DE", "Image":{"Name":"test-image", "Version":"1.0"}}`, http.StatusBadRequest},
 		{"Invalid JSON", `invalid-json`, http.StatusBadRequest},
+		{"Body Too Large", `{"JobName":"New Job", "InputFiles":[{"Name":"a", "Content":"` + strings.Repeat("A", 13<<20) + `"}]}`, http.StatusRequestEntityTooLarge},
 	}
 
 	for _, tt := range tests {
@@ -119,6 +151,42 @@ func TestHandler_CreateJob(t *testing.T) {
 	}
 }
 
+// TestHandler_CreateJobInputFileName checks the input file names against the real core,
+// the worker daemon refuses to stage names the service accepted otherwise
+func TestHandler_CreateJobInputFileName(t *testing.T) {
+	service, err := core.NewJobService(repo_in_memory.NewMockJobStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := handler_http.NewHandler(service)
+
+	tests := []struct {
+		name           string
+		fileName       string
+		expectedStatus int
+	}{
+		{"Plain Name", "data.csv", http.StatusCreated},
+		{"Dot Inside Name", "data.tar.gz", http.StatusCreated},
+		{"Hidden File", ".env", http.StatusBadRequest},
+		{"Parent Directory", "..", http.StatusBadRequest},
+		{"Path", "dir/data.csv", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := `{"JobName":"Input Job", "CreationZone":"DE", "Image":{"Name":"golang", "Version":"1.15"},` +
+				`"InputFiles":[{"Name":"` + tt.fileName + `", "Content":"aGVsbG8="}]}`
+			req, _ := http.NewRequest("POST", "/jobs", strings.NewReader(payload))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %v; got %v: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestHandler_GetJob(t *testing.T) {
 	mockService := &MockJobService{}
 	handler := handler_http.NewHandler(mockService)
@@ -177,6 +245,37 @@ func TestHandler_GetJobOutcome(t *testing.T) {
 	}
 }
 
+func TestHandler_GetInputFile(t *testing.T) {
+	mockService := &MockJobService{}
+	handler := handler_http.NewHandler(mockService)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Uploaded File", "/jobs/123/inputs/hello.txt", http.StatusOK, "hello"},
+		{"Unknown File", "/jobs/123/inputs/other.txt", http.StatusNotFound, ""},
+		{"Non-Existing Job", "/jobs/456/inputs/hello.txt", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %v; got %v", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q; got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestHandler_UpdateJobScheduler(t *testing.T) {
 	mockService := &MockJobService{}
 	handler := handler_http.NewHandler(mockService)
@@ -224,3 +323,33 @@ func TestHandler_UpdateJobWorkerDaemon(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 }
+
+func TestHandler_UpdateJobProgress(t *testing.T) {
+	mockService := &MockJobService{}
+	handler := handler_http.NewHandler(mockService)
+
+	tests := []struct {
+		name     string
+		id       string
+		progress ports.JobProgress
+		want     int
+	}{
+		{"Valid progress", "123", ports.JobProgress{Percent: 42, Message: "epoch 3/10"}, http.StatusOK},
+		{"Percent out of range", "123", ports.JobProgress{Percent: 120}, http.StatusBadRequest},
+		{"Unknown job", "456", ports.JobProgress{Percent: 42}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := json.Marshal(tt.progress)
+			req, _ := http.NewRequest("PATCH", "/jobs/"+tt.id+"/update-progress", bytes.NewBuffer(payload))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tt.want)
+			}
+		})
+	}
+}
@@ -49,9 +49,91 @@ func NewJobStorage(host, port, user, password, dbName, sslMode string, ctx conte
 	return &JobStorage{db: db}, nil
 }
 
+// jobColumns lists the columns of the jobs table in the order used by scanJob and jobValues.
+const jobColumns = `id, user_id, created_at, updated_at, job_name, image_name, image_version, image_digest, image_signature, adjustment_parameters, creation_zone, input_files, optimisation_target, allowed_zones, allowed_regions, worker_id, compute_zone, carbon_intensity, carbon_savings, carbon_data_age, result, error_message, result_id, progress_percent, progress_message, energy_wh, energy_method, carbon_emitted, carbon_saved, job_status`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJob reads a job from a row selected with jobColumns.
+func scanJob(row rowScanner) (ports.Job, error) {
+	var job ports.Job
+	var imageName, imageVersion, imageDigest, imageSignature string
+	var paramsJSON, inputFilesJSON, allowedZonesJSON, allowedRegionsJSON []byte
+	err := row.Scan(
+		&job.Id, &job.UserID, &job.CreatedAt, &job.UpdatedAt, &job.JobName,
+		&imageName, &imageVersion, &imageDigest, &imageSignature, &paramsJSON, &job.CreationZone, &inputFilesJSON, &job.OptimisationTarget, &allowedZonesJSON, &allowedRegionsJSON,
+		&job.WorkerID, &job.ComputeZone, &job.CarbonIntensity, &job.CarbonSaving, &job.CarbonDataAge,
+		&job.Result, &job.ErrorMessage, &job.ResultID, &job.Progress.Percent, &job.Progress.Message,
+		&job.EnergyWh, &job.EnergyMethod, &job.CarbonEmitted, &job.CarbonSaved, &job.Status,
+	)
+	if err != nil {
+		return ports.Job{}, err
+	}
+	if err := json.Unmarshal(paramsJSON, &job.AdjustmentParameters); err != nil {
+		return ports.Job{}, err
+	}
+	if err := json.Unmarshal(inputFilesJSON, &job.InputFiles); err != nil {
+		return ports.Job{}, err
+	}
+	if err := json.Unmarshal(allowedZonesJSON, &job.AllowedZones); err != nil {
+		return ports.Job{}, err
+	}
+	if err := json.Unmarshal(allowedRegionsJSON, &job.AllowedRegions); err != nil {
+		return ports.Job{}, err
+	}
+	job.Image = ports.ContainerImage{
+		Name:      imageName,
+		Version:   imageVersion,
+		Digest:    imageDigest,
+		Signature: imageSignature,
+	}
+	return job, nil
+}
+
+// jobValues returns the values of a job in the order of jobColumns, without id and created_at.
+func jobValues(job ports.Job) ([]any, error) {
+	paramsJSON, err := json.Marshal(job.AdjustmentParameters)
+	if err != nil {
+		return nil, err
+	}
+	inputFiles := job.InputFiles
+	if inputFiles == nil {
+		inputFiles = []ports.InputFile{}
+	}
+	inputFilesJSON, err := json.Marshal(inputFiles)
+	if err != nil {
+		return nil, err
+	}
+	allowedZonesJSON, err := json.Marshal(nonNil(job.AllowedZones))
+	if err != nil {
+		return nil, err
+	}
+	allowedRegionsJSON, err := json.Marshal(nonNil(job.AllowedRegions))
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		job.UserID, job.UpdatedAt, job.JobName,
+		job.Image.Name, job.Image.Version, job.Image.Digest, job.Image.Signature, paramsJSON, job.CreationZone, inputFilesJSON, job.OptimisationTarget, allowedZonesJSON, allowedRegionsJSON,
+		job.WorkerID, job.ComputeZone, job.CarbonIntensity, job.CarbonSaving, job.CarbonDataAge,
+		job.Result, job.ErrorMessage, job.ResultID, job.Progress.Percent, job.Progress.Message,
+		job.EnergyWh, job.EnergyMethod, job.CarbonEmitted, job.CarbonSaved, job.Status,
+	}, nil
+}
+
+// nonNil stores a missing list as an empty JSON array
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
 func (r *JobStorage) GetJobs(ctx context.Context, status []ports.JobStatus) ([]ports.Job, error) {
-	query := `SELECT id, user_id, created_at, updated_at, job_name, image_name, image_version, adjustment_parameters, creation_zone, worker_id, compute_zone, carbon_intensity, carbon_savings, result, error_message, job_status
-              FROM jobs`
+	query := `SELECT ` + jobColumns + ` FROM jobs`
 	var args []interface{}
 	if len(status) > 0 {
 		var statusStrings []string
@@ -69,93 +151,127 @@ func (r *JobStorage) GetJobs(ctx context.Context, status []ports.JobStatus) ([]p
 
 	var jobs []ports.Job
 	for rows.Next() {
-		var job ports.Job
-		var imageName, imageVersion string
-		var paramsJSON []byte
-		err := rows.Scan(
-			&job.Id, &job.UserID, &job.CreatedAt, &job.UpdatedAt, &job.JobName,
-			&imageName, &imageVersion, &paramsJSON, &job.CreationZone,
-			&job.WorkerID, &job.ComputeZone, &job.CarbonIntensity, &job.CarbonSaving,
-			&job.Result, &job.ErrorMessage, &job.Status,
-		)
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, err
 		}
-		if err := json.Unmarshal(paramsJSON, &job.AdjustmentParameters); err != nil {
-			return nil, err
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetJobsByWorker uses the index on worker_id and job_status
+func (r *JobStorage) GetJobsByWorker(ctx context.Context, workerID string, status []ports.JobStatus) ([]ports.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE worker_id = $1`
+	args := []interface{}{workerID}
+	if len(status) > 0 {
+		var statusStrings []string
+		for _, s := range status {
+			statusStrings = append(statusStrings, string(s))
 		}
-		job.Image = ports.ContainerImage{
-			Name:    imageName,
-			Version: imageVersion,
+		query += " AND job_status = ANY($2)"
+		args = append(args, statusStrings)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []ports.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
 		}
 		jobs = append(jobs, job)
 	}
-	return jobs, nil
+	return jobs, rows.Err()
 }
 
 func (r *JobStorage) GetJob(ctx context.Context, id string) (ports.Job, error) {
-	query := `SELECT id, user_id, created_at, updated_at, job_name, image_name, image_version, adjustment_parameters, creation_zone, worker_id, compute_zone, carbon_intensity, carbon_savings, result, error_message, job_status
-              FROM jobs WHERE id = $1`
-	var job ports.Job
-	var imageName, imageVersion string
-	var paramsJSON []byte
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&job.Id, &job.UserID, &job.CreatedAt, &job.UpdatedAt, &job.JobName,
-		&imageName, &imageVersion, &paramsJSON, &job.CreationZone,
-		&job.WorkerID, &job.ComputeZone, &job.CarbonIntensity, &job.CarbonSaving,
-		&job.Result, &job.ErrorMessage, &job.Status,
-	)
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return ports.Job{}, ports.ErrJobNotFound
 	} else if err != nil {
 		return ports.Job{}, err
 	}
-	if err := json.Unmarshal(paramsJSON, &job.AdjustmentParameters); err != nil {
-		return ports.Job{}, err
-	}
-	job.Image = ports.ContainerImage{
-		Name:    imageName,
-		Version: imageVersion,
-	}
 	return job, nil
 }
 
-func (r *JobStorage) CreateJob(ctx context.Context, job ports.Job) error {
-	paramsJSON, err := json.Marshal(job.AdjustmentParameters)
+// CreateJob inserts the job and its uploaded input files in one transaction.
+func (r *JobStorage) CreateJob(ctx context.Context, job ports.Job, inputs map[string][]byte) error {
+	values, err := jobValues(job)
 	if err != nil {
 		return err
 	}
-	query := `INSERT INTO jobs (id, user_id, created_at, updated_at, job_name, image_name, image_version, adjustment_parameters, creation_zone, worker_id, compute_zone, carbon_intensity, carbon_savings, result, error_message, job_status)
-              VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)`
-	_, err = r.db.ExecContext(ctx, query,
-		job.Id, job.UserID, job.CreatedAt, job.UpdatedAt, job.JobName,
-		job.Image.Name, job.Image.Version, paramsJSON, job.CreationZone,
-		job.WorkerID, job.ComputeZone, job.CarbonIntensity, job.CarbonSaving,
-		job.Result, job.ErrorMessage, job.Status,
-	)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO jobs (id, created_at, user_id, updated_at, job_name, image_name, image_version, image_digest, image_signature, adjustment_parameters, creation_zone, input_files, optimisation_target, allowed_zones, allowed_regions, worker_id, compute_zone, carbon_intensity, carbon_savings, carbon_data_age, result, error_message, result_id, progress_percent, progress_message, energy_wh, energy_method, carbon_emitted, carbon_saved, job_status)
+              VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30)`
+	if _, err := tx.ExecContext(ctx, query, append([]any{job.Id, job.CreatedAt}, values...)...); err != nil {
+		return err
+	}
+	for name, content := range inputs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO job_input_files (job_id, name, content) VALUES ($1, $2, $3)`, job.Id, name, content); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *JobStorage) GetInputFile(ctx context.Context, jobID string, name string) ([]byte, error) {
+	var content []byte
+	err := r.db.QueryRowContext(ctx, `SELECT content FROM job_input_files WHERE job_id = $1 AND name = $2`, jobID, name).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, ports.ErrInputFileNotFound
+	}
+	return content, err
 }
 
+// updateJobQuery writes all columns of a job, $22 is the result ID.
+const updateJobQuery = `UPDATE jobs SET
+        user_id=$2, updated_at=$3, job_name=$4, image_name=$5, image_version=$6, image_digest=$7, image_signature=$8, adjustment_parameters=$9, creation_zone=$10, input_files=$11, optimisation_target=$12, allowed_zones=$13, allowed_regions=$14, worker_id=$15, compute_zone=$16, carbon_intensity=$17, carbon_savings=$18, carbon_data_age=$19, result=$20, error_message=$21, result_id=$22, progress_percent=$23, progress_message=$24, energy_wh=$25, energy_method=$26, carbon_emitted=$27, carbon_saved=$28, job_status=$29
+        WHERE id=$1`
+
 func (r *JobStorage) UpdateJob(ctx context.Context, id string, job ports.Job) (ports.Job, error) {
-	paramsJSON, err := json.Marshal(job.AdjustmentParameters)
+	return r.updateJob(ctx, id, job, updateJobQuery, ports.ErrJobNotFound)
+}
+
+// UpdateJobResult checks the result ID in the same statement as it writes the job, so of two concurrent
+// deliveries only one is applied.
+func (r *JobStorage) UpdateJobResult(ctx context.Context, id string, job ports.Job) (ports.Job, error) {
+	query := updateJobQuery + ` AND ($22 = '' OR COALESCE(result_id, '') = ''
+        OR (result_id <> $22 AND job_status NOT IN ('completed', 'failed', 'cancelled')))`
+	updated, err := r.updateJob(ctx, id, job, query, ports.ErrJobAlreadyFinished)
+	if err == ports.ErrJobAlreadyFinished {
+		// no row was changed, either the job has a result or it does not exist
+		if _, getErr := r.GetJob(ctx, id); getErr != nil {
+			return ports.Job{}, getErr
+		}
+	}
+	return updated, err
+}
+
+// updateJob runs the update query with the values of the job and returns notUpdated if no row was changed.
+func (r *JobStorage) updateJob(ctx context.Context, id string, job ports.Job, query string, notUpdated error) (ports.Job, error) {
+	job.UpdatedAt = time.Now()
+	values, err := jobValues(job)
 	if err != nil {
 		return ports.Job{}, err
 	}
-	query := `UPDATE jobs SET
-        user_id=$2, updated_at=$3, job_name=$4, image_name=$5, image_version=$6, adjustment_parameters=$7, creation_zone=$8, worker_id=$9, compute_zone=$10, carbon_intensity=$11, carbon_savings=$12, result=$13, error_message=$14, job_status=$15
-        WHERE id=$1`
-	res, err := r.db.ExecContext(ctx, query,
-		id, job.UserID, time.Now(), job.JobName,
-		job.Image.Name, job.Image.Version, paramsJSON, job.CreationZone,
-		job.WorkerID, job.ComputeZone, job.CarbonIntensity, job.CarbonSaving,
-		job.Result, job.ErrorMessage, job.Status,
-	)
+	res, err := r.db.ExecContext(ctx, query, append([]any{id}, values...)...)
 	if err != nil {
 		return ports.Job{}, err
 	}
 	rowsAffected, _ := res.RowsAffected()
 	if rowsAffected == 0 {
-		return ports.Job{}, ports.ErrJobNotFound
+		return ports.Job{}, notUpdated
 	}
 	return r.GetJob(ctx, id)
 }
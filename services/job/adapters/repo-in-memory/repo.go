@@ -2,6 +2,7 @@ package repo_in_memory
 
 import (
 	"context"
+	"sync"
 
 	"github.com/informatik-mannheim/cmg-ss2025/services/job/ports"
 	"github.com/informatik-mannheim/cmg-ss2025/services/job/utils"
@@ -9,18 +10,24 @@ import (
 
 // MockJobStorage is a mock implementation of the JobStorage interface
 type MockJobStorage struct {
-	jobs map[string]ports.Job
+	mu     sync.RWMutex
+	jobs   map[string]ports.Job
+	inputs map[string]map[string][]byte // uploaded input files by job ID and file name
 }
 
 func NewMockJobStorage() *MockJobStorage {
 	var _ ports.JobStorage = (*MockJobStorage)(nil)
 
 	return &MockJobStorage{
-		jobs: make(map[string]ports.Job),
+		jobs:   make(map[string]ports.Job),
+		inputs: make(map[string]map[string][]byte),
 	}
 }
 
 func (m *MockJobStorage) GetJobs(ctx context.Context, status []ports.JobStatus) ([]ports.Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	var results []ports.Job
 
 	if len(status) == 0 {
@@ -39,12 +46,37 @@ func (m *MockJobStorage) GetJobs(ctx context.Context, status []ports.JobStatus)
 	return results, nil
 }
 
-func (m *MockJobStorage) CreateJob(ctx context.Context, job ports.Job) error {
+func (m *MockJobStorage) GetJobsByWorker(ctx context.Context, workerID string, status []ports.JobStatus) ([]ports.Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []ports.Job
+	for _, job := range m.jobs {
+		if job.WorkerID != workerID {
+			continue
+		}
+		if len(status) == 0 || utils.ContainsStatus(status, job.Status) {
+			results = append(results, job)
+		}
+	}
+	return results, nil
+}
+
+func (m *MockJobStorage) CreateJob(ctx context.Context, job ports.Job, inputs map[string][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.jobs[job.Id] = job
+	if len(inputs) > 0 {
+		m.inputs[job.Id] = inputs
+	}
 	return nil
 }
 
 func (m *MockJobStorage) GetJob(ctx context.Context, id string) (ports.Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	job, ok := m.jobs[id]
 	if !ok {
 		return ports.Job{}, ports.ErrJobNotFound
@@ -53,6 +85,9 @@ func (m *MockJobStorage) GetJob(ctx context.Context, id string) (ports.Job, erro
 }
 
 func (m *MockJobStorage) UpdateJob(ctx context.Context, id string, updatedJob ports.Job) (ports.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	_, exists := m.jobs[id]
 	if !exists {
 		return ports.Job{}, ports.ErrJobNotFound
@@ -60,3 +95,34 @@ func (m *MockJobStorage) UpdateJob(ctx context.Context, id string, updatedJob po
 	m.jobs[id] = updatedJob
 	return updatedJob, nil
 }
+
+func (m *MockJobStorage) UpdateJobResult(ctx context.Context, id string, updatedJob ports.Job) (ports.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[id]
+	if !exists {
+		return ports.Job{}, ports.ErrJobNotFound
+	}
+	if updatedJob.ResultID != "" && job.ResultID != "" {
+		switch {
+		case job.ResultID == updatedJob.ResultID:
+			return ports.Job{}, ports.ErrJobAlreadyFinished
+		case job.Status == ports.StatusCompleted, job.Status == ports.StatusFailed, job.Status == ports.StatusCancelled:
+			return ports.Job{}, ports.ErrJobAlreadyFinished
+		}
+	}
+	m.jobs[id] = updatedJob
+	return updatedJob, nil
+}
+
+func (m *MockJobStorage) GetInputFile(ctx context.Context, jobID string, name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	content, ok := m.inputs[jobID][name]
+	if !ok {
+		return nil, ports.ErrInputFileNotFound
+	}
+	return content, nil
+}
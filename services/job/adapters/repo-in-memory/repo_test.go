@@ -13,7 +13,7 @@ func TestCreateJob(t *testing.T) {
 	job := ports.Job{Id: "1", JobName: "TestJob", Status: ports.StatusQueued}
 
 	// Test creating a new job
-	err := storage.CreateJob(context.Background(), job)
+	err := storage.CreateJob(context.Background(), job, nil)
 	if err != nil {
 		t.Fatalf("expected no error but got %v", err)
 	}
@@ -22,7 +22,7 @@ func TestCreateJob(t *testing.T) {
 func TestGetJob_ExistingJob(t *testing.T) {
 	storage := repo_in_memory.NewMockJobStorage()
 	job := ports.Job{Id: "1", JobName: "TestJob", Status: ports.StatusQueued}
-	_ = storage.CreateJob(context.Background(), job)
+	_ = storage.CreateJob(context.Background(), job, nil)
 
 	// Test retrieving the existing job
 	retrievedJob, err := storage.GetJob(context.Background(), "1")
@@ -48,8 +48,8 @@ func TestGetJobs_NoFilter(t *testing.T) {
 	storage := repo_in_memory.NewMockJobStorage()
 	job1 := ports.Job{Id: "1", JobName: "TestJob1", Status: ports.StatusQueued}
 	job2 := ports.Job{Id: "2", JobName: "TestJob2", Status: ports.StatusCompleted}
-	_ = storage.CreateJob(context.Background(), job1)
-	_ = storage.CreateJob(context.Background(), job2)
+	_ = storage.CreateJob(context.Background(), job1, nil)
+	_ = storage.CreateJob(context.Background(), job2, nil)
 
 	// Test retrieving all jobs with no filter
 	allJobs, err := storage.GetJobs(context.Background(), nil)
@@ -65,8 +65,8 @@ func TestGetJobs_WithFilter(t *testing.T) {
 	storage := repo_in_memory.NewMockJobStorage()
 	job1 := ports.Job{Id: "1", JobName: "TestJob1", Status: ports.StatusQueued}
 	job2 := ports.Job{Id: "2", JobName: "TestJob2", Status: ports.StatusCompleted}
-	_ = storage.CreateJob(context.Background(), job1)
-	_ = storage.CreateJob(context.Background(), job2)
+	_ = storage.CreateJob(context.Background(), job1, nil)
+	_ = storage.CreateJob(context.Background(), job2, nil)
 
 	// Test retrieving jobs with a status filter
 	queuedJobs, err := storage.GetJobs(context.Background(), []ports.JobStatus{ports.StatusQueued})
@@ -78,10 +78,25 @@ func TestGetJobs_WithFilter(t *testing.T) {
 	}
 }
 
+func TestGetJobsByWorker(t *testing.T) {
+	storage := repo_in_memory.NewMockJobStorage()
+	_ = storage.CreateJob(context.Background(), ports.Job{Id: "1", WorkerID: "worker-1", Status: ports.StatusScheduled}, nil)
+	_ = storage.CreateJob(context.Background(), ports.Job{Id: "2", WorkerID: "worker-2", Status: ports.StatusScheduled}, nil)
+	_ = storage.CreateJob(context.Background(), ports.Job{Id: "3", WorkerID: "worker-1", Status: ports.StatusCompleted}, nil)
+
+	jobs, err := storage.GetJobsByWorker(context.Background(), "worker-1", []ports.JobStatus{ports.StatusScheduled})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Id != "1" {
+		t.Errorf("expected only job 1, got %v", jobs)
+	}
+}
+
 func TestUpdateJob_ExistingJob(t *testing.T) {
 	storage := repo_in_memory.NewMockJobStorage()
 	job := ports.Job{Id: "1", JobName: "TestJob", Status: ports.StatusQueued}
-	_ = storage.CreateJob(context.Background(), job)
+	_ = storage.CreateJob(context.Background(), job, nil)
 
 	updatedJob := ports.Job{Id: "1", JobName: "UpdatedTestJob", Status: ports.StatusRunning}
 	result, err := storage.UpdateJob(context.Background(), "1", updatedJob)
@@ -103,3 +118,39 @@ func TestUpdateJob_NonExistingJob(t *testing.T) {
 		t.Fatalf("expected %v error but got %v", ports.ErrJobNotFound, err)
 	}
 }
+
+func TestUpdateJobResult(t *testing.T) {
+	storage := repo_in_memory.NewMockJobStorage()
+	ctx := context.Background()
+	_ = storage.CreateJob(ctx, ports.Job{Id: "1", Status: ports.StatusRunning}, nil)
+
+	if _, err := storage.UpdateJobResult(ctx, "1", ports.Job{Id: "1", Status: ports.StatusCompleted, Result: "42", ResultID: "result-1"}); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	for _, resultID := range []string{"result-1", "result-2"} {
+		_, err := storage.UpdateJobResult(ctx, "1", ports.Job{Id: "1", Status: ports.StatusCompleted, Result: "other", ResultID: resultID})
+		if err != ports.ErrJobAlreadyFinished {
+			t.Errorf("expected %v for %s but got %v", ports.ErrJobAlreadyFinished, resultID, err)
+		}
+	}
+	if job, _ := storage.GetJob(ctx, "1"); job.Result != "42" {
+		t.Errorf("expected the first result to be kept, got %q", job.Result)
+	}
+	if _, err := storage.UpdateJobResult(ctx, "non-existing-id", ports.Job{}); err != ports.ErrJobNotFound {
+		t.Errorf("expected %v error but got %v", ports.ErrJobNotFound, err)
+	}
+}
+
+func TestGetInputFile(t *testing.T) {
+	storage := repo_in_memory.NewMockJobStorage()
+	job := ports.Job{Id: "1", JobName: "TestJob", Status: ports.StatusQueued}
+	_ = storage.CreateJob(context.Background(), job, map[string][]byte{"hello.txt": []byte("hello")})
+
+	content, err := storage.GetInputFile(context.Background(), "1", "hello.txt")
+	if err != nil || string(content) != "hello" {
+		t.Fatalf("expected the uploaded content but got %q, %v", content, err)
+	}
+	if _, err := storage.GetInputFile(context.Background(), "1", "other.txt"); err != ports.ErrInputFileNotFound {
+		t.Fatalf("expected %v error but got %v", ports.ErrInputFileNotFound, err)
+	}
+}
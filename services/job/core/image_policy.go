@@ -0,0 +1,51 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/imagepolicy"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job/ports"
+)
+
+// ImagePolicy restricts the container images jobs may run, see pkg/imagepolicy.
+// The worker daemon understands the same file to enforce the policy of its provider locally.
+type ImagePolicy struct {
+	policy *imagepolicy.Policy
+}
+
+// LoadImagePolicy reads the policy from a JSON file.
+func LoadImagePolicy(file string) (*ImagePolicy, error) {
+	policy, err := imagepolicy.Load(file)
+	if err != nil {
+		return nil, err
+	}
+	return &ImagePolicy{policy: policy}, nil
+}
+
+// Check returns ports.ErrImageNotAllowed if the image does not comply with the policy,
+// ports.ErrImageSignatureInvalid if a required signature is missing or does not verify.
+func (p *ImagePolicy) Check(image ports.ContainerImage) error {
+	err := p.policy.Check(imagepolicy.Image{Name: image.Name, Digest: image.Digest, Signature: image.Signature})
+	switch {
+	case errors.Is(err, imagepolicy.ErrNotAllowed):
+		return &policyError{err: err, mapped: ports.ErrImageNotAllowed}
+	case errors.Is(err, imagepolicy.ErrSignatureInvalid):
+		return &policyError{err: err, mapped: ports.ErrImageSignatureInvalid}
+	}
+	return err
+}
+
+// policyError keeps the message of the policy error, which names the rejected registry or repository,
+// and matches the error of the job service it is mapped to.
+type policyError struct {
+	err    error
+	mapped error
+}
+
+func (e *policyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *policyError) Unwrap() []error {
+	return []error{e.mapped, e.err}
+}
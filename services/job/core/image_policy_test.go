@@ -0,0 +1,119 @@
+package core_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/job/core"
+	"github.com/informatik-mannheim/cmg-ss2025/services/job/ports"
+)
+
+const testDigest = "sha256:2d1b4e8f9a0c3b5d7e6f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d"
+
+// writePolicy writes the policy and the public key of a new signing key, like a provider would
+func writePolicy(t *testing.T, policy string) (string, *ecdsa.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "cosign.pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644)
+	file := filepath.Join(dir, "policy.json")
+	os.WriteFile(file, []byte(policy), 0o644)
+	return file, key
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, reference string) string {
+	t.Helper()
+	hash := sha256.Sum256([]byte(reference))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestImagePolicy_Check(t *testing.T) {
+	file, key := writePolicy(t, `{"allowed_registries": ["ghcr.io"], "public_keys": ["cosign.pub"]}`)
+	policy, err := core.LoadImagePolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the errors of pkg/imagepolicy are mapped to the errors of the job service, the message names the reason
+	tests := []struct {
+		name    string
+		image   ports.ContainerImage
+		wantErr error
+		message string
+	}{
+		{"signed", ports.ContainerImage{Name: "ghcr.io/org/app", Digest: testDigest, Signature: sign(t, key, "ghcr.io/org/app@"+testDigest)}, nil, ""},
+		{"registry not allowed", ports.ContainerImage{Name: "quay.io/org/app", Digest: testDigest}, ports.ErrImageNotAllowed, "registry quay.io"},
+		{"unsigned", ports.ContainerImage{Name: "ghcr.io/org/app", Digest: testDigest}, ports.ErrImageSignatureInvalid, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Check(tc.image)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.message != "" && !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("expected message to contain %q, got %q", tc.message, err)
+			}
+		})
+	}
+}
+
+func TestJobService_CreateJob_ImagePolicy(t *testing.T) {
+	service, _ := setup()
+	file, _ := writePolicy(t, `{"allowed_registries": ["ghcr.io"]}`)
+	policy, err := core.LoadImagePolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.SetImagePolicy(policy)
+
+	_, err = service.CreateJob(context.Background(), ports.JobCreate{
+		JobName: "Test Job",
+		Image:   ports.ContainerImage{Name: "alpine", Version: "latest"},
+	})
+	if !errors.Is(err, ports.ErrImageNotAllowed) {
+		t.Errorf("expected ErrImageNotAllowed, got %v", err)
+	}
+
+	job, err := service.CreateJob(context.Background(), ports.JobCreate{
+		JobName: "Test Job",
+		Image:   ports.ContainerImage{Name: "ghcr.io/org/app", Version: "1.0", Digest: testDigest},
+	})
+	if err != nil {
+		t.Fatalf("expected allowed image to be created, got %v", err)
+	}
+	if job.Image.Digest != testDigest {
+		t.Errorf("expected digest to be stored, got %q", job.Image.Digest)
+	}
+
+	_, err = service.CreateJob(context.Background(), ports.JobCreate{
+		JobName: "Test Job",
+		Image:   ports.ContainerImage{Name: "ghcr.io/org/app", Digest: "latest"},
+	})
+	if !errors.Is(err, ports.ErrImageDigestIsInvalid) {
+		t.Errorf("expected ErrImageDigestIsInvalid, got %v", err)
+	}
+}
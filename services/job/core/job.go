@@ -2,7 +2,12 @@ package core
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"net/url"
+	"path"
 	"strings"
 	"time"
 	"unicode"
@@ -11,11 +16,16 @@ import (
 	"github.com/informatik-mannheim/cmg-ss2025/services/job/ports"
 )
 
+// MaxInputContentSize is the maximum total size in bytes of all uploaded input files of a job.
+// Larger inputs have to be referenced by URL.
+const MaxInputContentSize = 8 << 20
+
 // JobService is a struct that implements the JobService interface.
 // It provides methods to manage jobs, including creating, retrieving, and updating jobs.
 // It uses a storage interface to interact with the underlying data store.
 type JobService struct {
-	storage ports.JobStorage
+	storage     ports.JobStorage
+	imagePolicy *ImagePolicy
 }
 
 // NewJobService creates a new instance of JobService with the provided storage.
@@ -31,6 +41,12 @@ func NewJobService(storage ports.JobStorage) (*JobService, error) {
 	}, nil
 }
 
+// SetImagePolicy makes CreateJob reject images that do not comply with the policy.
+// A nil policy allows all images.
+func (s *JobService) SetImagePolicy(policy *ImagePolicy) {
+	s.imagePolicy = policy
+}
+
 // GetJobs retrieves jobs based on their status.
 // It returns a slice of jobs that match the provided status.
 // If no status is provided, it returns all jobs.
@@ -56,6 +72,30 @@ func (s *JobService) GetJobs(ctx context.Context, status []ports.JobStatus) ([]p
 	return s.storage.GetJobs(ctx, validStatuses)
 }
 
+// GetJobsByWorker retrieves the jobs assigned to the worker with the provided ID.
+// The statuses are filtered like in GetJobs, the worker ID must be provided.
+func (s *JobService) GetJobsByWorker(ctx context.Context, workerID string, status []ports.JobStatus) ([]ports.Job, error) {
+	if strings.TrimSpace(workerID) == "" {
+		return nil, ports.ErrNotExistingWorkerID
+	}
+
+	if len(status) == 0 {
+		return s.storage.GetJobsByWorker(ctx, workerID, nil)
+	}
+
+	validStatuses := make([]ports.JobStatus, 0, len(status))
+	for _, s := range status {
+		if isValidStatus(s) {
+			validStatuses = append(validStatuses, s)
+		}
+	}
+	if len(validStatuses) == 0 {
+		return []ports.Job{}, nil
+	}
+
+	return s.storage.GetJobsByWorker(ctx, workerID, validStatuses)
+}
+
 // CreateJob creates a new job with the provided job creation data.
 // It generates a unique ID for the job and sets the initial status to "queued".
 // The job is then stored in the storage.
@@ -70,6 +110,14 @@ func (s *JobService) CreateJob(ctx context.Context, jobCreate ports.JobCreate) (
 	if !isSimpleValidVersion(jobCreate.Image.Version) {
 		return ports.Job{}, ports.ErrImageVersionIsInvalid
 	}
+	if jobCreate.Image.Digest != "" && !isDigest(jobCreate.Image.Digest) {
+		return ports.Job{}, ports.ErrImageDigestIsInvalid
+	}
+	if s.imagePolicy != nil {
+		if err := s.imagePolicy.Check(jobCreate.Image); err != nil {
+			return ports.Job{}, err
+		}
+	}
 
 	for key, value := range jobCreate.Parameters {
 		if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
@@ -77,6 +125,28 @@ func (s *JobService) CreateJob(ctx context.Context, jobCreate ports.JobCreate) (
 		}
 	}
 
+	inputFiles, inputs, err := validateInputFiles(jobCreate.InputFiles)
+	if err != nil {
+		return ports.Job{}, err
+	}
+
+	target := jobCreate.OptimisationTarget
+	if target == "" {
+		target = ports.TargetCarbonIntensity
+	}
+	if target != ports.TargetCarbonIntensity && target != ports.TargetRenewable && target != ports.TargetFossilFree {
+		return ports.Job{}, ports.ErrOptimisationTarget
+	}
+
+	allowedZones, err := trimAll(jobCreate.AllowedZones)
+	if err != nil {
+		return ports.Job{}, err
+	}
+	allowedRegions, err := trimAll(jobCreate.AllowedRegions)
+	if err != nil {
+		return ports.Job{}, err
+	}
+
 	newJob := ports.Job{
 		Id:                   uuid.NewString(),
 		UserID:               "some-user-id", // this should be replaced with actual user ID from context
@@ -86,10 +156,18 @@ func (s *JobService) CreateJob(ctx context.Context, jobCreate ports.JobCreate) (
 		Image:                jobCreate.Image,
 		AdjustmentParameters: jobCreate.Parameters,
 		CreationZone:         jobCreate.CreationZone,
+		InputFiles:           inputFiles,
+		OptimisationTarget:   target,
+		AllowedZones:         allowedZones,
+		AllowedRegions:       allowedRegions,
+		CarbonDataAge:        -1,
+		EnergyWh:             -1,
+		CarbonEmitted:        -1,
+		CarbonSaved:          -1,
 		Status:               ports.StatusQueued,
 	}
 
-	err := s.storage.CreateJob(ctx, newJob)
+	err = s.storage.CreateJob(ctx, newJob, inputs)
 	if err != nil {
 		return ports.Job{}, err
 	}
@@ -116,6 +194,21 @@ func (s *JobService) GetJob(ctx context.Context, id string) (ports.Job, error) {
 	return s.storage.GetJob(ctx, id)
 }
 
+// GetInputFile retrieves the content of an uploaded input file of the job with the provided ID.
+// Input files referenced by URL are not stored, they return ErrInputFileNotFound like unknown names.
+func (s *JobService) GetInputFile(ctx context.Context, id string, name string) ([]byte, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range job.InputFiles {
+		if file.Name == name && file.Uploaded {
+			return s.storage.GetInputFile(ctx, id, name)
+		}
+	}
+	return nil, ports.ErrInputFileNotFound
+}
+
 // GetJobOutcome retrieves the outcome of a job by its ID.
 // It returns the job name, status, result, error message, compute zone, carbon intensity, and carbon savings.
 // If the job is not found, it returns an error.
@@ -145,11 +238,15 @@ func (s *JobService) GetJobOutcome(ctx context.Context, id string) (ports.JobOut
 		ComputeZone:     job.ComputeZone,
 		CarbonIntensity: job.CarbonIntensity,
 		CarbonSavings:   job.CarbonSaving,
+		Progress:        job.Progress,
+		EnergyWh:        job.EnergyWh,
+		CarbonEmitted:   job.CarbonEmitted,
+		CarbonSaved:     job.CarbonSaved,
 	}, nil
 }
 
 // UpdateJobScheduler updates the job with the provided ID using the provided scheduler update data.
-// It modifies the job's worker ID, compute zone, carbon intensity, carbon savings, and status.
+// It modifies the job's worker ID, compute zone, carbon intensity, carbon savings, carbon data age, and status.
 // The updated job is returned.
 // functional options are used to modify the job's properties.
 func (s *JobService) UpdateJobScheduler(ctx context.Context, id string, data ports.SchedulerUpdateData) (ports.Job, error) {
@@ -183,6 +280,10 @@ func (s *JobService) UpdateJobScheduler(ctx context.Context, id string, data por
 	updated_job.ComputeZone = data.ComputeZone
 	updated_job.CarbonIntensity = data.CarbonIntensity
 	updated_job.CarbonSaving = data.CarbonSaving
+	updated_job.CarbonDataAge = -1
+	if data.CarbonDataAge != nil {
+		updated_job.CarbonDataAge = *data.CarbonDataAge
+	}
 	updated_job.Status = data.Status
 	updated_job.UpdatedAt = time.Now()
 
@@ -191,6 +292,9 @@ func (s *JobService) UpdateJobScheduler(ctx context.Context, id string, data por
 
 // UpdateJobWorkerDaemon updates the job with the provided ID using the provided worker daemon update data.
 // It modifies the job's status, result, and error message.
+// A result that was already applied, identified by its result ID, is not applied again, so the daemon can
+// safely retry deliveries. A different result for a finished job is rejected. The storage checks the
+// result ID atomically with the update, so of concurrent deliveries only one is applied.
 // The updated job is returned.
 // functional options are used to modify the job's properties.
 func (s *JobService) UpdateJobWorkerDaemon(ctx context.Context, id string, data ports.WorkerDaemonUpdateData) (ports.Job, error) {
@@ -207,20 +311,173 @@ func (s *JobService) UpdateJobWorkerDaemon(ctx context.Context, id string, data
 	if data.Status == ports.StatusFailed && strings.TrimSpace(data.ErrorMessage) == "" {
 		return ports.Job{}, ports.ErrErrorMessageEmpty
 	}
+	if data.Energy != nil && data.Energy.EnergyWh < 0 {
+		return ports.Job{}, ports.ErrEnergyIsNegative
+	}
 
 	updated_job, err := s.GetJob(ctx, id)
 
 	if err != nil {
 		return ports.Job{}, err
 	}
+	if data.ResultID != "" && data.ResultID == updated_job.ResultID {
+		return updated_job, nil
+	}
 	updated_job.Status = data.Status
 	updated_job.Result = data.Result
 	updated_job.ErrorMessage = data.ErrorMessage
+	updated_job.ResultID = data.ResultID
+	if data.Status == ports.StatusCompleted {
+		updated_job.Progress.Percent = 100
+	}
+	if data.Energy != nil {
+		updated_job.EnergyWh = data.Energy.EnergyWh
+		updated_job.EnergyMethod = data.Energy.Method
+		updated_job.CarbonEmitted, updated_job.CarbonSaved = carbonFromEnergy(updated_job)
+	}
+	updated_job.UpdatedAt = time.Now()
+
+	// the storage checks the result ID again while it updates, a concurrent delivery may have won
+	job, err := s.storage.UpdateJobResult(ctx, id, updated_job)
+	if err == ports.ErrJobAlreadyFinished {
+		if stored, getErr := s.storage.GetJob(ctx, id); getErr == nil && data.ResultID != "" && stored.ResultID == data.ResultID {
+			return stored, nil
+		}
+	}
+	return job, err
+}
+
+// UpdateJobProgress updates the progress of the job with the provided ID.
+// A scheduled job is moved to running, because reporting progress means the container has started.
+// Progress for jobs that already finished is rejected, e.g. when a late heartbeat arrives.
+// The updated job is returned.
+func (s *JobService) UpdateJobProgress(ctx context.Context, id string, data ports.JobProgress) (ports.Job, error) {
+	if data.Percent < 0 || data.Percent > 100 {
+		return ports.Job{}, ports.ErrProgressOutOfRange
+	}
+
+	updated_job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return ports.Job{}, err
+	}
+
+	switch updated_job.Status {
+	case ports.StatusCompleted, ports.StatusFailed, ports.StatusCancelled:
+		return ports.Job{}, ports.ErrJobAlreadyFinished
+	case ports.StatusScheduled:
+		updated_job.Status = ports.StatusRunning
+	}
+	updated_job.Progress = ports.JobProgress{
+		Percent: data.Percent,
+		Message: strings.TrimSpace(data.Message),
+	}
 	updated_job.UpdatedAt = time.Now()
 
 	return s.storage.UpdateJob(ctx, id, updated_job)
 }
 
+// carbonFromEnergy computes the gCO2eq a job emitted in its compute zone and saved compared to its creation zone.
+// The carbon intensity and savings are set by the job-scheduler in gCO2eq/kWh, -1 is returned while they are unknown.
+func carbonFromEnergy(job ports.Job) (emitted float64, saved float64) {
+	if job.EnergyWh < 0 || job.ComputeZone == "" || job.CarbonIntensity < 0 {
+		return -1, -1
+	}
+	energyKWh := job.EnergyWh / 1000
+
+	emitted = energyKWh * float64(job.CarbonIntensity)
+	saved = -1
+	if job.CarbonSaving >= 0 {
+		saved = energyKWh * float64(job.CarbonSaving)
+	}
+	return emitted, saved
+}
+
+// validateInputFiles checks the input files of a new job and returns them normalized.
+// Uploaded content must be valid base64, its checksum is computed if not provided. The decoded
+// content is returned by file name and removed from the files, which are marked as uploaded.
+// URL references must use http(s) and provide the checksum the daemon verifies after the download.
+func validateInputFiles(files []ports.InputFile) ([]ports.InputFile, map[string][]byte, error) {
+	validated := make([]ports.InputFile, 0, len(files))
+	inputs := make(map[string][]byte)
+	names := make(map[string]bool, len(files))
+	totalSize := 0
+
+	for _, file := range files {
+		file.Name = strings.TrimSpace(file.Name)
+		file.Checksum = strings.ToLower(strings.TrimSpace(file.Checksum))
+
+		// hidden files are rejected like the worker daemon does, which stages the files into the container
+		if file.Name == "" || file.Name != path.Base(file.Name) || strings.HasPrefix(file.Name, ".") ||
+			strings.ContainsRune(file.Name, '\\') || names[file.Name] {
+			return nil, nil, ports.ErrInputFileName
+		}
+		names[file.Name] = true
+
+		if (file.URL == "") == (file.Content == "") {
+			return nil, nil, ports.ErrInputFileSource
+		}
+
+		if file.URL != "" {
+			u, err := url.Parse(file.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, nil, ports.ErrInputFileSource
+			}
+			if !isSHA256(file.Checksum) {
+				return nil, nil, ports.ErrInputFileChecksum
+			}
+		} else {
+			content, err := base64.StdEncoding.DecodeString(file.Content)
+			if err != nil {
+				return nil, nil, ports.ErrInputFileSource
+			}
+			totalSize += len(content)
+			if totalSize > MaxInputContentSize {
+				return nil, nil, ports.ErrInputFileTooLarge
+			}
+			sum := sha256.Sum256(content)
+			checksum := hex.EncodeToString(sum[:])
+			if file.Checksum != "" && file.Checksum != checksum {
+				return nil, nil, ports.ErrInputFileChecksum
+			}
+			file.Checksum = checksum
+			file.Content = ""
+			file.Uploaded = true
+			inputs[file.Name] = content
+		}
+
+		validated = append(validated, file)
+	}
+	return validated, inputs, nil
+}
+
+// trimAll trims the allowed zones or regions of a new job, empty ones are rejected
+func trimAll(values []string) ([]string, error) {
+	var trimmed []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, ports.ErrAllowedZoneEmpty
+		}
+		trimmed = append(trimmed, value)
+	}
+	return trimmed, nil
+}
+
+// isSHA256 checks if the checksum is a hex encoded SHA-256 hash.
+func isSHA256(checksum string) bool {
+	if len(checksum) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(checksum)
+	return err == nil
+}
+
+// isDigest checks if the image digest is a sha256 digest, e.g. "sha256:<hex>".
+func isDigest(digest string) bool {
+	checksum, found := strings.CutPrefix(digest, "sha256:")
+	return found && isSHA256(checksum) && strings.ToLower(checksum) == checksum
+}
+
 // isSimpleValidVersion checks if the image version string contains only valid characters.
 func isSimpleValidVersion(version string) bool {
 	for _, char := range version {
@@ -2,7 +2,10 @@ package core_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/google/uuid"
@@ -129,6 +132,201 @@ func TestJobService_CreateJob(t *testing.T) {
 	}
 }
 
+func TestJobService_CreateJob_OptimisationTarget(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	tests := []struct {
+		target   string
+		expected string
+		wantErr  error
+	}{
+		{"", ports.TargetCarbonIntensity, nil},
+		{ports.TargetRenewable, ports.TargetRenewable, nil},
+		{ports.TargetFossilFree, ports.TargetFossilFree, nil},
+		{"cheapest", "", ports.ErrOptimisationTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			job, err := service.CreateJob(ctx, ports.JobCreate{
+				JobName:            "Test Job",
+				CreationZone:       "DE",
+				Image:              ports.ContainerImage{Name: "golang", Version: "1.15"},
+				OptimisationTarget: tt.target,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if job.OptimisationTarget != tt.expected {
+				t.Errorf("Expected optimisation target %q, got %q", tt.expected, job.OptimisationTarget)
+			}
+		})
+	}
+}
+
+func TestJobService_CreateJob_AllowedZones(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		zones           []string
+		regions         []string
+		expectedZones   []string
+		expectedRegions []string
+		wantErr         error
+	}{
+		{"No restriction", nil, nil, nil, nil, nil},
+		{"Zones and regions are trimmed", []string{" DE", "FR "}, []string{"EU"}, []string{"DE", "FR"}, []string{"EU"}, nil},
+		{"Empty zone", []string{"DE", " "}, nil, nil, nil, ports.ErrAllowedZoneEmpty},
+		{"Empty region", nil, []string{""}, nil, nil, ports.ErrAllowedZoneEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := service.CreateJob(ctx, ports.JobCreate{
+				JobName:        "Test Job",
+				CreationZone:   "DE",
+				Image:          ports.ContainerImage{Name: "golang", Version: "1.15"},
+				AllowedZones:   tt.zones,
+				AllowedRegions: tt.regions,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(job.AllowedZones, tt.expectedZones) || !slices.Equal(job.AllowedRegions, tt.expectedRegions) {
+				t.Errorf("Expected allowed zones %v and regions %v, got %v and %v", tt.expectedZones, tt.expectedRegions, job.AllowedZones, job.AllowedRegions)
+			}
+		})
+	}
+}
+
+func TestJobService_CreateJob_InputFiles(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	// base64("hello") and its SHA-256
+	content := "aGVsbG8="
+	checksum := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	tests := []struct {
+		name      string
+		files     []ports.InputFile
+		wantErr   error
+		wantCheck string
+	}{
+		{
+			name:      "Uploaded content gets checksum",
+			files:     []ports.InputFile{{Name: "hello.txt", Content: content}},
+			wantCheck: checksum,
+		},
+		{
+			name:      "URL reference with checksum",
+			files:     []ports.InputFile{{Name: "data.csv", URL: "https://example.org/data.csv", Checksum: checksum}},
+			wantCheck: checksum,
+		},
+		{
+			name:    "URL reference without checksum",
+			files:   []ports.InputFile{{Name: "data.csv", URL: "https://example.org/data.csv"}},
+			wantErr: ports.ErrInputFileChecksum,
+		},
+		{
+			name:    "Content does not match checksum",
+			files:   []ports.InputFile{{Name: "hello.txt", Content: content, Checksum: "00" + checksum[2:]}},
+			wantErr: ports.ErrInputFileChecksum,
+		},
+		{
+			name:    "Neither url nor content",
+			files:   []ports.InputFile{{Name: "empty.txt"}},
+			wantErr: ports.ErrInputFileSource,
+		},
+		{
+			name:    "Unsupported url scheme",
+			files:   []ports.InputFile{{Name: "data.csv", URL: "file:///etc/passwd", Checksum: checksum}},
+			wantErr: ports.ErrInputFileSource,
+		},
+		{
+			name:    "Path in name",
+			files:   []ports.InputFile{{Name: "../hello.txt", Content: content}},
+			wantErr: ports.ErrInputFileName,
+		},
+		{
+			name:    "Hidden file",
+			files:   []ports.InputFile{{Name: ".env", Content: content}},
+			wantErr: ports.ErrInputFileName,
+		},
+		{
+			name:    "Parent directory as name",
+			files:   []ports.InputFile{{Name: "..", Content: content}},
+			wantErr: ports.ErrInputFileName,
+		},
+		{
+			name:    "Duplicate name",
+			files:   []ports.InputFile{{Name: "hello.txt", Content: content}, {Name: "hello.txt", Content: content}},
+			wantErr: ports.ErrInputFileName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := service.CreateJob(ctx, ports.JobCreate{
+				JobName:      "Input Job",
+				CreationZone: "DE",
+				Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+				InputFiles:   tt.files,
+			})
+			if err != tt.wantErr {
+				t.Fatalf("CreateJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && job.InputFiles[0].Checksum != tt.wantCheck {
+				t.Errorf("Expected checksum %v, got %v", tt.wantCheck, job.InputFiles[0].Checksum)
+			}
+		})
+	}
+}
+
+func TestJobService_GetInputFile(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	job, err := service.CreateJob(ctx, ports.JobCreate{
+		JobName:      "Input Job",
+		CreationZone: "DE",
+		Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+		InputFiles: []ports.InputFile{
+			{Name: "hello.txt", Content: "aGVsbG8="},
+			{Name: "data.csv", URL: "https://example.org/data.csv", Checksum: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	// the content is kept out of the job, only the uploaded file is marked
+	if job.InputFiles[0].Content != "" || !job.InputFiles[0].Uploaded || job.InputFiles[1].Uploaded {
+		t.Errorf("Expected only hello.txt marked as uploaded without content, got %+v", job.InputFiles)
+	}
+	stored, _ := service.GetJob(ctx, job.Id)
+	if stored.InputFiles[0].Content != "" {
+		t.Errorf("Expected the stored job without content, got %+v", stored.InputFiles)
+	}
+
+	content, err := service.GetInputFile(ctx, job.Id, "hello.txt")
+	if err != nil || string(content) != "hello" {
+		t.Errorf("Expected content hello, got %q, %v", content, err)
+	}
+	if _, err := service.GetInputFile(ctx, job.Id, "data.csv"); err != ports.ErrInputFileNotFound {
+		t.Errorf("Expected ErrInputFileNotFound for a URL reference, got %v", err)
+	}
+	if _, err := service.GetInputFile(ctx, job.Id, "missing.txt"); err != ports.ErrInputFileNotFound {
+		t.Errorf("Expected ErrInputFileNotFound, got %v", err)
+	}
+	if _, err := service.GetInputFile(ctx, uuid.NewString(), "hello.txt"); err != ports.ErrJobNotFound {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+}
+
 func TestJobService_GetJob(t *testing.T) {
 	service, _ := setup()
 	ctx := context.Background()
@@ -480,6 +678,36 @@ func TestJobService_UpdateJobScheduler(t *testing.T) {
 	}
 }
 
+func TestJobService_UpdateJobScheduler_CarbonDataAge(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	createdJob, _ := service.CreateJob(ctx, ports.JobCreate{
+		JobName:      "Carbon Data Age Test",
+		CreationZone: "DE",
+		Image:        ports.ContainerImage{Name: "python", Version: "3.8"},
+	})
+	if createdJob.CarbonDataAge != -1 {
+		t.Errorf("expected unknown carbon data age for a new job, got %d", createdJob.CarbonDataAge)
+	}
+
+	age := 900
+	updateData := ports.SchedulerUpdateData{
+		WorkerID: uuid.NewString(), ComputeZone: "FR", CarbonIntensity: 75, CarbonSaving: 30, CarbonDataAge: &age, Status: ports.StatusScheduled,
+	}
+	job, err := service.UpdateJobScheduler(ctx, createdJob.Id, updateData)
+	if err != nil || job.CarbonDataAge != 900 {
+		t.Errorf("expected carbon data age 900, got %d, %v", job.CarbonDataAge, err)
+	}
+
+	// a scheduler without the age leaves it unknown
+	updateData.CarbonDataAge = nil
+	job, err = service.UpdateJobScheduler(ctx, createdJob.Id, updateData)
+	if err != nil || job.CarbonDataAge != -1 {
+		t.Errorf("expected unknown carbon data age, got %d, %v", job.CarbonDataAge, err)
+	}
+}
+
 func TestJobService_UpdateJobWorkerDaemon(t *testing.T) {
 	service, _ := setup()
 	ctx := context.Background()
@@ -570,6 +798,205 @@ func TestJobService_UpdateJobWorkerDaemon(t *testing.T) {
 	}
 }
 
+func TestJobService_UpdateJobProgress(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	createdJob, _ := service.CreateJob(ctx, ports.JobCreate{
+		JobName:      "Progress Test",
+		CreationZone: "DE",
+		Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+	})
+	service.UpdateJobScheduler(ctx, createdJob.Id, ports.SchedulerUpdateData{
+		WorkerID: uuid.NewString(), ComputeZone: "FR", CarbonIntensity: 50, Status: ports.StatusScheduled,
+	})
+
+	job, err := service.UpdateJobProgress(ctx, createdJob.Id, ports.JobProgress{Percent: 42, Message: " epoch 3/10 "})
+	if err != nil {
+		t.Fatalf("UpdateJobProgress() error = %v", err)
+	}
+	if job.Status != ports.StatusRunning {
+		t.Errorf("Expected status %v, got %v", ports.StatusRunning, job.Status)
+	}
+	if job.Progress.Percent != 42 || job.Progress.Message != "epoch 3/10" {
+		t.Errorf("Unexpected progress %+v", job.Progress)
+	}
+
+	outcome, _ := service.GetJobOutcome(ctx, createdJob.Id)
+	if outcome.Progress != job.Progress {
+		t.Errorf("Expected outcome progress %+v, got %+v", job.Progress, outcome.Progress)
+	}
+
+	if _, err := service.UpdateJobProgress(ctx, createdJob.Id, ports.JobProgress{Percent: 101}); err != ports.ErrProgressOutOfRange {
+		t.Errorf("Expected %v, got %v", ports.ErrProgressOutOfRange, err)
+	}
+
+	service.UpdateJobWorkerDaemon(ctx, createdJob.Id, ports.WorkerDaemonUpdateData{Status: ports.StatusCompleted})
+	if _, err := service.UpdateJobProgress(ctx, createdJob.Id, ports.JobProgress{Percent: 50}); err != ports.ErrJobAlreadyFinished {
+		t.Errorf("Expected %v, got %v", ports.ErrJobAlreadyFinished, err)
+	}
+}
+
+func TestJobService_UpdateJobWorkerDaemon_Energy(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	createdJob, _ := service.CreateJob(ctx, ports.JobCreate{
+		JobName:      "Energy Test",
+		CreationZone: "DE",
+		Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+	})
+	// DE 300 gCO2eq/kWh, FR 50 gCO2eq/kWh
+	service.UpdateJobScheduler(ctx, createdJob.Id, ports.SchedulerUpdateData{
+		WorkerID: uuid.NewString(), ComputeZone: "FR", CarbonIntensity: 50, CarbonSaving: 250, Status: ports.StatusScheduled,
+	})
+
+	if _, err := service.UpdateJobWorkerDaemon(ctx, createdJob.Id, ports.WorkerDaemonUpdateData{
+		Status: ports.StatusCompleted,
+		Energy: &ports.EnergyReport{EnergyWh: -5},
+	}); err != ports.ErrEnergyIsNegative {
+		t.Errorf("Expected %v, got %v", ports.ErrEnergyIsNegative, err)
+	}
+
+	job, err := service.UpdateJobWorkerDaemon(ctx, createdJob.Id, ports.WorkerDaemonUpdateData{
+		Status: ports.StatusCompleted,
+		Energy: &ports.EnergyReport{EnergyWh: 20, Method: "rapl"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateJobWorkerDaemon() error = %v", err)
+	}
+	if job.EnergyMethod != "rapl" {
+		t.Errorf("Expected energy method rapl, got %v", job.EnergyMethod)
+	}
+
+	// 0.02 kWh * 50 = 1 g emitted, 0.02 kWh * 250 = 5 g saved
+	outcome, _ := service.GetJobOutcome(ctx, createdJob.Id)
+	if outcome.EnergyWh != 20 || outcome.CarbonEmitted != 1 || outcome.CarbonSaved != 5 {
+		t.Errorf("Unexpected outcome energy %v, emitted %v, saved %v", outcome.EnergyWh, outcome.CarbonEmitted, outcome.CarbonSaved)
+	}
+}
+
+func TestJobService_GetJobsByWorker(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	workers := []string{uuid.NewString(), uuid.NewString()}
+	for _, workerID := range workers {
+		createdJob, _ := service.CreateJob(ctx, ports.JobCreate{
+			JobName:      "Job for " + workerID,
+			CreationZone: "DE",
+			Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+		})
+		if _, err := service.UpdateJobScheduler(ctx, createdJob.Id, ports.SchedulerUpdateData{
+			WorkerID: workerID, ComputeZone: "DE", CarbonIntensity: 50, CarbonSaving: 10, Status: ports.StatusScheduled,
+		}); err != nil {
+			t.Fatalf("Failed to update job: %v", err)
+		}
+	}
+
+	jobs, err := service.GetJobsByWorker(ctx, workers[0], []ports.JobStatus{ports.StatusScheduled})
+	if err != nil {
+		t.Fatalf("GetJobsByWorker() error = %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].WorkerID != workers[0] {
+		t.Errorf("Expected only the job of worker %s, got %v", workers[0], jobs)
+	}
+
+	if _, err := service.GetJobsByWorker(ctx, " ", nil); err != ports.ErrNotExistingWorkerID {
+		t.Errorf("Expected %v, got %v", ports.ErrNotExistingWorkerID, err)
+	}
+}
+
+func TestJobService_UpdateJobWorkerDaemon_DuplicateResult(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	createdJob, _ := service.CreateJob(ctx, ports.JobCreate{
+		JobName:      "Retry Test",
+		CreationZone: "DE",
+		Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+	})
+	result := ports.WorkerDaemonUpdateData{Status: ports.StatusCompleted, Result: "42", ResultID: "result-1"}
+
+	first, err := service.UpdateJobWorkerDaemon(ctx, createdJob.Id, result)
+	if err != nil {
+		t.Fatalf("UpdateJobWorkerDaemon() error = %v", err)
+	}
+
+	// the daemon retries after a lost response
+	retried, err := service.UpdateJobWorkerDaemon(ctx, createdJob.Id, result)
+	if err != nil {
+		t.Fatalf("Expected duplicate delivery to succeed, got %v", err)
+	}
+	if !retried.UpdatedAt.Equal(first.UpdatedAt) || retried.Result != "42" {
+		t.Errorf("Expected duplicate delivery not to change the job")
+	}
+
+	_, err = service.UpdateJobWorkerDaemon(ctx, createdJob.Id, ports.WorkerDaemonUpdateData{
+		Status: ports.StatusCompleted, Result: "other", ResultID: "result-2",
+	})
+	if err != ports.ErrJobAlreadyFinished {
+		t.Errorf("Expected %v, got %v", ports.ErrJobAlreadyFinished, err)
+	}
+}
+
+func TestJobService_UpdateJobWorkerDaemon_ConcurrentResults(t *testing.T) {
+	service, _ := setup()
+	ctx := context.Background()
+
+	createdJob, _ := service.CreateJob(ctx, ports.JobCreate{
+		JobName:      "Race Test",
+		CreationZone: "DE",
+		Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+	})
+
+	// retries of one result race with a result from another delivery, only one of them may be applied
+	const deliveries = 20
+	var wg sync.WaitGroup
+	results := make([]string, deliveries)
+	errs := make([]error, deliveries)
+	for i := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resultID := fmt.Sprintf("result-%d", i%2)
+			job, err := service.UpdateJobWorkerDaemon(ctx, createdJob.Id, ports.WorkerDaemonUpdateData{
+				Status: ports.StatusCompleted, Result: resultID, ResultID: resultID,
+			})
+			results[i], errs[i] = job.Result, err
+		}()
+	}
+	wg.Wait()
+
+	stored, _ := service.GetJob(ctx, createdJob.Id)
+	for i, err := range errs {
+		switch {
+		case fmt.Sprintf("result-%d", i%2) == stored.ResultID:
+			if err != nil || results[i] != stored.Result {
+				t.Errorf("Expected delivery %d of the applied result to succeed with it, got %q, %v", i, results[i], err)
+			}
+		case err != ports.ErrJobAlreadyFinished:
+			t.Errorf("Expected delivery %d of the other result to fail with %v, got %v", i, ports.ErrJobAlreadyFinished, err)
+		}
+	}
+	if stored.Result != stored.ResultID {
+		t.Errorf("Expected result and result ID of the same delivery, got %q and %q", stored.Result, stored.ResultID)
+	}
+}
+
+func TestJobService_CreateJob_EnergyUnknown(t *testing.T) {
+	service, _ := setup()
+
+	job, _ := service.CreateJob(context.Background(), ports.JobCreate{
+		JobName:      "Unmeasured Job",
+		CreationZone: "DE",
+		Image:        ports.ContainerImage{Name: "golang", Version: "1.15"},
+	})
+	if job.EnergyWh != -1 || job.CarbonEmitted != -1 || job.CarbonSaved != -1 {
+		t.Errorf("Expected -1 for unknown energy and carbon, got %v, %v, %v", job.EnergyWh, job.CarbonEmitted, job.CarbonSaved)
+	}
+}
+
 func generateLargeParameters(n int) map[string]string {
 	params := make(map[string]string)
 	for i := 0; i < n; i++ {
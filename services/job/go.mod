@@ -4,6 +4,8 @@ go 1.24.1
 
 toolchain go1.24.2
 
+replace github.com/informatik-mannheim/cmg-ss2025/pkg/imagepolicy => ../../pkg/imagepolicy
+
 // To get the latest versions of the logging and tracing packages, run:
 // bash: go get github.com/informatik-mannheim/cmg-ss2025/pkg/tracing@<commit-id>
 // bash: go get github.com/informatik-mannheim/cmg-ss2025/pkg/logging@<commit-id>
@@ -12,6 +14,7 @@ toolchain go1.24.2
 require (
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
+	github.com/informatik-mannheim/cmg-ss2025/pkg/imagepolicy v0.0.0-00010101000000-000000000000
 	github.com/informatik-mannheim/cmg-ss2025/pkg/logging v0.0.0-20250703141304-772beaec9a92
 	github.com/informatik-mannheim/cmg-ss2025/pkg/tracing v0.0.0-20250703141304-772beaec9a92
 	github.com/jackc/pgx/v5 v5.7.5
@@ -77,6 +77,17 @@ func main() {
 		logging.Error(errorMessage)
 	}
 
+	// Restrict the images jobs may run, all images are allowed without a policy
+	if policyFile := os.Getenv("IMAGE_POLICY_FILE"); policyFile != "" {
+		policy, err := core.LoadImagePolicy(policyFile)
+		if err != nil {
+			logging.Error("could not load image policy: " + err.Error())
+			os.Exit(1)
+		}
+		jobService.SetImagePolicy(policy)
+		logging.Debug("Image policy loaded", "file", policyFile)
+	}
+
 	// Set up the HTTP server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -10,6 +10,13 @@ type JobCreate struct {
 	CreationZone string            `json:"creationZone"`
 	Image        ContainerImage    `json:"image"`
 	Parameters   map[string]string `json:"parameters"`
+	InputFiles   []InputFile       `json:"inputFiles"`
+	// OptimisationTarget is one of the Target constants, empty for carbon-intensity
+	OptimisationTarget string `json:"optimisationTarget"`
+	// AllowedZones and AllowedRegions restrict the compute zone, e.g. to keep data in the EU. A job with
+	// both may run in the zones and in the regions, a job without any runs anywhere.
+	AllowedZones   []string `json:"allowedZones"`
+	AllowedRegions []string `json:"allowedRegions"`
 }
 
 // SchedulerUpdateData represents data needed for updating a job from the scheduler's perspective
@@ -18,25 +25,41 @@ type SchedulerUpdateData struct {
 	ComputeZone     string    `json:"computeZone"`
 	CarbonIntensity int       `json:"carbonIntensity"`
 	CarbonSaving    int       `json:"carbonSavings"`
+	CarbonDataAge   *int      `json:"carbonDataAge,omitempty"` // nil if the scheduler does not know it
 	Status          JobStatus `json:"status"`
 }
 
 // WorkerDaemonUpdateData represents data needed for updating a job from the worker daemon's perspective
 type WorkerDaemonUpdateData struct {
-	Status       JobStatus `json:"status"`
-	Result       string    `json:"result"`
-	ErrorMessage string    `json:"errorMessage"`
+	Status       JobStatus     `json:"status"`
+	Result       string        `json:"result"`
+	ErrorMessage string        `json:"errorMessage"`
+	Energy       *EnergyReport `json:"energy,omitempty"`
+	ResultID     string        `json:"resultId,omitempty"` // generated by the worker daemon once per result, makes retried deliveries idempotent
+}
+
+// EnergyReport represents the resource usage and energy of a job execution measured by the worker daemon
+type EnergyReport struct {
+	EnergyWh        float64 `json:"energyWh"`
+	CPUSeconds      float64 `json:"cpuSeconds"`
+	MemoryGBSeconds float64 `json:"memoryGbSeconds"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Method          string  `json:"method"`
 }
 
 // JobOutcome represents the outcome of a job
 type JobOutcome struct {
-	JobName         string    `json:"jobName"`
-	Status          JobStatus `json:"status"`
-	Result          string    `json:"result"`
-	ErrorMessage    string    `json:"errorMessage"`
-	ComputeZone     string    `json:"computeZone"`
-	CarbonIntensity int       `json:"carbonIntensity"`
-	CarbonSavings   int       `json:"carbonSavings"`
+	JobName         string      `json:"jobName"`
+	Status          JobStatus   `json:"status"`
+	Result          string      `json:"result"`
+	ErrorMessage    string      `json:"errorMessage"`
+	ComputeZone     string      `json:"computeZone"`
+	CarbonIntensity int         `json:"carbonIntensity"`
+	CarbonSavings   int         `json:"carbonSavings"`
+	Progress        JobProgress `json:"progress"`
+	EnergyWh        float64     `json:"energyWh"`
+	CarbonEmitted   float64     `json:"carbonEmitted"`
+	CarbonSaved     float64     `json:"carbonSaved"`
 }
 
 // JobService defines interfaces for interacting with Job resources
@@ -44,12 +67,18 @@ type JobService interface {
 	// GetJobs retrieves jobs, optionally filtered by status
 	GetJobs(ctx context.Context, status []JobStatus) ([]Job, error)
 
+	// GetJobsByWorker retrieves the jobs assigned to a worker, optionally filtered by status
+	GetJobsByWorker(ctx context.Context, workerID string, status []JobStatus) ([]Job, error)
+
 	// CreateJob creates a new job in the queue
 	CreateJob(ctx context.Context, job JobCreate) (Job, error)
 
 	// GetJob retrieves a specific job by its ID
 	GetJob(ctx context.Context, id string) (Job, error)
 
+	// GetInputFile retrieves the content of an uploaded input file of a job
+	GetInputFile(ctx context.Context, id string, name string) ([]byte, error)
+
 	// GetJobOutcome retrieves detailed result and metadata of a job by its ID
 	GetJobOutcome(ctx context.Context, id string) (JobOutcome, error)
 
@@ -58,4 +87,7 @@ type JobService interface {
 
 	// UpdateJobWorkerDaemon updates job properties from a worker daemon's perspective
 	UpdateJobWorkerDaemon(ctx context.Context, id string, data WorkerDaemonUpdateData) (Job, error)
+
+	// UpdateJobProgress stores the progress a worker daemon reported for a running job
+	UpdateJobProgress(ctx context.Context, id string, data JobProgress) (Job, error)
 }
@@ -11,7 +11,20 @@ var (
 	ErrNotExistingImageName  = errors.New("image name must be provided")
 	ErrNotExistingWorkerID   = errors.New("worker ID must be provided")
 	ErrImageVersionIsInvalid = errors.New("image version format is invalid")
+	ErrImageDigestIsInvalid  = errors.New("image digest must be a sha256 digest")
+	ErrImageNotAllowed       = errors.New("image is not allowed by the image policy")
+	ErrImageSignatureInvalid = errors.New("image signature is missing or invalid")
 	ErrParamKeyValueEmpty    = errors.New("parameters cannot have empty keys or values")
 	ErrErrorMessageEmpty     = errors.New("error message must be provided for failed jobs")
 	ErrCarbonIsNegative      = errors.New("carbon intensity must be non-negative")
+	ErrEnergyIsNegative      = errors.New("energy must be non-negative")
+	ErrProgressOutOfRange    = errors.New("progress percent must be between 0 and 100")
+	ErrJobAlreadyFinished    = errors.New("job has already finished")
+	ErrInputFileName         = errors.New("input file names must be unique, must not contain path separators and must not start with a dot")
+	ErrInputFileSource       = errors.New("input file needs either a http(s) url or base64 content")
+	ErrInputFileChecksum     = errors.New("input file checksum must be a hex encoded SHA-256 matching the content")
+	ErrInputFileTooLarge     = errors.New("uploaded input files exceed the maximum size")
+	ErrInputFileNotFound     = errors.New("uploaded input file not found")
+	ErrOptimisationTarget    = errors.New("optimisation target must be carbon-intensity, renewable or fossil-free")
+	ErrAllowedZoneEmpty      = errors.New("allowed zones and regions cannot be empty")
 )
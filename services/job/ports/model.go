@@ -13,9 +13,37 @@ const (
 	StatusCancelled JobStatus = "cancelled" // set by daemon
 )
 
+// Optimisation targets of a job, the job-scheduler moves it to the zone that is best in its target
+const (
+	TargetCarbonIntensity = "carbon-intensity" // lowest gCO2eq/kWh, the default
+	TargetRenewable       = "renewable"        // highest renewable share of the production
+	TargetFossilFree      = "fossil-free"      // highest share of renewables and nuclear
+)
+
 type ContainerImage struct {
-	Name    string `json:"name" db:"image_name"`
-	Version string `json:"version" db:"image_version"`
+	Name      string `json:"name" db:"image_name"`
+	Version   string `json:"version" db:"image_version"`
+	Digest    string `json:"digest,omitempty" db:"image_digest"`       // e.g. "sha256:...", pins the image independent of the version
+	Signature string `json:"signature,omitempty" db:"image_signature"` // base64 signature of the digest reference, see core.ImagePolicy
+}
+
+// InputFile is a file that the worker daemon stages into the container before the job runs.
+// Either URL or Content must be set. Checksum is the hex encoded SHA-256 of the file content,
+// it is computed by the job-service for uploaded content and required for URL references.
+// Uploaded content is only part of the create request, it is stored apart from the job
+// and served by GET /jobs/{id}/inputs/{name}, the job only keeps Uploaded.
+type InputFile struct {
+	Name     string `json:"name"`               // file name inside the input directory, e.g. "data.csv"
+	URL      string `json:"url,omitempty"`      // http(s) location the daemon downloads the file from
+	Content  string `json:"content,omitempty"`  // base64 encoded file content, only in the create request
+	Uploaded bool   `json:"uploaded,omitempty"` // the content is served by GET /jobs/{id}/inputs/{name}
+	Checksum string `json:"checksum"`           // hex encoded SHA-256
+}
+
+// JobProgress is the last progress a running container reported through the worker daemon.
+type JobProgress struct {
+	Percent int    `json:"percent" db:"progress_percent"` // 0 - 100
+	Message string `json:"message" db:"progress_message"` // optional free text, e.g. "epoch 3/10"
 }
 
 // Json tags helps by (de-)serializing, json:"id" -> "id":"1234", functionality imported by "encoding/json" package
@@ -31,18 +59,31 @@ type Job struct {
 	// set by consumer-cli, theyre not empty by default
 	JobName              string            `json:"jobName" db:"job_name"` // set by User
 	Image                ContainerImage    `json:"image" db:"-"`
-	AdjustmentParameters map[string]string `json:"parameters" db:"adjustment_parameters"` // e.g key(-p) : value (8080:8080)
-	CreationZone         string            `json:"creationZone" db:"creation_zone"`       // origin of the job creation
+	AdjustmentParameters map[string]string `json:"parameters" db:"adjustment_parameters"`       // e.g key(-p) : value (8080:8080)
+	CreationZone         string            `json:"creationZone" db:"creation_zone"`             // origin of the job creation
+	InputFiles           []InputFile       `json:"inputFiles" db:"input_files"`                 // staged into the container under /input before the job runs
+	OptimisationTarget   string            `json:"optimisationTarget" db:"optimisation_target"` // see Target constants, carbon-intensity by default
+	AllowedZones         []string          `json:"allowedZones" db:"allowed_zones"`             // zones the job may run in, empty for all zones
+	AllowedRegions       []string          `json:"allowedRegions" db:"allowed_regions"`         // regions, continents or countries of the carbon-intensity-provider, e.g. "EU"
 
 	// set by job-scheduler
 	WorkerID        string `json:"workerId" db:"worker_id"`               // default value is empty string - saved as UUID
 	ComputeZone     string `json:"computeZone" db:"compute_zone"`         // default value is empty string - saved as "zone key", we get from Electricity Maps API, e.g "DE" (germany)
 	CarbonIntensity int    `json:"carbonIntensity" db:"carbon_intensity"` // default value is -1 - CO2eq/kWh which are emitted during job execution
 	CarbonSaving    int    `json:"carbonSavings" db:"carbon_savings"`     // default value is -1 - consumption savings compared to the actual consumer location
+	CarbonDataAge   int    `json:"carbonDataAge" db:"carbon_data_age"`    // default value is -1 - seconds since the carbon intensity was measured when the job was scheduled
 
 	// set by worker
-	Result       string `json:"result" db:"result"`              // empty string by default - perhaps some containers will provide a result
-	ErrorMessage string `json:"errorMessage" db:"error_message"` // empty string by default
+	Result       string      `json:"result" db:"result"`              // empty string by default - perhaps some containers will provide a result
+	ErrorMessage string      `json:"errorMessage" db:"error_message"` // empty string by default
+	ResultID     string      `json:"resultId" db:"result_id"`         // empty string by default - ID of the delivered result, repeated deliveries with the same ID are ignored
+	Progress     JobProgress `json:"progress" db:"-"`                 // last progress reported by the container while running
+	EnergyWh     float64     `json:"energyWh" db:"energy_wh"`         // default value is -1 - energy the container used, measured by the worker daemon
+	EnergyMethod string      `json:"energyMethod" db:"energy_method"` // how the energy was determined, e.g. "rapl" or "cgroup-model"
+
+	// computed by job-service from the energy and the carbon intensities
+	CarbonEmitted float64 `json:"carbonEmitted" db:"carbon_emitted"` // default value is -1 - gCO2eq emitted by the execution in the compute zone
+	CarbonSaved   float64 `json:"carbonSaved" db:"carbon_saved"`     // default value is -1 - gCO2eq saved compared to an execution in the creation zone
 
 	// multiple access
 	Status JobStatus `json:"status" db:"job_status"` // default value is "queued"
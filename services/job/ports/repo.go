@@ -6,7 +6,12 @@ import (
 
 type JobStorage interface {
 	GetJobs(ctx context.Context, status []JobStatus) ([]Job, error)
-	CreateJob(ctx context.Context, job Job) error
+	GetJobsByWorker(ctx context.Context, workerID string, status []JobStatus) ([]Job, error)
+	CreateJob(ctx context.Context, job Job, inputs map[string][]byte) error // inputs is the uploaded content by file name
 	GetJob(ctx context.Context, id string) (Job, error)
 	UpdateJob(ctx context.Context, id string, job Job) (Job, error)
+	// UpdateJobResult updates the job unless the stored job has the same result ID or is finished with another
+	// one, then ErrJobAlreadyFinished is returned. The check and the update are atomic.
+	UpdateJobResult(ctx context.Context, id string, job Job) (Job, error)
+	GetInputFile(ctx context.Context, jobID string, name string) ([]byte, error)
 }
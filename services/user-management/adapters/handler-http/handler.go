@@ -31,7 +31,7 @@ func (h *HTTPHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	var req ports.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil ||
-		(req.Role != ports.Consumer && req.Role != ports.Provider && req.Role != ports.JobScheduler) {
+		(req.Role != ports.Consumer && req.Role != ports.Provider && req.Role != ports.JobScheduler && req.Role != ports.Admin) {
 		http.Error(w, "invalid request", http.StatusBadRequest)
 		return
 	}
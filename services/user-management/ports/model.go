@@ -6,6 +6,8 @@ const (
 	Consumer     Role = "consumer"
 	Provider     Role = "provider"
 	JobScheduler Role = "job scheduler"
+	// Admin may use the admin APIs of the services, e.g. of the carbon-intensity-provider
+	Admin Role = "admin"
 )
 
 type UserManagement struct {
@@ -16,7 +18,7 @@ type UserManagement struct {
 
 // registerRequest is the request payload for user registration.
 // It contains the role of the user to be registered.
-// The role can be either Consumer, Provider, or JobScheduler.
+// The role can be either Consumer, Provider, JobScheduler, or Admin.
 type RegisterRequest struct {
 	Role Role `json:"role"`
 }
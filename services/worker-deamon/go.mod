@@ -1,3 +1,9 @@
 module worker-daemon
 
-go 1.24.1
\ No newline at end of file
+replace github.com/informatik-mannheim/cmg-ss2025/pkg/imagepolicy => ../../pkg/imagepolicy
+
+go 1.24.1
+
+require github.com/informatik-mannheim/cmg-ss2025/pkg/logging v0.0.0-20250703141304-772beaec9a92
+
+require github.com/informatik-mannheim/cmg-ss2025/pkg/imagepolicy v0.0.0-00010101000000-000000000000
@@ -4,24 +4,44 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 
 	"worker-daemon/internal/ports"
 )
 
+// PollTimeout is how long the gateway holds a NextJobs request open without assignment
+const PollTimeout = 30 * time.Second
+
 type Client struct {
 	BaseURL    string
 	httpClient *http.Client
+	pollClient *http.Client
+	tls        *clientTLS // nil without mutual TLS
 }
 
 func NewClient(baseURL string) *Client {
 	return &Client{
 		BaseURL:    baseURL,
 		httpClient: &http.Client{},
+		pollClient: &http.Client{Timeout: PollTimeout + 10*time.Second},
 	}
 }
 
 func checkStatusOK(resp *http.Response) error {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ports.ErrUnauthorized
+	}
+	// a 4xx is final, except for a timeout or rate limit the next try may get through
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &ports.RejectedError{StatusCode: resp.StatusCode}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return errors.New("http error: status code " + http.StatusText(resp.StatusCode))
 	}
@@ -34,12 +54,23 @@ func (c *Client) Register(key string, zone string) (*ports.RegisterResponse, err
 		"zone": zone,
 	}
 
+	// with mutual TLS a new key is created for every registration, the gateway signs it for the new worker ID
+	var keyPEM []byte
+	if c.tls != nil {
+		csrPEM, newKey, err := c.tls.newCSR()
+		if err != nil {
+			return nil, err
+		}
+		payload["csr"] = string(csrPEM)
+		keyPEM = newKey
+	}
+
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.Post(c.BaseURL+"/register", "application/json", bytes.NewBuffer(data))
+	resp, err := c.httpClient.Post(c.BaseURL+"/register", "application/json", bytes.NewBuffer(data))
 	if err != nil {
 		return nil, err
 	}
@@ -53,14 +84,63 @@ func (c *Client) Register(key string, zone string) (*ports.RegisterResponse, err
 		return nil, err
 	}
 
+	if c.tls != nil {
+		if regResp.Certificate == "" {
+			logging.Warn("Gateway issued no client certificate, continuing without mutual TLS")
+		} else if err := c.tls.store(keyPEM, []byte(regResp.Certificate)); err != nil {
+			return nil, err
+		} else {
+			// connections opened before carry no certificate
+			c.httpClient.CloseIdleConnections()
+		}
+	}
+
 	return regResp, err
 }
 
-func (c *Client) SendHeartbeat(workerId string, status string, token string) ([]ports.Job, error) {
-	payload := map[string]string{
+func (c *Client) RefreshToken(key string, token string) (string, error) {
+	data, err := json.Marshal(map[string]string{"key": key})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+"/worker/token/refresh", bytes.NewBuffer(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatusOK(resp); err != nil {
+		return "", err
+	}
+
+	var refreshResp struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&refreshResp); err != nil {
+		return "", err
+	}
+	return refreshResp.Token, nil
+}
+
+func (c *Client) SendHeartbeat(workerId string, status string, progress *ports.Progress, cachedImages []string, token string) ([]ports.Job, error) {
+	payload := map[string]any{
 		"workerId": workerId,
 		"status":   status,
 	}
+	if cachedImages != nil {
+		payload["cachedImages"] = cachedImages
+	}
+	if progress != nil {
+		payload["progress"] = progress
+	}
 
 	data, err := json.Marshal(payload)
 	if err != nil {
@@ -93,11 +173,15 @@ func (c *Client) SendHeartbeat(workerId string, status string, token string) ([]
 }
 
 func (c *Client) SendResult(j ports.Job, token string) error {
-	payload := map[string]string{
+	payload := map[string]any{
 		"jobId":        j.ID,
 		"status":       j.Status,
 		"result":       j.Result,
 		"errorMessage": j.ErrorMessage,
+		"resultId":     j.ResultID,
+	}
+	if j.Energy != nil {
+		payload["energy"] = j.Energy
 	}
 
 	data, err := json.Marshal(payload)
@@ -120,3 +204,54 @@ func (c *Client) SendResult(j ports.Job, token string) error {
 
 	return checkStatusOK(resp)
 }
+
+func (c *Client) NextJobs(workerId string, token string) ([]ports.Job, error) {
+	query := url.Values{}
+	query.Set("workerId", workerId)
+	query.Set("timeout", strconv.Itoa(int(PollTimeout.Seconds())))
+
+	req, err := http.NewRequest("GET", c.BaseURL+"/worker/jobs/next?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.pollClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// no assignment within the timeout
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if err := checkStatusOK(resp); err != nil {
+		return nil, err
+	}
+
+	var jobs []ports.Job
+	if err := json.NewDecoder(resp.Body).Decode(&jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (c *Client) FetchInputFile(jobID string, name string, token string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", c.BaseURL+"/worker/jobs/"+url.PathEscape(jobID)+"/inputs/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatusOK(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"worker-daemon/internal/ports"
+)
+
+func TestClient_SendResultErrors(t *testing.T) {
+	cases := []struct {
+		status   int
+		rejected bool
+	}{
+		{http.StatusForbidden, true},
+		{http.StatusConflict, true},
+		{http.StatusBadRequest, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+		}))
+		err := NewClient(server.URL).SendResult(ports.Job{ID: "job-1"}, "token")
+		server.Close()
+
+		if err == nil || errors.Is(err, ports.ErrRejected) != c.rejected {
+			t.Errorf("status %d: expected rejected=%v, got %v", c.status, c.rejected, err)
+		}
+	}
+
+	// 401 bleibt ein Token-Fehler, damit der Daemon das Token erneuert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	if err := NewClient(server.URL).SendResult(ports.Job{ID: "job-1"}, "token"); !errors.Is(err, ports.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestClient_FetchInputFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/worker/jobs/job-1/inputs/hello.txt" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	content, err := NewClient(server.URL).FetchInputFile("job-1", "hello.txt", "token")
+	if err != nil {
+		t.Fatalf("expected content, got %v", err)
+	}
+	defer content.Close()
+	if data, _ := io.ReadAll(content); string(data) != "hello" {
+		t.Errorf("expected hello, got %q", data)
+	}
+
+	if _, err := NewClient(server.URL).FetchInputFile("job-1", "other.txt", "token"); !errors.Is(err, ports.ErrRejected) {
+		t.Errorf("expected ErrRejected, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// files in the TLS directory of the daemon
+const (
+	clientKeyFile  = "client.key"
+	clientCertFile = "client.crt"
+)
+
+// clientTLS holds the client certificate for mutual TLS with the gateway. The key is created by the
+// daemon and never leaves the machine, the gateway only signs a CSR for it at registration.
+type clientTLS struct {
+	dir  string
+	mu   sync.Mutex
+	cert *tls.Certificate // nil until the first registration
+}
+
+// EnableMTLS makes the client present the certificate stored in dir on all calls. The gateway
+// certificate is verified with the CA in caFile, or with the system roots if caFile is empty.
+func (c *Client) EnableMTLS(dir string, caFile string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	var roots *x509.CertPool
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caPEM) {
+			return errors.New("no certificate found in " + caFile)
+		}
+	}
+
+	t := &clientTLS{dir: dir}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, clientCertFile), filepath.Join(dir, clientKeyFile))
+	if err == nil {
+		t.cert = &cert
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:              roots,
+		GetClientCertificate: t.clientCertificate,
+		MinVersion:           tls.VersionTLS12,
+	}
+	c.httpClient.Transport = transport
+	c.pollClient.Transport = transport
+	c.tls = t
+	return nil
+}
+
+func (t *clientTLS) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cert == nil {
+		// not registered yet, the gateway accepts the registration without certificate
+		return &tls.Certificate{}, nil
+	}
+	return t.cert, nil
+}
+
+// newCSR creates the key for the next registration and returns a CSR for it.
+// The gateway sets the subject to the worker ID.
+func (t *clientTLS) newCSR() ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return csrPEM, keyPEM, nil
+}
+
+// store saves the certificate issued at registration with its key and uses it for all further calls.
+func (t *clientTLS) store(keyPEM []byte, certPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(t.dir, clientKeyFile), keyPEM, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(t.dir, clientCertFile), certPEM, 0o644); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.cert = &cert
+	t.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+)
+
+func init() {
+	logging.Init("worker-daemon-test")
+}
+
+// testCA simuliert die CA des Gateways
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) sign(t *testing.T, csrPEM string, workerID string) string {
+	t.Helper()
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil {
+		t.Fatal("no CSR sent")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: workerID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestMutualTLS_RegisterAndHeartbeat(t *testing.T) {
+	ca := newTestCA(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]string{
+			"id":          "worker1",
+			"token":       "token",
+			"certificate": ca.sign(t, req["csr"], "worker1"),
+		})
+	})
+	mux.HandleFunc("/worker/heartbeat", func(w http.ResponseWriter, r *http.Request) {
+		// Gateway ordnet das Zertifikat dem Worker zu
+		if len(r.TLS.VerifiedChains) == 0 || r.TLS.VerifiedChains[0][0].Subject.CommonName != "worker1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+
+	server := httptest.NewUnstartedServer(mux)
+	server.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	// CA des Server Zertifikats, wie sie mit dem Gateway verteilt wird
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "gateway-ca.crt")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o644)
+	tlsDir := filepath.Join(dir, "tls")
+
+	client := NewClient(server.URL)
+	if err := client.EnableMTLS(tlsDir, caFile); err != nil {
+		t.Fatalf("EnableMTLS failed: %v", err)
+	}
+
+	// ohne Zertifikat wird der Heartbeat abgelehnt
+	if _, err := client.SendHeartbeat("worker1", "AVAILABLE", nil, nil, "token"); err == nil {
+		t.Error("Expected heartbeat without certificate to fail")
+	}
+
+	if _, err := client.Register("key", "DE"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if _, err := client.SendHeartbeat("worker1", "AVAILABLE", nil, nil, "token"); err != nil {
+		t.Errorf("Expected heartbeat with certificate to succeed, got %v", err)
+	}
+
+	// nach einem Neustart wird das gespeicherte Zertifikat verwendet
+	restarted := NewClient(server.URL)
+	if err := restarted.EnableMTLS(tlsDir, caFile); err != nil {
+		t.Fatalf("EnableMTLS failed: %v", err)
+	}
+	if _, err := restarted.SendHeartbeat("worker1", "AVAILABLE", nil, nil, "token"); err != nil {
+		t.Errorf("Expected stored certificate to be used after restart, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"worker-daemon/internal/ports"
+)
+
+func TestFileJournal_SaveAndLoad(t *testing.T) {
+	j := NewFileJournal(filepath.Join(t.TempDir(), "state", "journal.json"))
+
+	// Fehlende Datei bedeutet erster Start
+	state, err := j.Load()
+	if err != nil || state.WorkerID != "" {
+		t.Fatalf("expected empty state, got %+v (%v)", state, err)
+	}
+
+	saved := ports.JournalState{
+		WorkerID:    "worker-1",
+		Token:       "token-1",
+		Zone:        "DE",
+		CurrentJob:  &ports.Job{ID: "job-1"},
+		ContainerID: "abc123",
+	}
+	if err := j.Save(saved); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	state, err = j.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if state.WorkerID != "worker-1" || state.CurrentJob == nil || state.CurrentJob.ID != "job-1" || state.ContainerID != "abc123" {
+		t.Errorf("unexpected state %+v", state)
+	}
+}
@@ -0,0 +1,27 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"worker-daemon/internal/ports"
+)
+
+// NewHandler serves /healthz and /metrics for local monitoring of the daemon.
+// /healthz answers 503 while the daemon is not registered or has lost contact to the gateway.
+func NewHandler(source ports.StatusSource) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		health := source.Health()
+		w.Header().Set("Content-Type", "application/json")
+		if health.Status != ports.HealthOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(health)
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		source.WriteMetrics(w)
+	})
+	return mux
+}
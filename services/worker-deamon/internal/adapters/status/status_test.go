@@ -0,0 +1,52 @@
+package status
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"worker-daemon/internal/ports"
+)
+
+// dummySource liefert einen festen Zustand
+type dummySource struct {
+	health ports.Health
+}
+
+func (s dummySource) Health() ports.Health {
+	return s.health
+}
+
+func (s dummySource) WriteMetrics(w io.Writer) error {
+	_, err := io.WriteString(w, "worker_daemon_job_running 0\n")
+	return err
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		health   ports.Health
+		expected int
+		body     string
+	}{
+		{"healthy", "/healthz", ports.Health{Status: ports.HealthOK, WorkerID: "worker1"}, http.StatusOK, `"workerId":"worker1"`},
+		{"unavailable", "/healthz", ports.Health{Status: ports.HealthUnavailable, Reason: "not registered"}, http.StatusServiceUnavailable, `"reason":"not registered"`},
+		{"metrics", "/metrics", ports.Health{}, http.StatusOK, "worker_daemon_job_running 0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			NewHandler(dummySource{health: tc.health}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rr.Code != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tc.body) {
+				t.Errorf("expected body to contain %s, got %s", tc.body, rr.Body.String())
+			}
+		})
+	}
+}
@@ -2,26 +2,198 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
+// DefaultPath is the config file used if neither -config nor WORKER_CONFIG is set
+const DefaultPath = "config.json"
+
+// Every setting can be given in the config file, as environment variable and as flag.
+// The environment variable is the json name in upper case with the prefix WORKER_, e.g.
+// WORKER_GATEWAY_URL, the flag is the json name with dashes, e.g. -gateway-url.
 type Config struct {
-	GatewayURL               string `json:"gateway_url"`
-	Secret                   string `json:"secret"`
-	Zone                     string `json:"zone"`
-	HeartbeatIntervalSeconds int    `json:"heartbeat_interval_seconds"`
+	GatewayURL               string  `json:"gateway_url"`
+	Secret                   string  `json:"secret"`
+	Zone                     string  `json:"zone"`
+	HeartbeatIntervalSeconds int     `json:"heartbeat_interval_seconds"`
+	CacheDir                 string  `json:"cache_dir"`
+	JournalPath              string  `json:"journal_path"`
+	CPUWattsPerCore          float64 `json:"cpu_watts_per_core"`
+	MemoryWattsPerGB         float64 `json:"memory_watts_per_gb"`
+	TLSDir                   string  `json:"tls_dir"`           // enables mutual TLS, holds the client key and certificate
+	CAFile                   string  `json:"ca_file"`           // CA of the gateway certificate, the system roots if empty
+	CPULimit                 float64 `json:"cpu_limit"`         // CPUs a job container may use, 0 is unlimited
+	MemoryLimitMB            int     `json:"memory_limit_mb"`   // memory a job container may use, 0 is unlimited
+	StatusAddr               string  `json:"status_addr"`       // address of the /healthz and /metrics endpoint, disabled if empty
+	ImagePolicyFile          string  `json:"image_policy_file"` // restricts the images jobs may run, all images if empty
 }
 
-func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
+// Default returns the configuration used for all settings that are not given.
+func Default() Config {
+	return Config{
+		HeartbeatIntervalSeconds: 10,
+		CacheDir:                 filepath.Join(os.TempDir(), "worker-daemon-cache"),
+		JournalPath:              "journal.json",
+	}
+}
+
+// Load builds the configuration from the defaults, the config file, environment variables and
+// the command line flags in args. Later sources override earlier ones. A missing config file is
+// only an error if its path was given explicitly.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	flags := flag.NewFlagSet("worker-daemon", flag.ContinueOnError)
+	path := flags.String("config", "", "path of the config file (env WORKER_CONFIG, default "+DefaultPath+")")
+	values := make(map[string]*string)
+	for _, field := range fields(&cfg) {
+		values[field.name] = flags.String(flagName(field.name), "", field.name)
+	}
+	if err := flags.Parse(args); err != nil {
 		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	configPath := *path
+	if configPath == "" {
+		configPath = os.Getenv("WORKER_CONFIG")
+	}
+	explicit := configPath != ""
+	if !explicit {
+		configPath = DefaultPath
+	}
+	data, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+	case explicit || !errors.Is(err, os.ErrNotExist):
 		return nil, err
 	}
 
+	for _, field := range fields(&cfg) {
+		value, ok := os.LookupEnv(envName(field.name))
+		if !ok {
+			continue
+		}
+		if err := field.set(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", envName(field.name), err)
+		}
+	}
+
+	var flagErr error
+	fieldsByName := make(map[string]field)
+	for _, field := range fields(&cfg) {
+		fieldsByName[flagName(field.name)] = field
+	}
+	flags.Visit(func(f *flag.Flag) {
+		field, ok := fieldsByName[f.Name]
+		if !ok {
+			return
+		}
+		if err := field.set(*values[field.name]); err != nil {
+			flagErr = errors.Join(flagErr, fmt.Errorf("-%s: %w", f.Name, err))
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
+
+// Validate reports all invalid settings at once.
+func (c Config) Validate() error {
+	var errs []error
+	if u, err := url.Parse(c.GatewayURL); c.GatewayURL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("gateway_url must be an http or https URL, got %q", c.GatewayURL))
+	} else if c.TLSDir != "" && u.Scheme != "https" {
+		errs = append(errs, errors.New("gateway_url must be https with tls_dir"))
+	}
+	if c.Secret == "" {
+		errs = append(errs, errors.New("secret is required"))
+	}
+	if c.Zone == "" {
+		errs = append(errs, errors.New("zone is required"))
+	}
+	if c.HeartbeatIntervalSeconds < 1 {
+		errs = append(errs, fmt.Errorf("heartbeat_interval_seconds must be at least 1, got %d", c.HeartbeatIntervalSeconds))
+	}
+	if c.CacheDir == "" {
+		errs = append(errs, errors.New("cache_dir must not be empty"))
+	}
+	if c.JournalPath == "" {
+		errs = append(errs, errors.New("journal_path must not be empty"))
+	}
+	if c.CPUWattsPerCore < 0 || c.MemoryWattsPerGB < 0 {
+		errs = append(errs, errors.New("cpu_watts_per_core and memory_watts_per_gb must not be negative"))
+	}
+	if c.CPULimit < 0 || c.MemoryLimitMB < 0 {
+		errs = append(errs, errors.New("cpu_limit and memory_limit_mb must not be negative"))
+	}
+	if c.MemoryLimitMB > 0 && c.MemoryLimitMB < 6 {
+		// docker rejects smaller limits
+		errs = append(errs, fmt.Errorf("memory_limit_mb must be at least 6, got %d", c.MemoryLimitMB))
+	}
+	if c.CAFile != "" && c.TLSDir == "" {
+		errs = append(errs, errors.New("ca_file requires tls_dir"))
+	}
+	return errors.Join(errs...)
+}
+
+// field is a setting of the config, named by its json tag
+type field struct {
+	name  string
+	value reflect.Value
+}
+
+func fields(cfg *Config) []field {
+	v := reflect.ValueOf(cfg).Elem()
+	var result []field
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		result = append(result, field{name: name, value: v.Field(i)})
+	}
+	return result
+}
+
+func (f field) set(s string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported setting %s", f.name)
+	}
+	return nil
+}
+
+func envName(name string) string {
+	return "WORKER_" + strings.ToUpper(name)
+}
+
+func flagName(name string) string {
+	return strings.ReplaceAll(name, "_", "-")
+}
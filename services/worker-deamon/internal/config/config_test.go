@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	path := writeConfig(t, `{"gateway_url": "http://file:8080", "secret": "file", "zone": "DE", "heartbeat_interval_seconds": 5}`)
+	t.Setenv("WORKER_SECRET", "env")
+	t.Setenv("WORKER_HEARTBEAT_INTERVAL_SECONDS", "7")
+
+	cfg, err := Load([]string{"-config", path, "-heartbeat-interval-seconds", "3"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Datei < Umgebungsvariablen < Flags, Defaults für alles andere
+	if cfg.GatewayURL != "http://file:8080" || cfg.Secret != "env" || cfg.HeartbeatIntervalSeconds != 3 {
+		t.Errorf("Unexpected layering: %+v", cfg)
+	}
+	if cfg.JournalPath != "journal.json" || cfg.CacheDir == "" {
+		t.Errorf("Expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad_WithoutFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("WORKER_GATEWAY_URL", "http://gateway:8080")
+	t.Setenv("WORKER_SECRET", "secret")
+	t.Setenv("WORKER_ZONE", "DE")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Expected config from env only, got %v", err)
+	}
+	if cfg.HeartbeatIntervalSeconds != 10 {
+		t.Errorf("Expected default heartbeat interval, got %d", cfg.HeartbeatIntervalSeconds)
+	}
+
+	// ausdrücklich angegebene Datei muss existieren
+	if _, err := Load([]string{"-config", "missing.json"}); err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestLoad_Validation(t *testing.T) {
+	path := writeConfig(t, `{"gateway_url": "gateway:8080", "zone": "DE", "heartbeat_interval_seconds": 0, "memory_limit_mb": -1}`)
+
+	_, err := Load([]string{"-config", path})
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, expected := range []string{"gateway_url", "secret", "heartbeat_interval_seconds", "memory_limit_mb"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error about %s, got %v", expected, err)
+		}
+	}
+
+	if _, err := Load([]string{"-config", path, "-cpu-limit", "many"}); err == nil {
+		t.Error("Expected error for invalid flag value")
+	}
+}
@@ -3,49 +3,143 @@ package core
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
 	"worker-daemon/internal/config"
 	"worker-daemon/internal/ports"
 )
 
+// final job states reported by the daemon
+const (
+	JobStatusDone  = "DONE"
+	JobStatusError = "ERROR"
+)
+
 type Daemon struct {
-	cfg          config.Config
+	cfg          config.Config // guarded by cfgMu, the reloadable settings change on Reload
+	cfgMu        sync.Mutex
+	reloaded     chan struct{} // signals the heartbeat loop that the interval may have changed
 	api          ports.WorkerGateway
+	journal      ports.Journal
 	workerID     string
-	token        string
+	token        string // guarded by tokenMu, replaced on refresh
+	tokenMu      sync.Mutex
+	refreshMu    sync.Mutex // serializes token refreshes, tokenMu is not held during the call
 	currentJobID string
+	stager       *InputStager
+	progress     ProgressTracker
+	meter        *energyMeter
+	imagePolicy  *ImagePolicy // guarded by cfgMu, nil allows all images
+
+	journalMu sync.Mutex
+	state     ports.JournalState
+
+	metrics metrics
+	images  imageCache
+
+	jobFinished  chan struct{} // signals pollJobs that the worker is available again
+	pollRetry    time.Duration // delay before polling again after an error
+	outboxWake   chan struct{} // signals deliverResults that a result was queued
+	retryInitial time.Duration // first delay after a failed delivery, doubled up to retryMax
+	retryMax     time.Duration
 }
 
-func NewDaemon(cfg config.Config, api ports.WorkerGateway) *Daemon {
-	return &Daemon{cfg: cfg, api: api}
+func NewDaemon(cfg config.Config, api ports.WorkerGateway, journal ports.Journal) *Daemon {
+	cacheDir := cfg.CacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "worker-daemon-cache")
+	}
+	d := &Daemon{
+		cfg:          cfg,
+		api:          api,
+		journal:      journal,
+		jobFinished:  make(chan struct{}, 1),
+		reloaded:     make(chan struct{}, 1),
+		pollRetry:    max(time.Duration(cfg.HeartbeatIntervalSeconds)*time.Second, time.Second),
+		outboxWake:   make(chan struct{}, 1),
+		retryInitial: time.Second,
+		retryMax:     5 * time.Minute,
+		meter:        newEnergyMeter(PowerModel{CPUWattsPerCore: cfg.CPUWattsPerCore, MemoryWattsPerGB: cfg.MemoryWattsPerGB}),
+	}
+	d.stager = NewInputStager(cacheDir, d.fetchInputFile)
+	return d
 }
 
 func (d *Daemon) StartHeartbeatLoop(ctx context.Context) {
-	w, err := d.api.Register(d.cfg.Secret, d.cfg.Zone)
-	if err != nil {
-		fmt.Println("Registration failed:", err)
-		return
+	if d.restoreIdentity() {
+		logging.Debug("Worker identity restored from journal", "worker_id", d.workerID)
+	} else {
+		d.leaveZone(ctx)
+		if ctx.Err() != nil {
+			// the journal keeps the old identity, the next start finishes its jobs
+			return
+		}
+		w, err := d.api.Register(d.secret(), d.cfg.Zone)
+		if err != nil {
+			logging.Error("Registration failed", "error", err)
+			return
+		}
+		d.metrics.contact()
+		d.workerID = w.ID
+		d.token = w.Token
+		d.updateJournal(func(state *ports.JournalState) {
+			state.WorkerID = w.ID
+			state.Token = w.Token
+			state.Zone = d.cfg.Zone
+		})
+
+		logging.Debug("Worker registered successfully", "worker_id", w.ID)
 	}
-	d.workerID = w.ID
-	d.token = w.Token
 
-	fmt.Println("Worker registered successfully.", w)
+	d.refreshImages()
 
-	ticker := time.NewTicker(time.Duration(d.cfg.HeartbeatIntervalSeconds) * time.Second)
+	ticker := time.NewTicker(d.heartbeatInterval())
 	defer ticker.Stop()
 
 	var processing int32 // 0 = not processing, 1 = processing
 
+	// Job aus dem Journal, der vor dem Neustart lief
+	if d.state.CurrentJob != nil {
+		atomic.StoreInt32(&processing, 1)
+		go d.recoverJob(*d.state.CurrentJob, d.state.ContainerID, &processing)
+	}
+
+	// Ergebnisse werden unabhängig vom Heartbeat zugestellt, damit ein Fehler den Worker nicht blockiert.
+	// Der Loop kehrt erst zurück, wenn Zustellung und Long Poll beendet sind.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d.deliverResults(ctx)
+	}()
+	// Jobs kommen per Long Poll, nicht mehr mit dem Heartbeat
+	go func() {
+		defer wg.Done()
+		d.pollJobs(ctx, &processing)
+	}()
+
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Println("Heartbeat loop stopped.")
+			logging.Debug("Heartbeat loop stopped")
 			return
 
+		case <-d.reloaded:
+			ticker.Reset(d.heartbeatInterval())
+
 		case <-ticker.C:
 			var status string
 			if atomic.LoadInt32(&processing) == 1 {
@@ -54,45 +148,279 @@ func (d *Daemon) StartHeartbeatLoop(ctx context.Context) {
 				status = "AVAILABLE"
 			}
 
-			jobs, err := d.api.SendHeartbeat(d.workerID, status, d.token)
+			d.refreshIfExpiring()
+			err := d.withToken(func(token string) error {
+				_, err := d.api.SendHeartbeat(d.workerID, status, d.progress.Current(), d.cachedImages(), token)
+				return err
+			})
+			d.metrics.heartbeat(err)
 			if err != nil {
-				fmt.Println("Heartbeat failed:", err)
-				continue
+				logging.Warn("Heartbeat failed", "error", err)
 			}
-			fmt.Println("Heartbeat jobs:", jobs)
-
-			// Wenn kein Job verarbeitet wird und neue Jobs reinkommen:
-			if len(jobs) > 0 && atomic.CompareAndSwapInt32(&processing, 0, 1) {
-				job := jobs[0]
-				// Starte die Jobverarbeitung in separater Goroutine
-				go func(j ports.Job) {
-					d.currentJobID = j.ID
-					processedJob := computeJob(j)
-					err := d.api.SendResult(processedJob, d.token)
-					if err != nil {
-						fmt.Println("SendResult failed:", err)
-					} else {
-						atomic.StoreInt32(&processing, 0)
-						d.currentJobID = ""
-						status = "AVAILABLE"
-					}
-
-				}(job)
-			} else {
-				if len(jobs) < 1 {
-					fmt.Println("No Jobs scheduled.", jobs, status, d.currentJobID)
-				}
+		}
+	}
+}
+
+// pollJobs waits for job assignments while the worker is available and starts them.
+// The gateway holds each request open until a job is assigned, so jobs start without waiting for a heartbeat.
+func (d *Daemon) pollJobs(ctx context.Context, processing *int32) {
+	for ctx.Err() == nil {
+		if atomic.LoadInt32(processing) == 1 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.jobFinished:
+			}
+			continue
+		}
+
+		var jobs []ports.Job
+		err := d.withToken(func(token string) error {
+			var err error
+			jobs, err = d.api.NextJobs(d.workerID, token)
+			return err
+		})
+		if err != nil {
+			logging.Warn("Polling jobs failed", "error", err)
+			d.sleep(ctx, d.pollRetry)
+			continue
+		}
+
+		job, ok := d.firstNewJob(jobs)
+		if !ok {
+			if len(jobs) > 0 {
+				// only jobs whose results are still being delivered, they stay scheduled until then
+				d.sleep(ctx, d.pollRetry)
 			}
+			continue
+		}
+
+		if atomic.CompareAndSwapInt32(processing, 0, 1) {
+			go d.startJob(job, processing)
+		}
+	}
+}
+
+// firstNewJob returns the first job that is not running or waiting in the outbox.
+func (d *Daemon) firstNewJob(jobs []ports.Job) (ports.Job, bool) {
+	d.journalMu.Lock()
+	defer d.journalMu.Unlock()
+
+	for _, job := range jobs {
+		known := d.state.CurrentJob != nil && d.state.CurrentJob.ID == job.ID
+		for _, pending := range d.state.Outbox {
+			known = known || pending.ID == job.ID
 		}
+		if !known {
+			return job, true
+		}
+	}
+	return ports.Job{}, false
+}
+
+// startJob journals the job, runs it and moves its result to the outbox.
+func (d *Daemon) startJob(job ports.Job, processing *int32) {
+	d.currentJobID = job.ID
+	d.updateJournal(func(state *ports.JournalState) {
+		state.CurrentJob = &job
+		state.ContainerID = ""
+	})
+	processedJob := d.runJob(job)
+	d.finishJob(processedJob, processing)
+}
+
+// sleep waits for the duration or until ctx is done.
+func (d *Daemon) sleep(ctx context.Context, duration time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
 	}
 }
 
-func computeJob(job ports.Job) ports.Job {
-	imageRef := job.Image.Name
-	if job.Image.Version != "" {
-		imageRef += ":" + job.Image.Version
+// restoreIdentity loads the journal and reuses the worker ID and token of the last run.
+// A changed zone requires a new registration, see leaveZone for the jobs of the old identity.
+func (d *Daemon) restoreIdentity() bool {
+	state, err := d.journal.Load()
+	if err != nil {
+		logging.Error("Reading journal failed", "error", err)
+		return false
+	}
+	d.journalMu.Lock()
+	d.state = state
+	d.journalMu.Unlock()
+	if state.WorkerID == "" || state.Zone != d.cfg.Zone {
+		return false
+	}
+
+	d.workerID = state.WorkerID
+	d.token = state.Token
+	return true
+}
+
+// leaveZone finishes the work of the identity journaled for another zone before the worker registers again.
+// The gateway only accepts results from the worker a job was assigned to, so the job that was running
+// is recovered and all pending results are delivered with the old worker ID and token.
+// Results that are rejected or still fail after retrying up to retryMax are parked with the rejected ones,
+// the new identity could not deliver them.
+// If ctx is done first, the worker does not register again.
+func (d *Daemon) leaveZone(ctx context.Context) {
+	d.journalMu.Lock()
+	state := d.state
+	d.journalMu.Unlock()
+	if state.WorkerID == "" {
+		return
+	}
+
+	logging.Debug("Zone changed, finishing jobs of the old identity", "worker_id", state.WorkerID, "zone", state.Zone)
+	d.workerID = state.WorkerID
+	d.token = state.Token
+	if state.CurrentJob != nil {
+		job := recoverContainer(*state.CurrentJob, state.ContainerID)
+		d.metrics.jobFinished(job.Status)
+		d.enqueueResult(job)
 	}
 
+	delay := d.retryInitial
+	for ctx.Err() == nil {
+		job, ok := d.nextResult()
+		if !ok {
+			return
+		}
+		err := d.withToken(func(token string) error {
+			return d.api.SendResult(job, token)
+		})
+		switch {
+		case err == nil:
+			logging.Debug("Result delivered", "job_id", job.ID, "result_id", job.ResultID)
+			d.removeResult(job.ResultID)
+			delay = d.retryInitial
+		case errors.Is(err, ports.ErrRejected) || delay > d.retryMax:
+			logging.Warn("Result of the old zone not delivered, parking it", "job_id", job.ID, "result_id", job.ResultID, "error", err)
+			d.metrics.resultsRejected.Add(1)
+			d.rejectResult(job.ResultID)
+			delay = d.retryInitial
+		default:
+			logging.Warn("SendResult failed, retrying", "job_id", job.ID, "delay", delay, "error", err)
+			d.sleep(ctx, delay)
+			delay *= 2
+		}
+	}
+}
+
+// updateJournal applies change to the journaled state and persists it.
+func (d *Daemon) updateJournal(change func(state *ports.JournalState)) {
+	d.journalMu.Lock()
+	defer d.journalMu.Unlock()
+
+	change(&d.state)
+	if err := d.journal.Save(d.state); err != nil {
+		logging.Error("Writing journal failed", "error", err)
+	}
+}
+
+// finishJob moves the result of a job to the outbox and frees the worker for the next job.
+// Delivering the result is up to deliverResults.
+func (d *Daemon) finishJob(job ports.Job, processing *int32) {
+	d.metrics.jobFinished(job.Status)
+	d.enqueueResult(job)
+	atomic.StoreInt32(processing, 0)
+	d.currentJobID = ""
+
+	select {
+	case d.jobFinished <- struct{}{}:
+	default:
+	}
+}
+
+// recoverJob reattaches to the container of a job that was running before the restart.
+func (d *Daemon) recoverJob(job ports.Job, containerID string, processing *int32) {
+	d.currentJobID = job.ID
+	logging.Debug("Reattaching to job after restart", "job_id", job.ID, "container_id", containerID)
+	d.finishJob(recoverContainer(job, containerID), processing)
+}
+
+// jobEnv describes what the daemon provides to a job container.
+type jobEnv struct {
+	inputDir    string                            // mounted read-only at InputMountPath
+	progressDir string                            // mounted at ProgressMountPath, the container may write its progress file there
+	onProgress  func(percent int, message string) // called for progress markers on stdout
+	meter       *energyMeter                      // measures the energy of the container, may be nil
+	onStart     func(containerID string)          // called once the container is created
+
+	cpuLimit      float64 // docker --cpus, 0 is unlimited
+	memoryLimitMB int     // docker --memory, 0 is unlimited
+}
+
+// fetchInputFile downloads an uploaded input file of a job through the gateway
+func (d *Daemon) fetchInputFile(jobID string, name string) (io.ReadCloser, error) {
+	var content io.ReadCloser
+	err := d.withToken(func(token string) error {
+		var err error
+		content, err = d.api.FetchInputFile(jobID, name, token)
+		return err
+	})
+	return content, err
+}
+
+// runJob stages the input files of the job, computes it and tracks its progress.
+func (d *Daemon) runJob(job ports.Job) ports.Job {
+	if policy := d.currentImagePolicy(); policy != nil {
+		if err := policy.Check(job.Image); err != nil {
+			job.Status = JobStatusError
+			job.Result = ""
+			job.ErrorMessage = "image rejected: " + err.Error()
+			return job
+		}
+	}
+
+	if err := d.pullImage(job.Image); err != nil {
+		job.Status = JobStatusError
+		job.Result = ""
+		job.ErrorMessage = err.Error()
+		return job
+	}
+
+	inputDir, err := d.stager.Stage(job)
+	if err != nil {
+		job.Status = JobStatusError
+		job.Result = ""
+		job.ErrorMessage = "staging input files failed: " + err.Error()
+		return job
+	}
+	defer d.stager.Cleanup(inputDir)
+
+	cpuLimit, memoryLimitMB, meter := d.jobSettings()
+	env := jobEnv{inputDir: inputDir, onProgress: d.progress.Update, meter: meter, cpuLimit: cpuLimit, memoryLimitMB: memoryLimitMB}
+	env.onStart = func(containerID string) {
+		d.updateJournal(func(state *ports.JournalState) {
+			state.ContainerID = containerID
+		})
+	}
+	progressFile := ""
+	os.MkdirAll(d.stager.cacheDir, 0o755)
+	if dir, err := os.MkdirTemp(d.stager.cacheDir, "progress-"); err == nil {
+		// the container may run as any user
+		os.Chmod(dir, 0o777)
+		defer os.RemoveAll(dir)
+		env.progressDir = dir
+		progressFile = filepath.Join(dir, progressFileName)
+	} else {
+		logging.Warn("Creating progress directory failed", "error", err)
+	}
+
+	d.progress.Start(job.ID, progressFile)
+	defer d.progress.Stop()
+
+	start := time.Now()
+	result := computeJob(job, env)
+	d.metrics.jobDuration(time.Since(start))
+	if result.Status == JobStatusError {
+		d.metrics.containerFailures.Add(1)
+	}
+	return result
+}
+
+func computeJob(job ports.Job, env jobEnv) ports.Job {
 	// Map in []string konvertieren
 	args := []string{}
 	for k, v := range job.AdjustmentParameters {
@@ -102,13 +430,14 @@ func computeJob(job ports.Job) ports.Job {
 		}
 	}
 
-	output, err := runImage(imageRef, args)
+	output, energy, err := runImage(imageRef(job.Image), args, env)
+	job.Energy = energy
 	if err != nil {
-		job.Status = "ERROR"
+		job.Status = JobStatusError
 		job.Result = ""
 		job.ErrorMessage = err.Error()
 	} else {
-		job.Status = "DONE"
+		job.Status = JobStatusDone
 		job.Result = output
 		job.ErrorMessage = ""
 	}
@@ -116,18 +445,76 @@ func computeJob(job ports.Job) ports.Job {
 	return job
 }
 
-func runImage(image string, args []string) (string, error) {
-	allArgs := append([]string{"run", "--rm", image}, args...)
-	cmd := exec.Command("docker", allArgs...)
+// runImage creates the container first, so its ID can be journaled before it starts,
+// and removes it after its output was collected.
+func runImage(image string, args []string, env jobEnv) (string, *ports.EnergyReport, error) {
+	createArgs := []string{"create"}
+	if env.cpuLimit > 0 {
+		createArgs = append(createArgs, "--cpus", strconv.FormatFloat(env.cpuLimit, 'f', -1, 64))
+	}
+	if env.memoryLimitMB > 0 {
+		createArgs = append(createArgs, "--memory", strconv.Itoa(env.memoryLimitMB)+"m")
+	}
+	if env.inputDir != "" {
+		createArgs = append(createArgs, "-v", env.inputDir+":"+InputMountPath+":ro")
+	}
+	if env.progressDir != "" {
+		createArgs = append(createArgs,
+			"-v", env.progressDir+":"+ProgressMountPath,
+			"-e", ProgressFileEnv+"="+ProgressMountPath+"/"+progressFileName)
+	}
+	createArgs = append(createArgs, image)
+	createArgs = append(createArgs, args...)
+
+	var createErr bytes.Buffer
+	create := exec.Command("docker", createArgs...)
+	create.Stderr = &createErr
+	out, err := create.Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("run image failed: %v - %s", err, createErr.String())
+	}
+	containerID := strings.TrimSpace(string(out))
+	defer removeContainer(containerID)
+
+	if env.onStart != nil {
+		env.onStart(containerID)
+	}
+
+	cmd := exec.Command("docker", "start", "--attach", containerID)
 
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	progressOut := &progressWriter{out: &stdout, onProgress: env.onProgress}
+	cmd.Stdout = progressOut
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	if err := cmd.Start(); err != nil {
+		return "", nil, fmt.Errorf("run image failed: %v", err)
+	}
+
+	var energy *ports.EnergyReport
+	measured := make(chan ports.EnergyReport, 1)
+	done := make(chan struct{})
+	if env.meter != nil {
+		go func() { measured <- env.meter.measure(containerID, done) }()
+	}
+
+	err = cmd.Wait()
+	close(done)
+	if env.meter != nil {
+		report := <-measured
+		energy = &report
+	}
+
+	progressOut.Flush()
 	if err != nil {
-		return "", fmt.Errorf("run image failed: %v - %s", err, stderr.String())
+		return "", energy, fmt.Errorf("run image failed: %v - %s", err, stderr.String())
 	}
 
-	return stdout.String(), nil
+	return stdout.String(), energy, nil
+}
+
+func removeContainer(containerID string) {
+	if err := exec.Command("docker", "rm", "--force", containerID).Run(); err != nil {
+		logging.Warn("Removing container failed", "container_id", containerID, "error", err)
+	}
 }
@@ -4,29 +4,101 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
 	"worker-daemon/internal/config"
 	"worker-daemon/internal/ports"
 )
 
-// DummyWorkerGateway simuliert das API-Interface
+func init() {
+	logging.Init("worker-daemon-test")
+}
+
+// DummyWorkerGateway simuliert das API-Interface. Die Aufrufe laufen in den Goroutinen des Daemons,
+// Tests lesen sie daher mit Calls.
 type DummyWorkerGateway struct {
+	mu sync.Mutex
+
 	RegisterCalled      bool
 	SendHeartbeatCalled bool
 	SendResultCalled    bool
 
 	// Simuliere Fehler
-	RegisterErr      error
-	SendHeartbeatErr error
-	SendResultErr    error
+	RegisterErr        error
+	SendHeartbeatErr   error
+	SendResultErr      error
+	SendResultFailures int            // Anzahl der ersten SendResult-Aufrufe, die fehlschlagen
+	RejectedJobs       map[string]int // Job-ID -> Statuscode, mit dem das Gateway das Ergebnis ablehnt
 
-	JobsToReturn []ports.Job
+	LastStatus string
+
+	JobsToReturn []ports.Job // werden einmal von NextJobs zurückgegeben
 	ReceivedJobs []ports.Job
+	ResultTokens []string // Token, mit dem das jeweilige Ergebnis in ReceivedJobs gesendet wurde
+
+	NextJobsCalled bool
+
+	ValidToken     string // wenn gesetzt, werden andere Tokens mit ErrUnauthorized abgelehnt
+	RefreshedToken string // Token, das RefreshToken liefert
+	RefreshCalls   int
+	RefreshDelay   time.Duration // Dauer eines RefreshToken-Aufrufs
+
+	InputFiles map[string]string // Name -> Inhalt der hochgeladenen Eingabedateien
+}
+
+// GatewayCalls ist eine Kopie der bisherigen Aufrufe des DummyWorkerGateway
+type GatewayCalls struct {
+	RegisterCalled      bool
+	SendHeartbeatCalled bool
+	SendResultCalled    bool
+	NextJobsCalled      bool
+	LastStatus          string
+	ReceivedJobs        []ports.Job
+	ResultTokens        []string
+	RefreshCalls        int
+}
+
+// Calls liefert die bisherigen Aufrufe, auch während der Daemon läuft
+func (d *DummyWorkerGateway) Calls() GatewayCalls {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return GatewayCalls{
+		RegisterCalled:      d.RegisterCalled,
+		SendHeartbeatCalled: d.SendHeartbeatCalled,
+		SendResultCalled:    d.SendResultCalled,
+		NextJobsCalled:      d.NextJobsCalled,
+		LastStatus:          d.LastStatus,
+		ReceivedJobs:        slices.Clone(d.ReceivedJobs),
+		ResultTokens:        slices.Clone(d.ResultTokens),
+		RefreshCalls:        d.RefreshCalls,
+	}
+}
+
+func (d *DummyWorkerGateway) RefreshToken(key, token string) (string, error) {
+	time.Sleep(d.RefreshDelay)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.RefreshCalls++
+	return d.RefreshedToken, nil
+}
+
+func (d *DummyWorkerGateway) checkToken(token string) error {
+	if d.ValidToken != "" && token != d.ValidToken {
+		return ports.ErrUnauthorized
+	}
+	return nil
 }
 
 func (d *DummyWorkerGateway) Register(key, zone string) (*ports.RegisterResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.RegisterCalled = true
 	if d.RegisterErr != nil {
 		return &ports.RegisterResponse{}, d.RegisterErr
@@ -37,8 +109,14 @@ func (d *DummyWorkerGateway) Register(key, zone string) (*ports.RegisterResponse
 	}, nil
 }
 
-func (d *DummyWorkerGateway) SendHeartbeat(workerID, status, token string) ([]ports.Job, error) {
+func (d *DummyWorkerGateway) SendHeartbeat(workerID, status string, progress *ports.Progress, cachedImages []string, token string) ([]ports.Job, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.SendHeartbeatCalled = true
+	d.LastStatus = status
+	if err := d.checkToken(token); err != nil {
+		return nil, err
+	}
 	if d.SendHeartbeatErr != nil {
 		return nil, d.SendHeartbeatErr
 	}
@@ -46,11 +124,89 @@ func (d *DummyWorkerGateway) SendHeartbeat(workerID, status, token string) ([]po
 }
 
 func (d *DummyWorkerGateway) SendResult(job ports.Job, token string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.SendResultCalled = true
+	if err := d.checkToken(token); err != nil {
+		return err
+	}
 	d.ReceivedJobs = append(d.ReceivedJobs, job)
+	d.ResultTokens = append(d.ResultTokens, token)
+	if d.SendResultFailures > 0 {
+		d.SendResultFailures--
+		return errors.New("gateway unavailable")
+	}
+	if status, ok := d.RejectedJobs[job.ID]; ok {
+		return &ports.RejectedError{StatusCode: status}
+	}
 	return d.SendResultErr
 }
 
+// DummyJournal hält den Zustand im Speicher. State ist der Anfangszustand, während der Daemon
+// läuft wird er mit Current gelesen.
+type DummyJournal struct {
+	mu    sync.Mutex
+	State ports.JournalState
+	Saves int
+}
+
+func (j *DummyJournal) Load() (ports.JournalState, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.State, nil
+}
+
+func (j *DummyJournal) Save(state ports.JournalState) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.State = state
+	j.Saves++
+	return nil
+}
+
+// Current liefert den zuletzt gespeicherten Zustand, auch während der Daemon läuft
+func (j *DummyJournal) Current() ports.JournalState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.State
+}
+
+func (d *DummyWorkerGateway) NextJobs(workerID, token string) ([]ports.Job, error) {
+	// simuliert das Warten des Gateways
+	time.Sleep(10 * time.Millisecond)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.NextJobsCalled = true
+	jobs := d.JobsToReturn
+	d.JobsToReturn = nil
+	return jobs, nil
+}
+
+func (d *DummyWorkerGateway) FetchInputFile(jobID, name, token string) (io.ReadCloser, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.checkToken(token); err != nil {
+		return nil, err
+	}
+	content, ok := d.InputFiles[name]
+	if !ok {
+		return nil, &ports.RejectedError{StatusCode: 404}
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+// waitUntil prüft die Bedingung, bis sie erfüllt ist oder der Timeout abläuft
+func waitUntil(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
 func TestDaemon_HeartbeatLoop_RegisterFails(t *testing.T) {
 	dummyAPI := DummyWorkerGateway{
 		RegisterErr: errors.New("register failed"),
@@ -60,7 +216,7 @@ func TestDaemon_HeartbeatLoop_RegisterFails(t *testing.T) {
 		Zone:                     "zone",
 		HeartbeatIntervalSeconds: 1,
 	}
-	d := NewDaemon(cfg, &dummyAPI)
+	d := NewDaemon(cfg, &dummyAPI, &DummyJournal{})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -68,7 +224,7 @@ func TestDaemon_HeartbeatLoop_RegisterFails(t *testing.T) {
 	// Starte HeartbeatLoop, der Registrierung schlägt fehl und der Loop soll abbrechen
 	d.StartHeartbeatLoop(ctx)
 
-	if !dummyAPI.RegisterCalled {
+	if !dummyAPI.Calls().RegisterCalled {
 		t.Error("expected Register to be called")
 	}
 }
@@ -96,7 +252,7 @@ func TestDaemon_HeartbeatLoop_RegisterFails(t *testing.T) {
 		Zone:                     "zone",
 		HeartbeatIntervalSeconds: 1,
 	}
-	d := NewDaemon(cfg, dummyAPI)
+	d := NewDaemon(cfg, dummyAPI, &DummyJournal{})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -144,25 +300,24 @@ func TestDaemon_HeartbeatLoop_HeartbeatFails(t *testing.T) {
 		Zone:                     "zone",
 		HeartbeatIntervalSeconds: 1,
 	}
-	d := NewDaemon(cfg, dummyAPI)
+	d := NewDaemon(cfg, dummyAPI, &DummyJournal{})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	go d.StartHeartbeatLoop(ctx)
-
-	time.Sleep(2 * time.Second)
+	d.StartHeartbeatLoop(ctx)
 
-	if !dummyAPI.RegisterCalled {
+	calls := dummyAPI.Calls()
+	if !calls.RegisterCalled {
 		t.Error("expected Register to be called")
 	}
 
-	if !dummyAPI.SendHeartbeatCalled {
+	if !calls.SendHeartbeatCalled {
 		t.Error("expected SendHeartbeat to be called")
 	}
 
 	// Da Heartbeat Fehler liefert, sollte SendResult NICHT aufgerufen werden
-	if dummyAPI.SendResultCalled {
+	if calls.SendResultCalled {
 		t.Error("did not expect SendResult to be called when Heartbeat fails")
 	}
 }
@@ -183,7 +338,7 @@ func TestDaemon_HeartbeatLoop_HeartbeatFails(t *testing.T) {
 		fmt.Printf("Starte Job mit Image: %s:%s\n", job.Image.Name, job.Image.Version)
 		fmt.Printf("AdjustmentParameters: %v\n", job.AdjustmentParameters)
 
-		result := computeJob(job)
+		result := computeJob(job, jobEnv{})
 
 		fmt.Println("Job abgeschlossen. Ergebnis:")
 		fmt.Printf("Status:       %s\n", result.Status)
@@ -218,7 +373,7 @@ func TestComputeJob_InvalidImage(t *testing.T) {
 	fmt.Printf("Starte Job mit Image: %s:%s\n", job.Image.Name, job.Image.Version)
 	fmt.Printf("AdjustmentParameters: %v\n", job.AdjustmentParameters)
 
-	result := computeJob(job)
+	result := computeJob(job, jobEnv{})
 
 	fmt.Println("Job abgeschlossen. Ergebnis:")
 	fmt.Printf("Status:       %s\n", result.Status)
@@ -237,3 +392,48 @@ func TestComputeJob_InvalidImage(t *testing.T) {
 		t.Errorf("Expected error message, got empty")
 	}
 }
+
+func TestDaemon_PollJobs_StartsAssignedJob(t *testing.T) {
+	api := &DummyWorkerGateway{JobsToReturn: []ports.Job{{
+		ID:    "job-1",
+		Image: ports.ContainerImage{Name: "nonexistent-image-akjbfsfkjbdgnsdfkjv", Version: "never"},
+	}}}
+	cfg := config.Config{Secret: "key", Zone: "zone", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, &DummyJournal{})
+
+	calls := runUntilResult(t, d, api)
+
+	if !calls.NextJobsCalled {
+		t.Error("expected NextJobs to be called")
+	}
+	if calls.ReceivedJobs[0].ID != "job-1" {
+		t.Errorf("expected result of job-1, got %+v", calls.ReceivedJobs[0])
+	}
+}
+
+func TestDaemon_PollJobs_SkipsJobWithPendingResult(t *testing.T) {
+	// Ergebnis ist noch nicht zugestellt, der Job ist daher im Job Service noch zugewiesen
+	api := &DummyWorkerGateway{
+		SendResultErr: errors.New("gateway unavailable"),
+		JobsToReturn:  []ports.Job{{ID: "job-1"}},
+	}
+	journal := &DummyJournal{State: ports.JournalState{
+		WorkerID: "worker-1",
+		Token:    "token-1",
+		Zone:     "DE",
+		Outbox:   []ports.Job{{ID: "job-1", Status: JobStatusDone, ResultID: "result-1"}},
+	}}
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, journal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	d.pollJobs(ctx, new(int32))
+
+	if !api.Calls().NextJobsCalled {
+		t.Fatal("expected NextJobs to be called")
+	}
+	if job := journal.Current().CurrentJob; job != nil {
+		t.Errorf("expected job with pending result not to be started again, got %+v", job)
+	}
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"worker-daemon/internal/ports"
+)
+
+// Methods used to determine the energy of a job, reported with the result.
+const (
+	EnergyMethodRAPL     = "rapl"           // RAPL package energy, attributed by the container's share of host CPU time
+	EnergyMethodCgroup   = "cgroup-model"   // container CPU time and memory from cgroup stats and the power model
+	EnergyMethodDuration = "duration-model" // no container stats available, one fully used core for the job duration
+)
+
+// PowerModel estimates power draw from resource usage when no energy counters are available.
+type PowerModel struct {
+	CPUWattsPerCore  float64 // power of one fully used core
+	MemoryWattsPerGB float64 // power per GB of used memory
+}
+
+// DefaultPowerModel uses rough averages for server hardware.
+var DefaultPowerModel = PowerModel{CPUWattsPerCore: 10, MemoryWattsPerGB: 0.392}
+
+// energyMeter samples the resource usage of a container while it runs.
+type energyMeter struct {
+	model    PowerModel
+	sysRoot  string        // "/sys", overridden in tests
+	procStat string        // "/proc/stat", overridden in tests
+	interval time.Duration // sampling interval
+}
+
+func newEnergyMeter(model PowerModel) *energyMeter {
+	if model.CPUWattsPerCore <= 0 {
+		model.CPUWattsPerCore = DefaultPowerModel.CPUWattsPerCore
+	}
+	if model.MemoryWattsPerGB <= 0 {
+		model.MemoryWattsPerGB = DefaultPowerModel.MemoryWattsPerGB
+	}
+	return &energyMeter{model: model, sysRoot: "/sys", procStat: "/proc/stat", interval: time.Second}
+}
+
+// measure samples the container until done is closed.
+func (m *energyMeter) measure(containerID string, done <-chan struct{}) ports.EnergyReport {
+	start := time.Now()
+	raplStart, raplOK := m.readRAPL()
+	hostStart, hostOK := m.readHostCPU()
+
+	cpuSeconds, memoryGBSeconds := 0.0, 0.0
+	sampled := false
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	sample := func() {
+		cpu, memory, ok := m.readCgroup(containerID)
+		if !ok {
+			return
+		}
+		// cgroups are removed with the container, keep the last value
+		cpuSeconds = cpu
+		memoryGBSeconds += memory / 1e9 * m.interval.Seconds()
+		sampled = true
+	}
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-ticker.C:
+			sample()
+		}
+	}
+
+	report := ports.EnergyReport{
+		DurationSeconds: time.Since(start).Seconds(),
+		CPUSeconds:      cpuSeconds,
+		MemoryGBSeconds: memoryGBSeconds,
+	}
+
+	joules := 0.0
+	if !sampled {
+		report.Method = EnergyMethodDuration
+		joules = report.DurationSeconds * m.model.CPUWattsPerCore
+	} else {
+		report.Method = EnergyMethodCgroup
+		joules = cpuSeconds*m.model.CPUWattsPerCore + memoryGBSeconds*m.model.MemoryWattsPerGB
+
+		raplEnd, raplEndOK := m.readRAPL()
+		hostEnd, hostEndOK := m.readHostCPU()
+		if raplOK && raplEndOK && hostOK && hostEndOK && hostEnd > hostStart {
+			share := min(1, cpuSeconds/(hostEnd-hostStart))
+			report.Method = EnergyMethodRAPL
+			joules = raplDelta(raplStart, raplEnd)*share + memoryGBSeconds*m.model.MemoryWattsPerGB
+		}
+	}
+	report.EnergyWh = joules / 3600
+	return report
+}
+
+// readCgroup returns the CPU seconds and current memory bytes of a docker container,
+// supporting cgroup v2 with systemd or cgroupfs driver and cgroup v1.
+func (m *energyMeter) readCgroup(id string) (float64, float64, bool) {
+	cgroup := filepath.Join(m.sysRoot, "fs", "cgroup")
+	for _, dir := range []string{
+		filepath.Join(cgroup, "system.slice", "docker-"+id+".scope"),
+		filepath.Join(cgroup, "docker", id),
+	} {
+		usec, ok := readStatValue(filepath.Join(dir, "cpu.stat"), "usage_usec")
+		if !ok {
+			continue
+		}
+		memory, _ := readNumber(filepath.Join(dir, "memory.current"))
+		return usec / 1e6, memory, true
+	}
+
+	nsec, ok := readNumber(filepath.Join(cgroup, "cpuacct", "docker", id, "cpuacct.usage"))
+	if !ok {
+		return 0, 0, false
+	}
+	memory, _ := readNumber(filepath.Join(cgroup, "memory", "docker", id, "memory.usage_in_bytes"))
+	return nsec / 1e9, memory, true
+}
+
+// raplCounter is the energy counter of one CPU package.
+type raplCounter struct {
+	microJoules float64
+	maxRange    float64
+}
+
+// readRAPL reads the package level RAPL counters, e.g. intel-rapl:0 but not the subzone intel-rapl:0:0.
+func (m *energyMeter) readRAPL() (map[string]raplCounter, bool) {
+	zones, _ := filepath.Glob(filepath.Join(m.sysRoot, "class", "powercap", "intel-rapl:*"))
+	counters := make(map[string]raplCounter)
+	for _, zone := range zones {
+		if strings.Count(filepath.Base(zone), ":") != 1 {
+			continue
+		}
+		energy, ok := readNumber(filepath.Join(zone, "energy_uj"))
+		if !ok {
+			continue
+		}
+		maxRange, _ := readNumber(filepath.Join(zone, "max_energy_range_uj"))
+		counters[zone] = raplCounter{microJoules: energy, maxRange: maxRange}
+	}
+	return counters, len(counters) > 0
+}
+
+// raplDelta returns the consumed joules between two readings, handling counter wrap around.
+func raplDelta(start, end map[string]raplCounter) float64 {
+	joules := 0.0
+	for zone, s := range start {
+		e, ok := end[zone]
+		if !ok {
+			continue
+		}
+		delta := e.microJoules - s.microJoules
+		if delta < 0 {
+			delta += s.maxRange
+		}
+		joules += delta / 1e6
+	}
+	return joules
+}
+
+// readHostCPU returns the busy CPU seconds of all cores from /proc/stat.
+func (m *energyMeter) readHostCPU() (float64, bool) {
+	f, err := os.Open(m.procStat)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return 0, false
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 6 || fields[0] != "cpu" {
+		return 0, false
+	}
+
+	busy := 0.0
+	// user nice system idle iowait irq softirq steal, guest time is already part of user
+	for i, field := range fields[1:min(len(fields), 9)] {
+		ticks, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, false
+		}
+		// idle and iowait
+		if i == 3 || i == 4 {
+			continue
+		}
+		busy += ticks
+	}
+	// USER_HZ is 100 on all common platforms
+	return busy / 100, true
+}
+
+func readNumber(path string) (float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	return value, err == nil
+}
+
+// readStatValue reads a "key value" line from a cgroup stat file.
+func readStatValue(path string, key string) (float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			value, err := strconv.ParseFloat(fields[1], 64)
+			return value, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestMeter(t *testing.T) (*energyMeter, string) {
+	root := t.TempDir()
+	m := newEnergyMeter(PowerModel{})
+	m.sysRoot = filepath.Join(root, "sys")
+	m.procStat = filepath.Join(root, "stat")
+	m.interval = 10 * time.Millisecond
+	return m, root
+}
+
+// measureFor lässt den Meter kurz laufen und führt change währenddessen aus
+func measureFor(m *energyMeter, containerID string, change func()) float64 {
+	done := make(chan struct{})
+	result := make(chan float64, 1)
+	go func() {
+		report := m.measure(containerID, done)
+		result <- report.EnergyWh
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if change != nil {
+		change()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(done)
+	return <-result
+}
+
+func TestEnergyMeter_CgroupModel(t *testing.T) {
+	m, _ := newTestMeter(t)
+	scope := filepath.Join(m.sysRoot, "fs", "cgroup", "system.slice", "docker-abc123.scope")
+	writeFile(t, filepath.Join(scope, "cpu.stat"), "usage_usec 360000000\nuser_usec 1\n")
+	writeFile(t, filepath.Join(scope, "memory.current"), "0")
+
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(done)
+	}()
+	report := m.measure("abc123", done)
+
+	if report.Method != EnergyMethodCgroup {
+		t.Fatalf("Expected method %s, got %s", EnergyMethodCgroup, report.Method)
+	}
+	// 360 CPU seconds * 10 W = 1 Wh
+	if math.Abs(report.EnergyWh-1) > 1e-9 {
+		t.Errorf("Expected 1 Wh, got %f", report.EnergyWh)
+	}
+}
+
+func TestEnergyMeter_RAPL(t *testing.T) {
+	m, _ := newTestMeter(t)
+	writeFile(t, filepath.Join(m.sysRoot, "fs", "cgroup", "docker", "abc123", "cpu.stat"), "usage_usec 100000000\n")
+	rapl := filepath.Join(m.sysRoot, "class", "powercap", "intel-rapl:0")
+	writeFile(t, filepath.Join(rapl, "energy_uj"), "1000000000")
+	writeFile(t, filepath.Join(rapl, "max_energy_range_uj"), "262143328850")
+	// Subzonen werden ignoriert
+	writeFile(t, filepath.Join(m.sysRoot, "class", "powercap", "intel-rapl:0:0", "energy_uj"), "0")
+	writeFile(t, m.procStat, "cpu  1000 0 0 5000 0 0 0 0 0 0\n")
+
+	energy := measureFor(m, "abc123", func() {
+		// 3600 J für das Paket, Host war 200 CPU-Sekunden beschäftigt, der Container 100
+		writeFile(t, filepath.Join(rapl, "energy_uj"), "4600000000")
+		writeFile(t, m.procStat, "cpu  21000 0 0 9000 0 0 0 0 0 0\n")
+	})
+
+	// 3600 J * 100/200 = 0.5 Wh
+	if math.Abs(energy-0.5) > 1e-9 {
+		t.Errorf("Expected 0.5 Wh, got %f", energy)
+	}
+}
+
+func TestEnergyMeter_DurationFallback(t *testing.T) {
+	m, _ := newTestMeter(t)
+
+	done := make(chan struct{})
+	close(done)
+	report := m.measure("missing", done)
+
+	if report.Method != EnergyMethodDuration {
+		t.Errorf("Expected method %s, got %s", EnergyMethodDuration, report.Method)
+	}
+}
+
+func TestRAPLDelta_WrapAround(t *testing.T) {
+	start := map[string]raplCounter{"p0": {microJoules: 900, maxRange: 1000}}
+	end := map[string]raplCounter{"p0": {microJoules: 100, maxRange: 1000}}
+
+	if delta := raplDelta(start, end); math.Abs(delta-200e-6) > 1e-12 {
+		t.Errorf("Expected 200 µJ, got %g J", delta)
+	}
+}
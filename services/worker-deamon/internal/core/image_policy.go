@@ -0,0 +1,27 @@
+package core
+
+import (
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/imagepolicy"
+
+	"worker-daemon/internal/ports"
+)
+
+// ImagePolicy restricts the container images the worker runs, see pkg/imagepolicy. It uses the file
+// format of the job service policy, so a provider can enforce the central policy or a stricter one locally.
+type ImagePolicy struct {
+	policy *imagepolicy.Policy
+}
+
+// LoadImagePolicy reads the policy from a JSON file.
+func LoadImagePolicy(file string) (*ImagePolicy, error) {
+	policy, err := imagepolicy.Load(file)
+	if err != nil {
+		return nil, err
+	}
+	return &ImagePolicy{policy: policy}, nil
+}
+
+// Check returns an error if the image does not comply with the policy, runJob reports it as the job error.
+func (p *ImagePolicy) Check(image ports.ContainerImage) error {
+	return p.policy.Check(imagepolicy.Image{Name: image.Name, Digest: image.Digest, Signature: image.Signature})
+}
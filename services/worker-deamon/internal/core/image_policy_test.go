@@ -0,0 +1,32 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"worker-daemon/internal/config"
+	"worker-daemon/internal/ports"
+)
+
+func TestDaemon_RunJob_ImagePolicy(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(file, []byte(`{"allowed_repositories": ["ghcr.io/org/*"]}`), 0o644)
+	policy, err := LoadImagePolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDaemon(config.Config{CacheDir: t.TempDir()}, &DummyWorkerGateway{}, &DummyJournal{})
+	d.SetImagePolicy(policy)
+
+	// das Image wird abgelehnt, bevor Docker aufgerufen wird
+	job := d.runJob(ports.Job{ID: "job1", Image: ports.ContainerImage{Name: "alpine", Version: "latest"}})
+	if job.Status != JobStatusError || !strings.HasPrefix(job.ErrorMessage, "image rejected") {
+		t.Errorf("expected image to be rejected, got %s %q", job.Status, job.ErrorMessage)
+	}
+	if got := d.metrics.containerFailures.Load(); got != 0 {
+		t.Errorf("expected rejected image not to count as container failure, got %d", got)
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
+	"worker-daemon/internal/ports"
+)
+
+// imageCache is the list of images docker has locally, reported with every heartbeat
+// so the scheduler can prefer workers that do not have to pull the image of a job.
+type imageCache struct {
+	mu   sync.Mutex
+	refs []string // "name:tag" and "name@digest" of each image
+}
+
+// imageRef returns the reference docker runs for the image. A digest pins the image,
+// docker refuses an image with another digest even if the tag matches.
+func imageRef(image ports.ContainerImage) string {
+	ref := image.Name
+	if image.Version != "" {
+		ref += ":" + image.Version
+	}
+	if image.Digest != "" {
+		ref += "@" + image.Digest
+	}
+	return ref
+}
+
+// isCached reports whether docker has the image locally. Images that are not pinned by
+// digest are matched by tag, "latest" if the version is empty.
+func isCached(cached []string, image ports.ContainerImage) bool {
+	if image.Digest != "" {
+		return slices.Contains(cached, image.Name+"@"+image.Digest)
+	}
+	version := image.Version
+	if version == "" {
+		version = "latest"
+	}
+	return slices.Contains(cached, image.Name+":"+version)
+}
+
+func (d *Daemon) cachedImages() []string {
+	d.images.mu.Lock()
+	defer d.images.mu.Unlock()
+	return d.images.refs
+}
+
+// refreshImages reads the images docker has locally.
+func (d *Daemon) refreshImages() {
+	output, err := exec.Command("docker", "image", "ls", "--digests", "--format", "{{.Repository}}\t{{.Tag}}\t{{.Digest}}").Output()
+	if err != nil {
+		logging.Warn("Listing images failed", "error", err)
+		return
+	}
+	refs := parseImageList(string(output))
+
+	d.images.mu.Lock()
+	d.images.refs = refs
+	d.images.mu.Unlock()
+}
+
+// parseImageList returns the references of the images in the output of docker image ls.
+func parseImageList(output string) []string {
+	refs := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || fields[0] == "<none>" {
+			continue
+		}
+		if fields[1] != "<none>" {
+			refs = append(refs, fields[0]+":"+fields[1])
+		}
+		if fields[2] != "<none>" {
+			refs = append(refs, fields[0]+"@"+fields[2])
+		}
+	}
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
+
+// pullImage pulls the image unless docker has it already. Pulling before the container is
+// created keeps the download out of the measured run time and energy of the job.
+func (d *Daemon) pullImage(image ports.ContainerImage) error {
+	if isCached(d.cachedImages(), image) {
+		return nil
+	}
+	var stderr bytes.Buffer
+	pull := exec.Command("docker", "pull", "--quiet", imageRef(image))
+	pull.Stderr = &stderr
+	if err := pull.Run(); err != nil {
+		return fmt.Errorf("pull image failed: %v - %s", err, strings.TrimSpace(stderr.String()))
+	}
+	d.refreshImages()
+	return nil
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"worker-daemon/internal/ports"
+)
+
+const testDigest = "sha256:2d1b4e8f9a0c3b5d7e6f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d"
+
+func TestParseImageList(t *testing.T) {
+	output := "alpine\tlatest\t" + testDigest + "\n" +
+		"ghcr.io/org/app\t<none>\t" + testDigest + "\n" +
+		"local-build\tdev\t<none>\n" +
+		"<none>\t<none>\t<none>\n"
+
+	expected := []string{"alpine:latest", "alpine@" + testDigest, "ghcr.io/org/app@" + testDigest, "local-build:dev"}
+	if refs := parseImageList(output); !slices.Equal(refs, expected) {
+		t.Errorf("expected %v, got %v", expected, refs)
+	}
+}
+
+func TestIsCached(t *testing.T) {
+	cached := []string{"alpine:latest", "ghcr.io/org/app@" + testDigest}
+
+	tests := []struct {
+		image    ports.ContainerImage
+		expected bool
+	}{
+		{ports.ContainerImage{Name: "alpine"}, true},
+		{ports.ContainerImage{Name: "alpine", Version: "3.20"}, false},
+		{ports.ContainerImage{Name: "ghcr.io/org/app", Version: "1.0", Digest: testDigest}, true},
+		// gleicher Tag, aber anderer Digest
+		{ports.ContainerImage{Name: "alpine", Version: "latest", Digest: testDigest}, false},
+	}
+	for _, tc := range tests {
+		if got := isCached(cached, tc.image); got != tc.expected {
+			t.Errorf("%+v: expected %v, got %v", tc.image, tc.expected, got)
+		}
+	}
+}
+
+func TestImageRef(t *testing.T) {
+	image := ports.ContainerImage{Name: "ghcr.io/org/app", Version: "1.0", Digest: testDigest}
+	if ref := imageRef(image); ref != "ghcr.io/org/app:1.0@"+testDigest {
+		t.Errorf("unexpected reference %s", ref)
+	}
+}
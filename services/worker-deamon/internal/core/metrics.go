@@ -0,0 +1,151 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"worker-daemon/internal/ports"
+)
+
+// jobDurationBuckets are the upper bounds of the job duration histogram in seconds
+var jobDurationBuckets = []float64{1, 10, 60, 300, 900, 3600, 4 * 3600, 24 * 3600}
+
+// metrics counts what the daemon does, exposed in the Prometheus text format by WriteMetrics.
+type metrics struct {
+	heartbeatsOK      atomic.Int64
+	heartbeatsFailed  atomic.Int64
+	jobsDone          atomic.Int64
+	jobsFailed        atomic.Int64
+	containerFailures atomic.Int64
+	resultsRejected   atomic.Int64
+	lastContact       atomic.Int64 // unix nanoseconds of the last successful gateway call, 0 if none
+
+	mu              sync.Mutex
+	durationBuckets []int64 // cumulative counts per bucket of jobDurationBuckets
+	durationSum     float64
+	durationCount   int64
+}
+
+func (m *metrics) contact() {
+	m.lastContact.Store(time.Now().UnixNano())
+}
+
+func (m *metrics) heartbeat(err error) {
+	if err != nil {
+		m.heartbeatsFailed.Add(1)
+	} else {
+		m.heartbeatsOK.Add(1)
+	}
+}
+
+func (m *metrics) jobFinished(status string) {
+	if status == JobStatusDone {
+		m.jobsDone.Add(1)
+	} else {
+		m.jobsFailed.Add(1)
+	}
+}
+
+func (m *metrics) jobDuration(duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.durationBuckets == nil {
+		m.durationBuckets = make([]int64, len(jobDurationBuckets))
+	}
+	seconds := duration.Seconds()
+	for i, bound := range jobDurationBuckets {
+		if seconds <= bound {
+			m.durationBuckets[i]++
+		}
+	}
+	m.durationSum += seconds
+	m.durationCount++
+}
+
+// Health reports whether the daemon is registered and recently reached the gateway.
+// The worker counts as unhealthy after three heartbeat intervals without contact.
+func (d *Daemon) Health() ports.Health {
+	health := ports.Health{Status: ports.HealthOK, WorkerID: d.registeredWorkerID(), RunningJob: d.runningJob()}
+	if last := d.metrics.lastContact.Load(); last > 0 {
+		health.LastContact = time.Unix(0, last)
+	}
+
+	switch {
+	case health.WorkerID == "":
+		health.Status = ports.HealthUnavailable
+		health.Reason = "not registered"
+	case time.Since(health.LastContact) > 3*d.heartbeatInterval():
+		health.Status = ports.HealthUnavailable
+		health.Reason = "no contact to the gateway"
+	}
+	return health
+}
+
+// WriteMetrics writes the metrics in the Prometheus text format.
+func (d *Daemon) WriteMetrics(w io.Writer) error {
+	m := &d.metrics
+	running := 0
+	if d.runningJob() != "" {
+		running = 1
+	}
+
+	_, err := fmt.Fprintf(w, `# HELP worker_daemon_heartbeats_total Heartbeats sent to the gateway.
+# TYPE worker_daemon_heartbeats_total counter
+worker_daemon_heartbeats_total{result="success"} %d
+worker_daemon_heartbeats_total{result="failure"} %d
+# HELP worker_daemon_jobs_total Jobs run by the worker.
+# TYPE worker_daemon_jobs_total counter
+worker_daemon_jobs_total{status="done"} %d
+worker_daemon_jobs_total{status="error"} %d
+# HELP worker_daemon_container_failures_total Job containers that failed to run or exited with an error.
+# TYPE worker_daemon_container_failures_total counter
+worker_daemon_container_failures_total %d
+# HELP worker_daemon_results_rejected_total Job results the gateway rejected, they are parked in the journal.
+# TYPE worker_daemon_results_rejected_total counter
+worker_daemon_results_rejected_total %d
+# HELP worker_daemon_job_running Whether a job is running.
+# TYPE worker_daemon_job_running gauge
+worker_daemon_job_running %d
+# HELP worker_daemon_last_contact_timestamp_seconds Time of the last successful call to the gateway.
+# TYPE worker_daemon_last_contact_timestamp_seconds gauge
+worker_daemon_last_contact_timestamp_seconds %g
+`, m.heartbeatsOK.Load(), m.heartbeatsFailed.Load(), m.jobsDone.Load(), m.jobsFailed.Load(),
+		m.containerFailures.Load(), m.resultsRejected.Load(), running, float64(m.lastContact.Load())/1e9)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintln(w, "# HELP worker_daemon_job_duration_seconds Duration of the jobs run by the worker.")
+	fmt.Fprintln(w, "# TYPE worker_daemon_job_duration_seconds histogram")
+	for i, bound := range jobDurationBuckets {
+		count := int64(0)
+		if m.durationBuckets != nil {
+			count = m.durationBuckets[i]
+		}
+		fmt.Fprintf(w, "worker_daemon_job_duration_seconds_bucket{le=\"%g\"} %d\n", bound, count)
+	}
+	fmt.Fprintf(w, "worker_daemon_job_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.durationCount)
+	fmt.Fprintf(w, "worker_daemon_job_duration_seconds_sum %g\n", m.durationSum)
+	_, err = fmt.Fprintf(w, "worker_daemon_job_duration_seconds_count %d\n", m.durationCount)
+	return err
+}
+
+func (d *Daemon) registeredWorkerID() string {
+	d.journalMu.Lock()
+	defer d.journalMu.Unlock()
+	return d.state.WorkerID
+}
+
+func (d *Daemon) runningJob() string {
+	d.journalMu.Lock()
+	defer d.journalMu.Unlock()
+	if d.state.CurrentJob == nil {
+		return ""
+	}
+	return d.state.CurrentJob.ID
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"worker-daemon/internal/config"
+	"worker-daemon/internal/ports"
+)
+
+func TestDaemon_Health(t *testing.T) {
+	d := NewDaemon(config.Config{HeartbeatIntervalSeconds: 1}, &DummyWorkerGateway{}, &DummyJournal{})
+
+	// noch nicht registriert
+	if health := d.Health(); health.Status != ports.HealthUnavailable {
+		t.Errorf("expected unavailable before registration, got %+v", health)
+	}
+
+	d.updateJournal(func(state *ports.JournalState) { state.WorkerID = "worker1" })
+	d.metrics.contact()
+	if health := d.Health(); health.Status != ports.HealthOK || health.WorkerID != "worker1" {
+		t.Errorf("expected ok after contact, got %+v", health)
+	}
+
+	// kein Kontakt seit mehr als drei Intervallen
+	d.metrics.lastContact.Store(time.Now().Add(-5 * time.Second).UnixNano())
+	if health := d.Health(); health.Status != ports.HealthUnavailable {
+		t.Errorf("expected unavailable without recent contact, got %+v", health)
+	}
+}
+
+func TestDaemon_WriteMetrics(t *testing.T) {
+	d := NewDaemon(config.Config{HeartbeatIntervalSeconds: 1}, &DummyWorkerGateway{}, &DummyJournal{})
+	d.metrics.heartbeat(nil)
+	d.metrics.heartbeat(nil)
+	d.metrics.heartbeat(errors.New("timeout"))
+	d.metrics.jobFinished(JobStatusDone)
+	d.metrics.jobFinished(JobStatusError)
+	d.metrics.containerFailures.Add(1)
+	d.metrics.resultsRejected.Add(1)
+	d.metrics.jobDuration(30 * time.Second)
+
+	var out strings.Builder
+	if err := d.WriteMetrics(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`worker_daemon_heartbeats_total{result="success"} 2`,
+		`worker_daemon_heartbeats_total{result="failure"} 1`,
+		`worker_daemon_jobs_total{status="done"} 1`,
+		`worker_daemon_jobs_total{status="error"} 1`,
+		`worker_daemon_container_failures_total 1`,
+		`worker_daemon_results_rejected_total 1`,
+		`worker_daemon_job_running 0`,
+		`worker_daemon_job_duration_seconds_bucket{le="10"} 0`,
+		`worker_daemon_job_duration_seconds_bucket{le="60"} 1`,
+		`worker_daemon_job_duration_seconds_bucket{le="+Inf"} 1`,
+		`worker_daemon_job_duration_seconds_sum 30`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected metrics to contain %q, got:\n%s", line, out.String())
+		}
+	}
+}
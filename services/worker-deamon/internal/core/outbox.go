@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
+	"worker-daemon/internal/ports"
+)
+
+// maxRejected is how many rejected results the journal keeps, the oldest are dropped first
+const maxRejected = 50
+
+// enqueueResult assigns a result ID to the finished job and moves it from the running job to the
+// journaled outbox, so the result survives a restart until it is delivered.
+func (d *Daemon) enqueueResult(job ports.Job) {
+	if job.ResultID == "" {
+		job.ResultID = rand.Text()
+	}
+	d.updateJournal(func(state *ports.JournalState) {
+		state.CurrentJob = nil
+		state.ContainerID = ""
+		state.Outbox = append(state.Outbox, job)
+	})
+
+	select {
+	case d.outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// nextResult returns the oldest result in the outbox.
+func (d *Daemon) nextResult() (ports.Job, bool) {
+	d.journalMu.Lock()
+	defer d.journalMu.Unlock()
+
+	if len(d.state.Outbox) == 0 {
+		return ports.Job{}, false
+	}
+	return d.state.Outbox[0], true
+}
+
+// removeResult removes a delivered result from the outbox.
+func (d *Daemon) removeResult(resultID string) {
+	d.updateJournal(func(state *ports.JournalState) {
+		for i, job := range state.Outbox {
+			if job.ResultID == resultID {
+				state.Outbox = append(state.Outbox[:i:i], state.Outbox[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// rejectResult moves a result the gateway refused from the outbox to the rejected results.
+func (d *Daemon) rejectResult(resultID string) {
+	d.updateJournal(func(state *ports.JournalState) {
+		for i, job := range state.Outbox {
+			if job.ResultID == resultID {
+				state.Outbox = append(state.Outbox[:i:i], state.Outbox[i+1:]...)
+				state.Rejected = append(state.Rejected, job)
+				if len(state.Rejected) > maxRejected {
+					state.Rejected = state.Rejected[len(state.Rejected)-maxRejected:]
+				}
+				return
+			}
+		}
+	})
+}
+
+// deliverResults sends the results in the outbox in order until ctx is done.
+// Failed deliveries are retried with exponential backoff. The result ID stays the same,
+// so the job service ignores a result that arrived although the response got lost.
+// A result the gateway rejects, e.g. because the job was assigned to another worker,
+// is parked in the journal, so it does not block the results behind it.
+func (d *Daemon) deliverResults(ctx context.Context) {
+	delay := d.retryInitial
+	for {
+		job, ok := d.nextResult()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.outboxWake:
+				continue
+			}
+		}
+
+		err := d.withToken(func(token string) error {
+			return d.api.SendResult(job, token)
+		})
+		if errors.Is(err, ports.ErrRejected) {
+			logging.Warn("Result rejected by the gateway, parking it", "job_id", job.ID, "result_id", job.ResultID, "error", err)
+			d.metrics.resultsRejected.Add(1)
+			d.rejectResult(job.ResultID)
+			delay = d.retryInitial
+			continue
+		}
+		if err != nil {
+			logging.Warn("SendResult failed, retrying", "job_id", job.ID, "delay", delay, "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(2*delay, d.retryMax)
+			continue
+		}
+
+		logging.Debug("Result delivered", "job_id", job.ID, "result_id", job.ResultID)
+		d.removeResult(job.ResultID)
+		delay = d.retryInitial
+	}
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"worker-daemon/internal/config"
+	"worker-daemon/internal/ports"
+)
+
+func TestDaemon_FailedDeliveryIsRetriedWithSameResultID(t *testing.T) {
+	api := &DummyWorkerGateway{SendResultFailures: 2}
+	journal := &DummyJournal{}
+	d := NewDaemon(config.Config{CacheDir: t.TempDir()}, api, journal)
+	d.retryInitial = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		d.deliverResults(ctx)
+		close(done)
+	}()
+
+	d.enqueueResult(ports.Job{ID: "job-1", Status: JobStatusDone, Result: "42"})
+
+	waitUntil(time.Second, func() bool { return len(journal.Current().Outbox) == 0 })
+	cancel()
+	<-done
+
+	received := api.Calls().ReceivedJobs
+	if len(received) != 3 {
+		t.Fatalf("expected 3 delivery attempts, got %d", len(received))
+	}
+	resultID := received[0].ResultID
+	if resultID == "" {
+		t.Fatal("expected result ID to be set")
+	}
+	for _, job := range received {
+		if job.ResultID != resultID {
+			t.Errorf("expected all attempts to use result ID %s, got %s", resultID, job.ResultID)
+		}
+	}
+	if len(journal.Current().Outbox) != 0 {
+		t.Error("expected outbox to be empty after delivery")
+	}
+}
+
+func TestDaemon_FailedDeliveryDoesNotBlockWorker(t *testing.T) {
+	api := &DummyWorkerGateway{SendResultErr: errors.New("gateway unavailable")}
+	journal := &DummyJournal{State: ports.JournalState{
+		WorkerID:   "worker-1",
+		Token:      "token-1",
+		Zone:       "DE",
+		CurrentJob: &ports.Job{ID: "job-1"},
+	}}
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, journal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	d.StartHeartbeatLoop(ctx)
+
+	// the loop returns after the delivery stopped
+	calls := api.Calls()
+	if !calls.SendResultCalled {
+		t.Fatal("expected SendResult to be called")
+	}
+	if calls.LastStatus != "AVAILABLE" {
+		t.Errorf("expected worker to be AVAILABLE while the result is retried, got %q", calls.LastStatus)
+	}
+	if outbox := journal.Current().Outbox; len(outbox) != 1 || outbox[0].ID != "job-1" {
+		t.Errorf("expected undelivered result to stay in the outbox, got %+v", outbox)
+	}
+}
+
+func TestDaemon_RejectedResultDoesNotBlockOutbox(t *testing.T) {
+	api := &DummyWorkerGateway{RejectedJobs: map[string]int{"job-1": http.StatusForbidden}}
+	journal := &DummyJournal{}
+	d := NewDaemon(config.Config{CacheDir: t.TempDir()}, api, journal)
+	d.retryInitial = time.Hour // ein Retry würde den Test blockieren
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		d.deliverResults(ctx)
+		close(done)
+	}()
+
+	d.enqueueResult(ports.Job{ID: "job-1", Status: JobStatusDone, Result: "42"})
+	d.enqueueResult(ports.Job{ID: "job-2", Status: JobStatusDone, Result: "43"})
+
+	waitUntil(time.Second, func() bool { return len(journal.Current().Outbox) == 0 })
+	cancel()
+	<-done
+
+	received := api.Calls().ReceivedJobs
+	if len(received) != 2 || received[0].ID != "job-1" || received[1].ID != "job-2" {
+		t.Fatalf("expected job-1 to be tried once and job-2 to be delivered, got %+v", received)
+	}
+	state := journal.Current()
+	if len(state.Outbox) != 0 {
+		t.Errorf("expected outbox to be empty, got %+v", state.Outbox)
+	}
+	if len(state.Rejected) != 1 || state.Rejected[0].ID != "job-1" {
+		t.Errorf("expected job-1 to be parked as rejected, got %+v", state.Rejected)
+	}
+	if rejected := d.metrics.resultsRejected.Load(); rejected != 1 {
+		t.Errorf("expected 1 rejected result, got %d", rejected)
+	}
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"worker-daemon/internal/ports"
+)
+
+// Containers report progress either by printing a marker line to stdout, e.g.
+//
+//	##progress 42 epoch 3/10
+//
+// or by writing "<percent> <message>" to the file named in the PROGRESS_FILE
+// environment variable. Marker lines are not part of the job result.
+const (
+	ProgressMarker    = "##progress"
+	ProgressMountPath = "/progress"
+	ProgressFileEnv   = "PROGRESS_FILE"
+	progressFileName  = "progress"
+)
+
+// parseProgress parses "<percent> [message]", the percent is clamped to 0 - 100.
+func parseProgress(s string) (int, string, bool) {
+	fields := strings.SplitN(strings.TrimSpace(s), " ", 2)
+	percent, err := strconv.Atoi(strings.TrimSuffix(fields[0], "%"))
+	if err != nil {
+		return 0, "", false
+	}
+	percent = max(0, min(100, percent))
+
+	message := ""
+	if len(fields) == 2 {
+		message = strings.TrimSpace(fields[1])
+	}
+	return percent, message, true
+}
+
+// ProgressTracker holds the last progress of the running job, which is sent with every heartbeat.
+type ProgressTracker struct {
+	mu       sync.Mutex
+	jobID    string
+	file     string
+	lastFile string
+	current  *ports.Progress
+}
+
+// Start begins tracking a job. The file is polled on Current, it may be empty.
+func (t *ProgressTracker) Start(jobID string, file string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.jobID = jobID
+	t.file = file
+	t.lastFile = ""
+	t.current = nil
+}
+
+// Stop ends tracking, Current returns nil afterwards.
+func (t *ProgressTracker) Stop() {
+	t.Start("", "")
+}
+
+// Update sets the progress of the tracked job.
+func (t *ProgressTracker) Update(percent int, message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.update(percent, message)
+}
+
+func (t *ProgressTracker) update(percent int, message string) {
+	if t.jobID == "" {
+		return
+	}
+	t.current = &ports.Progress{JobID: t.jobID, Percent: percent, Message: message}
+}
+
+// Current returns the last reported progress or nil, if nothing was reported yet.
+// A changed progress file takes precedence over earlier stdout markers.
+func (t *ProgressTracker) Current() *ports.Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file != "" {
+		if data, err := os.ReadFile(t.file); err == nil && string(data) != t.lastFile {
+			t.lastFile = string(data)
+			if percent, message, ok := parseProgress(string(data)); ok {
+				t.update(percent, message)
+			}
+		}
+	}
+
+	if t.current == nil {
+		return nil
+	}
+	progress := *t.current
+	return &progress
+}
+
+// progressWriter passes container output through to out, except for progress marker lines.
+type progressWriter struct {
+	out        io.Writer
+	onProgress func(percent int, message string)
+	buf        []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush handles a last line without trailing newline.
+func (w *progressWriter) Flush() error {
+	line := w.buf
+	w.buf = nil
+	if len(line) == 0 {
+		return nil
+	}
+	return w.writeLine(line)
+}
+
+func (w *progressWriter) writeLine(line []byte) error {
+	text := strings.TrimRight(string(line), "\r\n")
+	if rest, ok := strings.CutPrefix(text, ProgressMarker); ok {
+		if percent, message, ok := parseProgress(rest); ok {
+			if w.onProgress != nil {
+				w.onProgress(percent, message)
+			}
+			return nil
+		}
+	}
+	_, err := w.out.Write(line)
+	return err
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		input   string
+		percent int
+		message string
+		ok      bool
+	}{
+		{" 42 epoch 3/10", 42, "epoch 3/10", true},
+		{"75%", 75, "", true},
+		{"150 too much", 100, "too much", true},
+		{"abc", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		percent, message, ok := parseProgress(tt.input)
+		if percent != tt.percent || message != tt.message || ok != tt.ok {
+			t.Errorf("parseProgress(%q) = %d, %q, %v", tt.input, percent, message, ok)
+		}
+	}
+}
+
+func TestProgressWriter_StripsMarkers(t *testing.T) {
+	var out bytes.Buffer
+	var reported []int
+	w := &progressWriter{out: &out, onProgress: func(percent int, message string) {
+		reported = append(reported, percent)
+	}}
+
+	w.Write([]byte("start\n##progress 10 loading\n##pro"))
+	w.Write([]byte("gress 90\ndone"))
+	w.Flush()
+
+	if out.String() != "start\ndone" {
+		t.Errorf("Expected output without markers, got %q", out.String())
+	}
+	if len(reported) != 2 || reported[0] != 10 || reported[1] != 90 {
+		t.Errorf("Expected progress 10 and 90, got %v", reported)
+	}
+}
+
+func TestProgressTracker_FileOverridesMarker(t *testing.T) {
+	file := filepath.Join(t.TempDir(), progressFileName)
+	var tracker ProgressTracker
+
+	if tracker.Current() != nil {
+		t.Fatal("Expected no progress before a job is started")
+	}
+
+	tracker.Start("job-1", file)
+	tracker.Update(10, "from stdout")
+	if p := tracker.Current(); p == nil || p.JobID != "job-1" || p.Percent != 10 {
+		t.Fatalf("Unexpected progress %+v", p)
+	}
+
+	os.WriteFile(file, []byte("55 from file\n"), 0o644)
+	if p := tracker.Current(); p == nil || p.Percent != 55 || p.Message != "from file" {
+		t.Fatalf("Unexpected progress %+v", p)
+	}
+
+	tracker.Stop()
+	if tracker.Current() != nil {
+		t.Error("Expected no progress after the job stopped")
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"worker-daemon/internal/ports"
+)
+
+// recoverContainer waits for the container of a job that was running when the daemon stopped,
+// collects its output and removes it. The job fails if the container is gone.
+func recoverContainer(job ports.Job, containerID string) ports.Job {
+	if containerID == "" {
+		job.Status = JobStatusError
+		job.Result = ""
+		job.ErrorMessage = "worker daemon restarted before the job container was created"
+		return job
+	}
+
+	// docker wait returns the exit code immediately if the container already exited
+	var waitErr bytes.Buffer
+	wait := exec.Command("docker", "wait", containerID)
+	wait.Stderr = &waitErr
+	out, err := wait.Output()
+	if err != nil {
+		job.Status = JobStatusError
+		job.Result = ""
+		job.ErrorMessage = fmt.Sprintf("job container lost after worker daemon restart: %v - %s", err, waitErr.String())
+		return job
+	}
+	exitCode := strings.TrimSpace(string(out))
+
+	var stdout, stderr bytes.Buffer
+	output := &progressWriter{out: &stdout}
+	logs := exec.Command("docker", "logs", containerID)
+	logs.Stdout = output
+	logs.Stderr = &stderr
+	logsErr := logs.Run()
+	output.Flush()
+	removeContainer(containerID)
+
+	switch {
+	case logsErr != nil:
+		job.Status = JobStatusError
+		job.Result = ""
+		job.ErrorMessage = fmt.Sprintf("reading job container output failed: %v", logsErr)
+	case exitCode != "0":
+		job.Status = JobStatusError
+		job.Result = ""
+		job.ErrorMessage = fmt.Sprintf("run image failed: exit status %s - %s", exitCode, stderr.String())
+	default:
+		job.Status = JobStatusDone
+		job.Result = stdout.String()
+		job.ErrorMessage = ""
+	}
+	return job
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"worker-daemon/internal/config"
+	"worker-daemon/internal/ports"
+)
+
+// runUntilResult startet den Heartbeat Loop, bis ein Ergebnis gesendet wurde, und hält ihn wieder an.
+// Der Loop kehrt erst nach der Zustellung zurück, das Journal ist danach geleert.
+func runUntilResult(t *testing.T, d *Daemon, api *DummyWorkerGateway) GatewayCalls {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.StartHeartbeatLoop(ctx)
+		close(done)
+	}()
+
+	sent := waitUntil(2*time.Second, func() bool { return len(api.Calls().ReceivedJobs) > 0 })
+	cancel()
+	<-done
+	if !sent {
+		t.Fatal("expected SendResult to be called")
+	}
+	return api.Calls()
+}
+
+func TestDaemon_DeliversOutboxFromJournal(t *testing.T) {
+	api := &DummyWorkerGateway{}
+	journal := &DummyJournal{State: ports.JournalState{
+		WorkerID: "worker-1",
+		Token:    "token-1",
+		Zone:     "DE",
+		Outbox:   []ports.Job{{ID: "job-1", Status: JobStatusDone, Result: "42", ResultID: "result-1"}},
+	}}
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, journal)
+
+	calls := runUntilResult(t, d, api)
+
+	if calls.RegisterCalled {
+		t.Error("expected journaled worker identity to be reused")
+	}
+	if calls.ReceivedJobs[0].ResultID != "result-1" || calls.ReceivedJobs[0].Result != "42" {
+		t.Errorf("expected journaled result to be resent, got %+v", calls.ReceivedJobs[0])
+	}
+	if len(journal.Current().Outbox) != 0 {
+		t.Error("expected result to be removed from journal after delivery")
+	}
+}
+
+func TestDaemon_JobWithoutContainerFailsAfterRestart(t *testing.T) {
+	api := &DummyWorkerGateway{}
+	journal := &DummyJournal{State: ports.JournalState{
+		WorkerID:   "worker-1",
+		Token:      "token-1",
+		Zone:       "DE",
+		CurrentJob: &ports.Job{ID: "job-1"},
+	}}
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, journal)
+
+	job := runUntilResult(t, d, api).ReceivedJobs[0]
+	if job.Status != JobStatusError || job.ErrorMessage == "" {
+		t.Errorf("expected ERROR with message, got %s %q", job.Status, job.ErrorMessage)
+	}
+	if journal.Current().CurrentJob != nil {
+		t.Error("expected job to be removed from journal")
+	}
+}
+
+func TestDaemon_ZoneChangeRegistersAgain(t *testing.T) {
+	api := &DummyWorkerGateway{}
+	journal := &DummyJournal{State: ports.JournalState{WorkerID: "worker-1", Token: "token-1", Zone: "FR"}}
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, journal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	d.StartHeartbeatLoop(ctx)
+
+	if !api.Calls().RegisterCalled {
+		t.Error("expected Register to be called")
+	}
+	if state := journal.Current(); state.WorkerID != "worker123" || state.Zone != "DE" {
+		t.Errorf("expected new identity in journal, got %+v", state)
+	}
+}
+
+func TestDaemon_ZoneChangeDeliversJobsOfOldIdentity(t *testing.T) {
+	api := &DummyWorkerGateway{}
+	journal := &DummyJournal{State: ports.JournalState{
+		WorkerID:   "worker-1",
+		Token:      "token-1",
+		Zone:       "FR",
+		CurrentJob: &ports.Job{ID: "job-2"},
+		Outbox:     []ports.Job{{ID: "job-1", Status: JobStatusDone, Result: "42", ResultID: "result-1"}},
+	}}
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, journal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	d.StartHeartbeatLoop(ctx)
+
+	calls := api.Calls()
+	if !calls.RegisterCalled {
+		t.Error("expected Register to be called")
+	}
+	// beide Ergebnisse gehen mit dem alten Token raus, bevor sich der Worker neu registriert
+	if len(calls.ReceivedJobs) != 2 || calls.ReceivedJobs[0].ID != "job-1" || calls.ReceivedJobs[1].ID != "job-2" {
+		t.Fatalf("expected results of job-1 and job-2, got %+v", calls.ReceivedJobs)
+	}
+	if calls.ReceivedJobs[1].Status != JobStatusError {
+		t.Errorf("expected recovered job without container to fail, got %s", calls.ReceivedJobs[1].Status)
+	}
+	for i, token := range calls.ResultTokens {
+		if token != "token-1" {
+			t.Errorf("expected result %d to be sent with the old token, got %q", i, token)
+		}
+	}
+	state := journal.Current()
+	if state.WorkerID != "worker123" || state.Zone != "DE" || state.CurrentJob != nil || len(state.Outbox) != 0 {
+		t.Errorf("expected new identity without jobs of the old one, got %+v", state)
+	}
+}
+
+func TestDaemon_ZoneChangeParksUndeliverableResults(t *testing.T) {
+	api := &DummyWorkerGateway{SendResultErr: errors.New("gateway unavailable")}
+	journal := &DummyJournal{State: ports.JournalState{
+		WorkerID: "worker-1",
+		Token:    "token-1",
+		Zone:     "FR",
+		Outbox:   []ports.Job{{ID: "job-1", Status: JobStatusDone, Result: "42", ResultID: "result-1"}},
+	}}
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 1, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, api, journal)
+	d.retryInitial = 10 * time.Millisecond
+	d.retryMax = 20 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	d.StartHeartbeatLoop(ctx)
+
+	calls := api.Calls()
+	if !calls.RegisterCalled {
+		t.Error("expected Register to be called after the result was parked")
+	}
+	// erster Versuch und zwei Retries mit dem alten Token, danach nichts mehr mit dem neuen
+	if len(calls.ReceivedJobs) != 3 {
+		t.Errorf("expected 3 delivery attempts, got %d", len(calls.ReceivedJobs))
+	}
+	for i, token := range calls.ResultTokens {
+		if token != "token-1" {
+			t.Errorf("expected attempt %d with the old token, got %q", i, token)
+		}
+	}
+	state := journal.Current()
+	if len(state.Outbox) != 0 || len(state.Rejected) != 1 || state.Rejected[0].ResultID != "result-1" {
+		t.Errorf("expected result to be parked, got outbox %+v rejected %+v", state.Outbox, state.Rejected)
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
+	"worker-daemon/internal/config"
+)
+
+// Reload applies the settings that can change while the daemon runs: the heartbeat interval,
+// the container resource limits and the power model. They apply from the next heartbeat and
+// the next job on, a running job keeps its limits. Other settings only change with a restart.
+// The image policy file is loaded by the caller, see SetImagePolicy.
+func (d *Daemon) Reload(cfg config.Config) {
+	d.cfgMu.Lock()
+	for _, name := range restartRequired(d.cfg, cfg) {
+		logging.Warn("Setting changes after a restart", "setting", name)
+	}
+	d.cfg.HeartbeatIntervalSeconds = cfg.HeartbeatIntervalSeconds
+	d.cfg.CPULimit = cfg.CPULimit
+	d.cfg.MemoryLimitMB = cfg.MemoryLimitMB
+	d.cfg.CPUWattsPerCore = cfg.CPUWattsPerCore
+	d.cfg.MemoryWattsPerGB = cfg.MemoryWattsPerGB
+	d.cfg.ImagePolicyFile = cfg.ImagePolicyFile
+	d.meter = newEnergyMeter(PowerModel{CPUWattsPerCore: cfg.CPUWattsPerCore, MemoryWattsPerGB: cfg.MemoryWattsPerGB})
+	d.cfgMu.Unlock()
+
+	select {
+	case d.reloaded <- struct{}{}:
+	default:
+	}
+	logging.Debug("Configuration reloaded")
+}
+
+// restartRequired returns the changed settings that are only read at startup.
+func restartRequired(old, cfg config.Config) []string {
+	var changed []string
+	for name, differs := range map[string]bool{
+		"gateway_url":  old.GatewayURL != cfg.GatewayURL,
+		"secret":       old.Secret != cfg.Secret,
+		"zone":         old.Zone != cfg.Zone,
+		"cache_dir":    old.CacheDir != cfg.CacheDir,
+		"journal_path": old.JournalPath != cfg.JournalPath,
+		"tls_dir":      old.TLSDir != cfg.TLSDir,
+		"ca_file":      old.CAFile != cfg.CAFile,
+		"status_addr":  old.StatusAddr != cfg.StatusAddr,
+	} {
+		if differs {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+func (d *Daemon) heartbeatInterval() time.Duration {
+	d.cfgMu.Lock()
+	defer d.cfgMu.Unlock()
+	return max(time.Duration(d.cfg.HeartbeatIntervalSeconds)*time.Second, time.Second)
+}
+
+// secret returns the provider key the worker registers and refreshes its token with.
+func (d *Daemon) secret() string {
+	d.cfgMu.Lock()
+	defer d.cfgMu.Unlock()
+	return d.cfg.Secret
+}
+
+// SetImagePolicy makes the daemon reject jobs whose image does not comply with the policy.
+// A nil policy allows all images.
+func (d *Daemon) SetImagePolicy(policy *ImagePolicy) {
+	d.cfgMu.Lock()
+	defer d.cfgMu.Unlock()
+	d.imagePolicy = policy
+}
+
+func (d *Daemon) currentImagePolicy() *ImagePolicy {
+	d.cfgMu.Lock()
+	defer d.cfgMu.Unlock()
+	return d.imagePolicy
+}
+
+// jobSettings returns the reloadable settings a new job is started with.
+func (d *Daemon) jobSettings() (float64, int, *energyMeter) {
+	d.cfgMu.Lock()
+	defer d.cfgMu.Unlock()
+	return d.cfg.CPULimit, d.cfg.MemoryLimitMB, d.meter
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+
+	"worker-daemon/internal/config"
+)
+
+func TestReload_AppliesRuntimeSettings(t *testing.T) {
+	cfg := config.Config{Zone: "DE", HeartbeatIntervalSeconds: 10, CacheDir: t.TempDir()}
+	d := NewDaemon(cfg, &DummyWorkerGateway{}, &DummyJournal{})
+
+	reloaded := cfg
+	reloaded.HeartbeatIntervalSeconds = 2
+	reloaded.CPULimit = 1.5
+	reloaded.MemoryLimitMB = 512
+	reloaded.Zone = "FR"
+	d.Reload(reloaded)
+
+	if d.heartbeatInterval().Seconds() != 2 {
+		t.Errorf("Expected heartbeat interval of 2s, got %v", d.heartbeatInterval())
+	}
+	cpuLimit, memoryLimitMB, _ := d.jobSettings()
+	if cpuLimit != 1.5 || memoryLimitMB != 512 {
+		t.Errorf("Expected new limits for the next job, got %v and %d", cpuLimit, memoryLimitMB)
+	}
+	// die Zone ändert sich erst mit einem Neustart
+	if d.cfg.Zone != "DE" {
+		t.Errorf("Expected zone to stay DE, got %s", d.cfg.Zone)
+	}
+
+	// Heartbeat Loop wird über das neue Intervall informiert
+	select {
+	case <-d.reloaded:
+	default:
+		t.Error("Expected heartbeat loop to be signalled")
+	}
+}
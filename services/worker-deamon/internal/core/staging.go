@@ -0,0 +1,188 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
+	"worker-daemon/internal/ports"
+)
+
+// InputMountPath is the directory inside the container where the input files of a job are mounted.
+const InputMountPath = "/input"
+
+// InputStager downloads the input files of a job into a cache keyed by their SHA-256,
+// so jobs on the same worker share downloads, and links them into a per job directory.
+type InputStager struct {
+	cacheDir   string
+	httpClient *http.Client
+	// fetchUploaded downloads an uploaded input file from the gateway
+	fetchUploaded func(jobID string, name string) (io.ReadCloser, error)
+}
+
+func NewInputStager(cacheDir string, fetchUploaded func(jobID string, name string) (io.ReadCloser, error)) *InputStager {
+	return &InputStager{
+		cacheDir:      cacheDir,
+		httpClient:    &http.Client{Timeout: 10 * time.Minute},
+		fetchUploaded: fetchUploaded,
+	}
+}
+
+// Stage makes all input files of the job available in a per job directory and returns it.
+// An empty directory name is returned if the job has no input files.
+func (s *InputStager) Stage(job ports.Job) (string, error) {
+	if len(job.InputFiles) == 0 {
+		return "", nil
+	}
+
+	jobDir := filepath.Join(s.cacheDir, "jobs", job.ID)
+	if err := os.MkdirAll(jobDir, 0o755); err != nil {
+		return "", err
+	}
+
+	for _, file := range job.InputFiles {
+		if file.Name != filepath.Base(file.Name) || strings.HasPrefix(file.Name, ".") {
+			s.Cleanup(jobDir)
+			return "", fmt.Errorf("invalid input file name %q", file.Name)
+		}
+		cached, err := s.cache(job.ID, file)
+		if err != nil {
+			s.Cleanup(jobDir)
+			return "", fmt.Errorf("input file %s: %w", file.Name, err)
+		}
+		if err := linkOrCopy(cached, filepath.Join(jobDir, file.Name)); err != nil {
+			s.Cleanup(jobDir)
+			return "", fmt.Errorf("input file %s: %w", file.Name, err)
+		}
+	}
+	return jobDir, nil
+}
+
+// Cleanup removes a job directory created by Stage. The cache is kept for later jobs.
+func (s *InputStager) Cleanup(jobDir string) {
+	if jobDir == "" {
+		return
+	}
+	if err := os.RemoveAll(jobDir); err != nil {
+		logging.Warn("Removing input directory failed", "error", err)
+	}
+}
+
+// cache returns the path of the verified file in the cache, fetching it if it is not cached yet.
+func (s *InputStager) cache(jobID string, file ports.InputFile) (string, error) {
+	checksum := strings.ToLower(file.Checksum)
+	if len(checksum) != sha256.Size*2 {
+		return "", fmt.Errorf("missing SHA-256 checksum")
+	}
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", fmt.Errorf("invalid SHA-256 checksum")
+	}
+
+	path := filepath.Join(s.cacheDir, checksum)
+	if _, err := os.Stat(path); err == nil {
+		// the file may have been changed on disk since it was verified, it is downloaded again then
+		if actual, err := fileChecksum(path); err == nil && actual == checksum {
+			return path, nil
+		}
+		logging.Warn("Cached input file does not match its checksum, downloading it again", "checksum", checksum)
+		if err := os.Remove(path); err != nil {
+			return "", err
+		}
+	}
+	if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
+		return "", err
+	}
+
+	var src io.Reader
+	if file.Uploaded {
+		content, err := s.fetchUploaded(jobID, file.Name)
+		if err != nil {
+			return "", err
+		}
+		defer content.Close()
+		src = content
+	} else {
+		resp, err := s.httpClient.Get(file.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("download failed: %s", resp.Status)
+		}
+		src = resp.Body
+	}
+
+	// write to a temporary file first, so the cache only ever contains verified files
+	tmp, err := os.CreateTemp(s.cacheDir, "download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != checksum {
+		return "", fmt.Errorf("checksum mismatch: expected %s, got %s", checksum, actual)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// fileChecksum returns the hex encoded SHA-256 of the file.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// linkOrCopy hard links src to dst and falls back to copying, e.g. across file systems.
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"worker-daemon/internal/config"
+	"worker-daemon/internal/ports"
+)
+
+// SHA-256 von "hello"
+const helloChecksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestInputStager_DownloadIsVerifiedAndCached(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	stager := NewInputStager(t.TempDir(), nil)
+	file := ports.InputFile{Name: "hello.txt", URL: server.URL + "/hello.txt", Checksum: helloChecksum}
+
+	for _, id := range []string{"job-1", "job-2"} {
+		dir, err := stager.Stage(ports.Job{ID: id, InputFiles: []ports.InputFile{file}})
+		if err != nil {
+			t.Fatalf("Stage failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(dir, "hello.txt"))
+		if err != nil || string(content) != "hello" {
+			t.Errorf("Expected staged file with content 'hello', got %q (%v)", content, err)
+		}
+		stager.Cleanup(dir)
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Expected job directory to be removed")
+		}
+	}
+
+	// Zweiter Job muss aus dem Cache bedient werden
+	if downloads != 1 {
+		t.Errorf("Expected 1 download, got %d", downloads)
+	}
+}
+
+func TestInputStager_ChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered"))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	stager := NewInputStager(cacheDir, nil)
+	job := ports.Job{ID: "job-1", InputFiles: []ports.InputFile{
+		{Name: "hello.txt", URL: server.URL, Checksum: helloChecksum},
+	}}
+
+	if _, err := stager.Stage(job); err == nil {
+		t.Fatal("Expected checksum error, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, helloChecksum)); !os.IsNotExist(err) {
+		t.Errorf("Expected unverified file not to be cached")
+	}
+}
+
+func TestInputStager_CorruptedCacheIsDownloadedAgain(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	// Datei im Cache wurde nach der Prüfung verändert
+	if err := os.WriteFile(filepath.Join(cacheDir, helloChecksum), []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stager := NewInputStager(cacheDir, nil)
+	job := ports.Job{ID: "job-1", InputFiles: []ports.InputFile{
+		{Name: "hello.txt", URL: server.URL, Checksum: helloChecksum},
+	}}
+
+	dir, err := stager.Stage(job)
+	if err != nil {
+		t.Fatalf("Stage failed: %v", err)
+	}
+	defer stager.Cleanup(dir)
+
+	content, _ := os.ReadFile(filepath.Join(dir, "hello.txt"))
+	if string(content) != "hello" || downloads != 1 {
+		t.Errorf("Expected file to be downloaded again, got %q after %d downloads", content, downloads)
+	}
+}
+
+func TestInputStager_InvalidNames(t *testing.T) {
+	stager := NewInputStager(t.TempDir(), func(jobID, name string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("hello")), nil
+	})
+
+	// dieselben Namen lehnt der Job Service schon beim Anlegen ab
+	for _, name := range []string{".env", "..", "dir/hello.txt"} {
+		job := ports.Job{ID: "job-1", InputFiles: []ports.InputFile{
+			{Name: name, Uploaded: true, Checksum: helloChecksum},
+		}}
+		if _, err := stager.Stage(job); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+
+	job := ports.Job{ID: "job-1", InputFiles: []ports.InputFile{
+		{Name: "data.tar.gz", Uploaded: true, Checksum: helloChecksum},
+	}}
+	dir, err := stager.Stage(job)
+	if err != nil {
+		t.Fatalf("Expected name with inner dots to be staged, got %v", err)
+	}
+	stager.Cleanup(dir)
+}
+
+func TestInputStager_UploadedContent(t *testing.T) {
+	var fetched []string
+	stager := NewInputStager(t.TempDir(), func(jobID, name string) (io.ReadCloser, error) {
+		fetched = append(fetched, jobID+"/"+name)
+		return io.NopCloser(strings.NewReader("hello")), nil
+	})
+	job := ports.Job{ID: "job-1", InputFiles: []ports.InputFile{
+		{Name: "hello.txt", Uploaded: true, Checksum: helloChecksum},
+	}}
+
+	dir, err := stager.Stage(job)
+	if err != nil {
+		t.Fatalf("Stage failed: %v", err)
+	}
+	defer stager.Cleanup(dir)
+
+	content, _ := os.ReadFile(filepath.Join(dir, "hello.txt"))
+	if string(content) != "hello" {
+		t.Errorf("Expected content 'hello', got %q", content)
+	}
+	// der Inhalt wird beim Gateway für den Job geladen
+	if len(fetched) != 1 || fetched[0] != "job-1/hello.txt" {
+		t.Errorf("Expected hello.txt of job-1 to be fetched once, got %v", fetched)
+	}
+}
+
+func TestFetchInputFile_RefreshesToken(t *testing.T) {
+	api := &DummyWorkerGateway{ValidToken: "token-new", RefreshedToken: "token-new", InputFiles: map[string]string{"hello.txt": "hello"}}
+	d := NewDaemon(config.Config{CacheDir: t.TempDir()}, api, &DummyJournal{})
+	d.token = "token-old"
+
+	dir, err := d.stager.Stage(ports.Job{ID: "job-1", InputFiles: []ports.InputFile{
+		{Name: "hello.txt", Uploaded: true, Checksum: helloChecksum},
+	}})
+	if err != nil {
+		t.Fatalf("Stage failed: %v", err)
+	}
+	defer d.stager.Cleanup(dir)
+
+	content, _ := os.ReadFile(filepath.Join(dir, "hello.txt"))
+	if string(content) != "hello" || api.RefreshCalls != 1 {
+		t.Errorf("Expected content 'hello' after one refresh, got %q and %d refreshes", content, api.RefreshCalls)
+	}
+}
+
+func TestRunJob_StagingFails(t *testing.T) {
+	d := NewDaemon(config.Config{CacheDir: t.TempDir()}, &DummyWorkerGateway{}, &DummyJournal{})
+	job := ports.Job{ID: "job-1", InputFiles: []ports.InputFile{
+		{Name: "hello.txt", Uploaded: true, Checksum: "invalid"},
+	}}
+
+	result := d.runJob(job)
+	if result.Status != "ERROR" || result.ErrorMessage == "" {
+		t.Errorf("Expected ERROR with message, got %s %q", result.Status, result.ErrorMessage)
+	}
+}
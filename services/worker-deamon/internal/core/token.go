@@ -0,0 +1,94 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
+	"worker-daemon/internal/ports"
+)
+
+// refreshBefore is how long before its expiry the token is refreshed
+const refreshBefore = 5 * time.Minute
+
+func (d *Daemon) currentToken() string {
+	d.tokenMu.Lock()
+	defer d.tokenMu.Unlock()
+	return d.token
+}
+
+// refreshToken replaces the stale token with a new one for the same worker ID.
+// Callers that hit the same stale token concurrently only refresh once, other calls keep
+// using the current token while the refresh is running.
+func (d *Daemon) refreshToken(stale string) error {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	if d.currentToken() != stale {
+		return nil
+	}
+
+	token, err := d.api.RefreshToken(d.secret(), stale)
+	if err != nil {
+		return err
+	}
+	d.tokenMu.Lock()
+	d.token = token
+	d.tokenMu.Unlock()
+	d.updateJournal(func(state *ports.JournalState) {
+		state.Token = token
+	})
+	logging.Debug("Token refreshed")
+	return nil
+}
+
+// withToken calls the gateway and retries once with a refreshed token if the token was rejected.
+func (d *Daemon) withToken(call func(token string) error) error {
+	token := d.currentToken()
+	err := call(token)
+	if errors.Is(err, ports.ErrUnauthorized) {
+		if refreshErr := d.refreshToken(token); refreshErr != nil {
+			return fmt.Errorf("token refresh failed: %w", refreshErr)
+		}
+		err = call(d.currentToken())
+	}
+	if err == nil {
+		d.metrics.contact()
+	}
+	return err
+}
+
+// refreshIfExpiring refreshes the token shortly before it expires, so calls do not fail in between.
+func (d *Daemon) refreshIfExpiring() {
+	token := d.currentToken()
+	expiresAt, ok := tokenExpiry(token)
+	if !ok || time.Until(expiresAt) > refreshBefore {
+		return
+	}
+	if err := d.refreshToken(token); err != nil {
+		logging.Warn("Token refresh failed", "error", err)
+	}
+}
+
+// tokenExpiry reads the expiry of a JWT without verifying it, the gateway verifies the token.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"worker-daemon/internal/config"
+	"worker-daemon/internal/ports"
+)
+
+// jwtExpiringAt baut ein unsigniertes JWT, der Daemon liest nur exp
+func jwtExpiringAt(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJIUzI1NiJ9." + payload + ".signature"
+}
+
+func TestWithToken_RefreshesOnUnauthorized(t *testing.T) {
+	api := &DummyWorkerGateway{ValidToken: "token-new", RefreshedToken: "token-new"}
+	journal := &DummyJournal{}
+	d := NewDaemon(config.Config{}, api, journal)
+	d.token = "token-old"
+
+	err := d.withToken(func(token string) error {
+		return api.SendResult(ports.Job{ID: "job-1"}, token)
+	})
+	if err != nil {
+		t.Fatalf("Expected result to be sent after refresh, got %v", err)
+	}
+	if api.RefreshCalls != 1 || len(api.ReceivedJobs) != 1 {
+		t.Errorf("Expected one refresh and one delivered result, got %d and %d", api.RefreshCalls, len(api.ReceivedJobs))
+	}
+	// das neue Token überlebt einen Neustart
+	if journal.State.Token != "token-new" {
+		t.Errorf("Expected refreshed token in journal, got %q", journal.State.Token)
+	}
+
+	// weitere Aufrufe verwenden das neue Token ohne erneuten Refresh
+	d.withToken(func(token string) error {
+		return api.SendResult(ports.Job{ID: "job-2"}, token)
+	})
+	if api.RefreshCalls != 1 {
+		t.Errorf("Expected no second refresh, got %d", api.RefreshCalls)
+	}
+}
+
+func TestRefreshToken_Concurrent(t *testing.T) {
+	api := &DummyWorkerGateway{RefreshedToken: "token-new", RefreshDelay: 200 * time.Millisecond}
+	d := NewDaemon(config.Config{}, api, &DummyJournal{})
+	d.token = "token-old"
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.refreshToken("token-old"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// während des Refreshs bleibt das alte Token lesbar
+	time.Sleep(50 * time.Millisecond)
+	read := make(chan string)
+	go func() { read <- d.currentToken() }()
+	select {
+	case token := <-read:
+		if token != "token-old" {
+			t.Errorf("Expected old token during the refresh, got %q", token)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("Expected currentToken not to wait for the refresh")
+	}
+
+	wg.Wait()
+	if calls := api.Calls().RefreshCalls; calls != 1 {
+		t.Errorf("Expected one refresh for concurrent callers, got %d", calls)
+	}
+	if token := d.currentToken(); token != "token-new" {
+		t.Errorf("Expected refreshed token, got %q", token)
+	}
+}
+
+func TestRefreshIfExpiring(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		refreshed bool
+	}{
+		{"expires soon", jwtExpiringAt(time.Now().Add(time.Minute)), true},
+		{"expired", jwtExpiringAt(time.Now().Add(-time.Minute)), true},
+		{"valid for long", jwtExpiringAt(time.Now().Add(time.Hour)), false},
+		{"no JWT", "token123", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			api := &DummyWorkerGateway{RefreshedToken: "token-new"}
+			d := NewDaemon(config.Config{}, api, &DummyJournal{})
+			d.token = tc.token
+
+			d.refreshIfExpiring()
+			if refreshed := d.currentToken() == "token-new"; refreshed != tc.refreshed {
+				t.Errorf("Expected refreshed %v, got token %q", tc.refreshed, d.currentToken())
+			}
+		})
+	}
+}
@@ -1,28 +1,93 @@
 package ports
 
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrUnauthorized is returned by the gateway calls if the token was rejected
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrRejected matches a RejectedError with errors.Is
+var ErrRejected = errors.New("rejected by the gateway")
+
+// RejectedError is returned by the gateway calls if the gateway refused the request itself,
+// e.g. because the job is no longer assigned to the worker. Retrying the same request does not help.
+type RejectedError struct {
+	StatusCode int
+}
+
+func (e *RejectedError) Error() string {
+	return "rejected by the gateway: " + http.StatusText(e.StatusCode)
+}
+
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
+
 type WorkerGateway interface {
 	Register(key string, zone string) (*RegisterResponse, error)
-	SendHeartbeat(workerID string, status string, token string) ([]Job, error)
+	// RefreshToken returns a new token for the worker of the given, possibly expired, token
+	RefreshToken(key string, token string) (string, error)
+	// SendHeartbeat reports the status, the progress of the running job and the images docker has locally
+	SendHeartbeat(workerID string, status string, progress *Progress, cachedImages []string, token string) ([]Job, error)
 	SendResult(j Job, token string) error
+	// NextJobs waits until jobs are assigned to the worker, it returns no jobs after the poll timeout
+	NextJobs(workerID string, token string) ([]Job, error)
+	// FetchInputFile downloads an uploaded input file of a job assigned to the worker, the caller closes it
+	FetchInputFile(jobID string, name string, token string) (io.ReadCloser, error)
 }
 
 type Job struct {
 	ID                   string            `json:"id"`
 	Image                ContainerImage    `json:"image"`
 	AdjustmentParameters map[string]string `json:"adjustmentParameters"`
+	InputFiles           []InputFile       `json:"inputFiles"`
 	Status               string            `json:"status"`
 	Result               string            `json:"result"`
 	ErrorMessage         string            `json:"errorMessage"`
+	Energy               *EnergyReport     `json:"energy,omitempty"`
+	ResultID             string            `json:"resultId,omitempty"` // set once the job finished, identifies retried deliveries
+}
+
+// EnergyReport is the measured resource usage and energy of a job execution
+type EnergyReport struct {
+	EnergyWh        float64 `json:"energyWh"`
+	CPUSeconds      float64 `json:"cpuSeconds"`
+	MemoryGBSeconds float64 `json:"memoryGbSeconds"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Method          string  `json:"method"` // rapl, cgroup-model or duration-model
+}
+
+// Progress of the running job, sent with the heartbeat
+type Progress struct {
+	JobID   string `json:"jobId"`
+	Percent int    `json:"percent"`
+	Message string `json:"message,omitempty"`
 }
 
 type RegisterResponse struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
-	Zone   string `json:"zone"`
-	Token  string `json:"token"`
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	Zone        string `json:"zone"`
+	Token       string `json:"token"`
+	Certificate string `json:"certificate,omitempty"` // client certificate for mutual TLS, if requested
 }
 
 type ContainerImage struct {
-	Name    string `json:"name" db:"image_name"`
-	Version string `json:"version" db:"image_version"`
+	Name      string `json:"name" db:"image_name"`
+	Version   string `json:"version" db:"image_version"`
+	Digest    string `json:"digest,omitempty" db:"image_digest"`       // pins the image, docker verifies it on pull
+	Signature string `json:"signature,omitempty" db:"image_signature"` // verified against the image policy
+}
+
+// InputFile is mounted into the container under /input before the job runs.
+// Either URL is set or the file was Uploaded and is downloaded with FetchInputFile,
+// Checksum is the hex encoded SHA-256.
+type InputFile struct {
+	Name     string `json:"name"`
+	URL      string `json:"url,omitempty"`
+	Uploaded bool   `json:"uploaded,omitempty"`
+	Checksum string `json:"checksum"`
 }
@@ -0,0 +1,18 @@
+package ports
+
+// JournalState is the daemon state that has to survive a restart
+type JournalState struct {
+	WorkerID    string `json:"workerId"`
+	Token       string `json:"token"`
+	Zone        string `json:"zone"`
+	CurrentJob  *Job   `json:"currentJob,omitempty"`  // job that is running
+	ContainerID string `json:"containerId,omitempty"` // container of the running job
+	Outbox      []Job  `json:"outbox,omitempty"`      // finished jobs whose results are not delivered yet
+	Rejected    []Job  `json:"rejected,omitempty"`    // results the gateway refused, kept for inspection
+}
+
+type Journal interface {
+	// Load returns the last saved state, or an empty state if nothing was saved yet
+	Load() (JournalState, error)
+	Save(state JournalState) error
+}
@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// Health of the daemon, served on /healthz
+type Health struct {
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason,omitempty"`
+	WorkerID    string    `json:"workerId,omitempty"`
+	RunningJob  string    `json:"runningJob,omitempty"`
+	LastContact time.Time `json:"lastContact,omitzero"`
+}
+
+// StatusSource provides what the local status endpoint serves
+type StatusSource interface {
+	Health() Health
+	// WriteMetrics writes the metrics in the Prometheus text format
+	WriteMetrics(w io.Writer) error
+}
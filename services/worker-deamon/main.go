@@ -2,39 +2,96 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+
 	"worker-daemon/internal/adapters/gateway"
+	"worker-daemon/internal/adapters/journal"
+	"worker-daemon/internal/adapters/status"
 	"worker-daemon/internal/config"
 	worker "worker-daemon/internal/core"
 )
 
 func main() {
-	cfg, err := config.LoadConfig("config.json")
+	logging.Init("worker-daemon")
+
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Fatal("Failed to load config:", err)
+		logging.Error("Failed to load config", "error", err)
+		os.Exit(1)
 	}
 
 	client := gateway.NewClient(cfg.GatewayURL)
-	daemon := worker.NewDaemon(*cfg, client)
+	if cfg.TLSDir != "" {
+		if err := client.EnableMTLS(cfg.TLSDir, cfg.CAFile); err != nil {
+			logging.Error("Failed to enable mutual TLS", "error", err)
+			os.Exit(1)
+		}
+	}
+	daemon := worker.NewDaemon(*cfg, client, journal.NewFileJournal(cfg.JournalPath))
+	if err := loadImagePolicy(daemon, cfg.ImagePolicyFile); err != nil {
+		logging.Error("Failed to load image policy", "error", err)
+		os.Exit(1)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if cfg.StatusAddr != "" {
+		srv := &http.Server{Addr: cfg.StatusAddr, Handler: status.NewHandler(daemon)}
+		go func() {
+			logging.Debug("Status endpoint listening", "addr", cfg.StatusAddr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logging.Error("Status endpoint failed", "error", err)
+			}
+		}()
+		defer srv.Close()
+	}
+
 	go daemon.StartHeartbeatLoop(ctx)
 
-	// Graceful shutdown handling
+	// Graceful shutdown handling, SIGHUP reloads the config
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
-	log.Println("Shutting down daemon...")
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloaded, err := config.Load(os.Args[1:])
+		if err != nil {
+			logging.Warn("Reloading config failed, keeping the current settings", "error", err)
+			continue
+		}
+		if err := loadImagePolicy(daemon, reloaded.ImagePolicyFile); err != nil {
+			logging.Warn("Reloading image policy failed, keeping the current settings", "error", err)
+			continue
+		}
+		daemon.Reload(*reloaded)
+	}
+	logging.Debug("Shutting down daemon...")
 
 	cancel()
 
-	log.Println("Shutdown complete")
+	logging.Debug("Shutdown complete")
 
 	//select {} // Block forever
 }
+
+// loadImagePolicy sets the image policy in file on the daemon, no file allows all images.
+func loadImagePolicy(daemon *worker.Daemon, file string) error {
+	if file == "" {
+		daemon.SetImagePolicy(nil)
+		return nil
+	}
+	policy, err := worker.LoadImagePolicy(file)
+	if err != nil {
+		return err
+	}
+	daemon.SetImagePolicy(policy)
+	return nil
+}
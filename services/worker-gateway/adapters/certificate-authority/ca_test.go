@@ -0,0 +1,76 @@
+package certificate_authority_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	certificate_authority "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/certificate-authority"
+	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
+)
+
+// newCSR returns a PEM encoded CSR, the key stays with the worker
+func newCSR(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestLoadOrCreate_KeepsCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+
+	created, err := certificate_authority.LoadOrCreate(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	loaded, err := certificate_authority.LoadOrCreate(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !bytes.Equal(created.CertificatePEM(), loaded.CertificatePEM()) {
+		t.Error("expected the CA to be reused after a restart")
+	}
+}
+
+func TestIssueClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := certificate_authority.LoadOrCreate(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, err := ca.IssueClientCertificate(newCSR(t), "worker1")
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "worker1" {
+		t.Errorf("expected subject worker1, got %s", cert.Subject.CommonName)
+	}
+	_, err = cert.Verify(x509.VerifyOptions{Roots: ca.CertPool(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		t.Errorf("expected client certificate to verify against the CA: %v", err)
+	}
+
+	if _, err := ca.IssueClientCertificate([]byte("no csr"), "worker1"); !errors.Is(err, ports.ErrInvalidCSR) {
+		t.Errorf("expected ErrInvalidCSR, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package client_http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
+)
+
+// statusError maps an unexpected response of another service to the errors in ports.
+// notFound is returned for 404, it depends on what was requested.
+func statusError(action string, resp *http.Response, notFound error) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var err error
+	switch {
+	case resp.StatusCode == http.StatusBadRequest:
+		err = ports.ErrInvalidRequest
+	case resp.StatusCode == http.StatusUnauthorized:
+		err = ports.ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		err = ports.ErrForbidden
+	case resp.StatusCode == http.StatusNotFound && notFound != nil:
+		err = notFound
+	default:
+		err = ports.ErrServiceUnavailable
+	}
+	return fmt.Errorf("%s failed: %w (status %d: %s)", action, err, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// requestError wraps an error of a request that got no response
+func requestError(action string, err error) error {
+	return fmt.Errorf("%s failed: %w (%v)", action, ports.ErrServiceUnavailable, err)
+}
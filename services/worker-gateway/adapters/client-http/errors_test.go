@@ -0,0 +1,83 @@
+package client_http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	client_http "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/client-http"
+	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
+)
+
+func init() {
+	logging.Init("worker-gateway-test")
+}
+
+func respondWith(status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, http.StatusText(status), status)
+	}))
+}
+
+func TestClients_StatusMapping(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected error
+	}{
+		{http.StatusBadRequest, ports.ErrInvalidRequest},
+		{http.StatusUnauthorized, ports.ErrUnauthorized},
+		{http.StatusForbidden, ports.ErrForbidden},
+		{http.StatusInternalServerError, ports.ErrServiceUnavailable},
+		{http.StatusServiceUnavailable, ports.ErrServiceUnavailable},
+	}
+
+	for _, tc := range tests {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			server := respondWith(tc.status)
+			defer server.Close()
+			ctx := context.Background()
+
+			if _, err := client_http.NewJobClient(server.URL).FetchJob(ctx, "job1", "token"); !errors.Is(err, tc.expected) {
+				t.Errorf("FetchJob: expected %v, got %v", tc.expected, err)
+			}
+			if err := client_http.NewRegistryClient(server.URL).UpdateWorkerStatus(ctx, ports.HeartbeatRequest{WorkerID: "worker1"}, "token"); !errors.Is(err, tc.expected) {
+				t.Errorf("UpdateWorkerStatus: expected %v, got %v", tc.expected, err)
+			}
+			if _, err := client_http.NewUserClient(server.URL).GetToken(ctx, ports.GetTokenRequest{Secret: "key"}); !errors.Is(err, tc.expected) {
+				t.Errorf("GetToken: expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestClients_NotFound(t *testing.T) {
+	server := respondWith(http.StatusNotFound)
+	defer server.Close()
+	ctx := context.Background()
+
+	if _, err := client_http.NewJobClient(server.URL).FetchJob(ctx, "job1", "token"); !errors.Is(err, ports.ErrJobNotFound) {
+		t.Errorf("FetchJob: expected ErrJobNotFound, got %v", err)
+	}
+	if err := client_http.NewJobClient(server.URL).UpdateJob(ctx, ports.ResultRequest{JobID: "job1"}, "token"); !errors.Is(err, ports.ErrJobNotFound) {
+		t.Errorf("UpdateJob: expected ErrJobNotFound, got %v", err)
+	}
+	if err := client_http.NewRegistryClient(server.URL).UpdateWorkerStatus(ctx, ports.HeartbeatRequest{WorkerID: "worker1"}, "token"); !errors.Is(err, ports.ErrWorkerNotFound) {
+		t.Errorf("UpdateWorkerStatus: expected ErrWorkerNotFound, got %v", err)
+	}
+	// a missing route is an error of the service, not a missing worker
+	if _, err := client_http.NewRegistryClient(server.URL).RegisterWorker(ctx, ports.RegisterRequest{Zone: "DE"}, "token"); !errors.Is(err, ports.ErrServiceUnavailable) {
+		t.Errorf("RegisterWorker: expected ErrServiceUnavailable, got %v", err)
+	}
+}
+
+func TestClients_Unreachable(t *testing.T) {
+	server := respondWith(http.StatusOK)
+	server.Close()
+
+	if _, err := client_http.NewJobClient(server.URL).FetchScheduledJobs(context.Background(), "worker1", "token"); !errors.Is(err, ports.ErrServiceUnavailable) {
+		t.Errorf("expected ErrServiceUnavailable, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
@@ -24,11 +25,17 @@ func NewJobClient(baseURL string) *JobClient {
 func (c *JobClient) UpdateJob(ctx context.Context, req ports.ResultRequest, token string) error {
 	url := fmt.Sprintf("%s/jobs/%s/update-workerdaemon", c.BaseURL, req.JobID)
 
-	payload := map[string]string{
+	payload := map[string]any{
 		"status":       req.Status,
 		"result":       req.Result,
 		"errorMessage": req.ErrorMessage,
 	}
+	if req.Energy != nil {
+		payload["energy"] = req.Energy
+	}
+	if req.ResultID != "" {
+		payload["resultId"] = req.ResultID
+	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		logging.From(ctx).Error("Failed to marshal job update payload", "jobID", req.JobID, "error", err)
@@ -48,26 +55,69 @@ func (c *JobClient) UpdateJob(ctx context.Context, req ports.ResultRequest, toke
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		logging.From(ctx).Error("HTTP request failed during job update", "jobID", req.JobID, "error", err)
-		return err
+		return requestError("update job", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		respBody, _ := io.ReadAll(resp.Body)
-		logging.From(ctx).Warn("Unexpected response during job update", "jobID", req.JobID, "status", resp.StatusCode, "response", string(respBody))
-		return fmt.Errorf("update job failed: %s", respBody)
+		err := statusError("update job", resp, ports.ErrJobNotFound)
+		logging.From(ctx).Warn("Unexpected response during job update", "jobID", req.JobID, "status", resp.StatusCode, "error", err)
+		return err
 	}
 
 	logging.From(ctx).Debug("Job updated successfully", "jobID", req.JobID, "status", req.Status)
 	return nil
 }
 
-func (c *JobClient) FetchScheduledJobs(ctx context.Context, token string) ([]ports.Job, error) {
-	url := fmt.Sprintf("%s/jobs?status=scheduled", c.BaseURL)
+func (c *JobClient) UpdateProgress(ctx context.Context, progress ports.JobProgress, token string) error {
+	url := fmt.Sprintf("%s/jobs/%s/update-progress", c.BaseURL, progress.JobID)
+
+	payload := map[string]any{
+		"percent": progress.Percent,
+		"message": progress.Message,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logging.From(ctx).Error("Failed to marshal job progress payload", "jobID", progress.JobID, "error", err)
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		logging.From(ctx).Error("Failed to create job progress request", "jobID", progress.JobID, "error", err)
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	logging.From(ctx).Debug("Sending job progress", "jobID", progress.JobID, "percent", progress.Percent)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		logging.From(ctx).Error("HTTP request failed during job progress update", "jobID", progress.JobID, "error", err)
+		return requestError("update job progress", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := statusError("update job progress", resp, ports.ErrJobNotFound)
+		logging.From(ctx).Warn("Unexpected response during job progress update", "jobID", progress.JobID, "status", resp.StatusCode, "error", err)
+		return err
+	}
+
+	logging.From(ctx).Debug("Job progress updated", "jobID", progress.JobID, "percent", progress.Percent)
+	return nil
+}
+
+func (c *JobClient) FetchScheduledJobs(ctx context.Context, workerID string, token string) ([]ports.Job, error) {
+	query := url.Values{}
+	query.Set("status", "scheduled")
+	query.Set("workerId", workerID)
+	endpoint := fmt.Sprintf("%s/jobs?%s", c.BaseURL, query.Encode())
 
-	logging.From(ctx).Debug("Fetching scheduled jobs", "url", url)
+	logging.From(ctx).Debug("Fetching scheduled jobs", "url", endpoint)
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		logging.From(ctx).Error("Failed to create request for fetching jobs", "error", err)
 		return nil, err
@@ -78,7 +128,7 @@ func (c *JobClient) FetchScheduledJobs(ctx context.Context, token string) ([]por
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		logging.From(ctx).Error("HTTP request failed during job fetch", "error", err)
-		return nil, err
+		return nil, requestError("fetch scheduled jobs", err)
 	}
 	defer resp.Body.Close()
 
@@ -88,9 +138,9 @@ func (c *JobClient) FetchScheduledJobs(ctx context.Context, token string) ([]por
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		logging.From(ctx).Warn("Unexpected response when fetching jobs", "status", resp.StatusCode, "response", string(respBody))
-		return nil, fmt.Errorf("fetch scheduled jobs failed: %s", respBody)
+		err := statusError("fetch scheduled jobs", resp, nil)
+		logging.From(ctx).Warn("Unexpected response when fetching jobs", "status", resp.StatusCode, "error", err)
+		return nil, err
 	}
 
 	var jobs []ports.Job
@@ -102,3 +152,59 @@ func (c *JobClient) FetchScheduledJobs(ctx context.Context, token string) ([]por
 	logging.From(ctx).Debug("Scheduled jobs fetched", "count", len(jobs))
 	return jobs, nil
 }
+
+func (c *JobClient) FetchJob(ctx context.Context, jobID string, token string) (ports.Job, error) {
+	endpoint := fmt.Sprintf("%s/jobs/%s", c.BaseURL, url.PathEscape(jobID))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		logging.From(ctx).Error("Failed to create request for fetching job", "jobID", jobID, "error", err)
+		return ports.Job{}, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		logging.From(ctx).Error("HTTP request failed during job fetch", "jobID", jobID, "error", err)
+		return ports.Job{}, requestError("fetch job", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := statusError("fetch job", resp, ports.ErrJobNotFound)
+		logging.From(ctx).Warn("Unexpected response when fetching job", "jobID", jobID, "status", resp.StatusCode, "error", err)
+		return ports.Job{}, err
+	}
+
+	var job ports.Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		logging.From(ctx).Error("Failed to decode job response", "jobID", jobID, "error", err)
+		return ports.Job{}, err
+	}
+	return job, nil
+}
+
+func (c *JobClient) FetchInputFile(ctx context.Context, jobID string, name string, token string) (io.ReadCloser, error) {
+	endpoint := fmt.Sprintf("%s/jobs/%s/inputs/%s", c.BaseURL, url.PathEscape(jobID), url.PathEscape(name))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		logging.From(ctx).Error("Failed to create request for fetching input file", "jobID", jobID, "name", name, "error", err)
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		logging.From(ctx).Error("HTTP request failed during input file fetch", "jobID", jobID, "name", name, "error", err)
+		return nil, requestError("fetch input file", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		err := statusError("fetch input file", resp, ports.ErrInputFileNotFound)
+		logging.From(ctx).Warn("Unexpected response when fetching input file", "jobID", jobID, "name", name, "status", resp.StatusCode, "error", err)
+		return nil, err
+	}
+	return resp.Body, nil
+}
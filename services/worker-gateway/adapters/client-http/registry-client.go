@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 
@@ -38,14 +37,14 @@ func (c *RegistryClient) RegisterWorker(ctx context.Context, req ports.RegisterR
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		logging.From(ctx).Error("HTTP request failed during worker registration", "error", err)
-		return nil, err
+		return nil, requestError("register worker", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		logging.From(ctx).Warn("Unexpected response during registration", "status", resp.StatusCode, "response", string(body))
-		return nil, fmt.Errorf("register worker failed: %s", body)
+		err := statusError("register worker", resp, nil)
+		logging.From(ctx).Warn("Unexpected response during registration", "status", resp.StatusCode, "error", err)
+		return nil, err
 	}
 
 	var regResp ports.RegisterRespose
@@ -61,7 +60,10 @@ func (c *RegistryClient) RegisterWorker(ctx context.Context, req ports.RegisterR
 func (c *RegistryClient) UpdateWorkerStatus(ctx context.Context, req ports.HeartbeatRequest, token string) error {
 	url := fmt.Sprintf("%s/workers/%s/status", c.BaseURL, req.WorkerID)
 
-	payload := map[string]string{"status": req.Status}
+	payload := map[string]any{"status": req.Status}
+	if req.CachedImages != nil {
+		payload["cachedImages"] = req.CachedImages
+	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		logging.From(ctx).Error("Failed to marshal worker status payload", "workerID", req.WorkerID, "error", err)
@@ -81,14 +83,14 @@ func (c *RegistryClient) UpdateWorkerStatus(ctx context.Context, req ports.Heart
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		logging.From(ctx).Error("HTTP request failed during status update", "workerID", req.WorkerID, "error", err)
-		return err
+		return requestError("update worker status", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		logging.From(ctx).Warn("Unexpected response during status update", "workerID", req.WorkerID, "status", resp.StatusCode, "response", string(respBody))
-		return fmt.Errorf("update worker status failed: %s", respBody)
+		err := statusError("update worker status", resp, ports.ErrWorkerNotFound)
+		logging.From(ctx).Warn("Unexpected response during status update", "workerID", req.WorkerID, "status", resp.StatusCode, "error", err)
+		return err
 	}
 
 	logging.From(ctx).Debug("Worker status updated", "workerID", req.WorkerID, "status", req.Status)
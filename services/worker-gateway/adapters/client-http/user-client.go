@@ -42,21 +42,23 @@ func (c *UserClient) GetToken(ctx context.Context, req ports.GetTokenRequest) (p
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		logging.From(ctx).Error("HTTP request failed", "error", err)
-		return ports.GetTokenResponse{}, err
+		return ports.GetTokenResponse{}, requestError("login", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		// the user service rejects an unknown key with 401
+		err := statusError("login", resp, nil)
+		logging.From(ctx).Warn("Unexpected response status from user service", "status", resp.StatusCode, "error", err)
+		return ports.GetTokenResponse{}, err
+	}
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logging.From(ctx).Error("Failed to read response body", "error", err)
 		return ports.GetTokenResponse{}, err
 	}
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		logging.From(ctx).Warn("Unexpected response status from user service", "status", resp.StatusCode, "response", string(respBody))
-		return ports.GetTokenResponse{}, fmt.Errorf("user registration failed: %s", string(respBody))
-	}
-
 	var parsed ports.GetTokenResponse
 	if err := json.Unmarshal(respBody, &parsed); err != nil {
 		logging.From(ctx).Error("Failed to unmarshal response", "error", err)
@@ -0,0 +1,233 @@
+package handler_http_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/auth"
+	handler_http "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/handler-http"
+	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
+)
+
+// openAPI is the part of api/api.yaml the contract tests check
+type openAPI struct {
+	Paths map[string]map[string]struct {
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema struct {
+					Ref string `yaml:"$ref"`
+				} `yaml:"schema"`
+			} `yaml:"content"`
+		} `yaml:"responses"`
+	} `yaml:"paths"`
+}
+
+const errorResponseRef = "#/components/schemas/ErrorResponse"
+
+func loadSpec(t *testing.T) openAPI {
+	t.Helper()
+	data, err := os.ReadFile("../../api/api.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec openAPI
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// TestContract sends requests to the routes of the real handler and checks that every answer is
+// documented in api/api.yaml and that error answers have the documented ErrorResponse body.
+func TestContract(t *testing.T) {
+	spec := loadSpec(t)
+
+	workerToken, err := auth.IssueWorkerToken("worker1", map[string]any{ports.ProviderTokenClaim: "provider"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serviceToken := roleToken(t, ports.JobSchedulerRole)
+	downstreamNotFound := fmt.Errorf("fetch job failed: %w (status 404: not found)", ports.ErrJobNotFound)
+	downstreamDown := fmt.Errorf("update worker status failed: %w (status 503: )", ports.ErrServiceUnavailable)
+
+	heartbeat := `{"workerId":"worker1","status":"AVAILABLE"}`
+	result := `{"jobId":"job1","status":"DONE","result":"ok"}`
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		body     string
+		err      error // returned by the core
+		expected int
+	}{
+		{"heartbeat", http.MethodPost, "/worker/heartbeat", workerToken, heartbeat, nil, http.StatusOK},
+		{"heartbeat without token", http.MethodPost, "/worker/heartbeat", "", heartbeat, nil, http.StatusUnauthorized},
+		{"heartbeat with service token", http.MethodPost, "/worker/heartbeat", serviceToken, heartbeat, nil, http.StatusForbidden},
+		{"heartbeat invalid payload", http.MethodPost, "/worker/heartbeat", workerToken, `{`, nil, http.StatusBadRequest},
+		{"heartbeat of another worker", http.MethodPost, "/worker/heartbeat", workerToken, `{"workerId":"worker2","status":"AVAILABLE"}`, nil, http.StatusForbidden},
+		{"heartbeat unknown worker", http.MethodPost, "/worker/heartbeat", workerToken, heartbeat, ports.ErrWorkerNotFound, http.StatusNotFound},
+		{"heartbeat rejected provider token", http.MethodPost, "/worker/heartbeat", workerToken, heartbeat, ports.ErrUnauthorized, http.StatusUnauthorized},
+		{"heartbeat registry down", http.MethodPost, "/worker/heartbeat", workerToken, heartbeat, downstreamDown, http.StatusBadGateway},
+		{"heartbeat internal error", http.MethodPost, "/worker/heartbeat", workerToken, heartbeat, fmt.Errorf("boom"), http.StatusInternalServerError},
+		{"heartbeat wrong method", http.MethodGet, "/worker/heartbeat", workerToken, "", nil, http.StatusMethodNotAllowed},
+
+		{"next jobs", http.MethodGet, "/worker/jobs/next?timeout=0", workerToken, "", nil, http.StatusNoContent},
+		{"next jobs invalid timeout", http.MethodGet, "/worker/jobs/next?timeout=x", workerToken, "", nil, http.StatusBadRequest},
+		{"next jobs of another worker", http.MethodGet, "/worker/jobs/next?workerId=worker2", workerToken, "", nil, http.StatusForbidden},
+		{"next jobs without token", http.MethodGet, "/worker/jobs/next", "", "", nil, http.StatusUnauthorized},
+		{"next jobs job service down", http.MethodGet, "/worker/jobs/next?timeout=0", workerToken, "", downstreamDown, http.StatusBadGateway},
+
+		{"input file", http.MethodGet, "/worker/jobs/job1/inputs/data.csv", workerToken, "", nil, http.StatusOK},
+		{"input file of another worker", http.MethodGet, "/worker/jobs/job1/inputs/data.csv", workerToken, "", ports.ErrJobNotAssigned, http.StatusForbidden},
+		{"input file unknown", http.MethodGet, "/worker/jobs/job1/inputs/data.csv", workerToken, "", ports.ErrInputFileNotFound, http.StatusNotFound},
+		{"input file job service down", http.MethodGet, "/worker/jobs/job1/inputs/data.csv", workerToken, "", downstreamDown, http.StatusBadGateway},
+		{"input file internal error", http.MethodGet, "/worker/jobs/job1/inputs/data.csv", workerToken, "", fmt.Errorf("boom"), http.StatusInternalServerError},
+		{"input file without token", http.MethodGet, "/worker/jobs/job1/inputs/data.csv", "", "", nil, http.StatusUnauthorized},
+
+		{"assignment", http.MethodPost, "/worker/assignments", serviceToken, `{"jobId":"job1","workerId":"worker1"}`, nil, http.StatusAccepted},
+		{"assignment by worker", http.MethodPost, "/worker/assignments", workerToken, `{"jobId":"job1","workerId":"worker1"}`, nil, http.StatusForbidden},
+		{"assignment by consumer", http.MethodPost, "/worker/assignments", roleToken(t, "consumer"), `{"jobId":"job1","workerId":"worker1"}`, nil, http.StatusForbidden},
+		{"assignment invalid payload", http.MethodPost, "/worker/assignments", serviceToken, `{"jobId":"job1"}`, nil, http.StatusBadRequest},
+		{"assignment without token", http.MethodPost, "/worker/assignments", "", `{}`, nil, http.StatusUnauthorized},
+
+		{"result", http.MethodPost, "/result", workerToken, result, nil, http.StatusOK},
+		{"result invalid payload", http.MethodPost, "/result", workerToken, `[]`, nil, http.StatusBadRequest},
+		{"result of another worker", http.MethodPost, "/result", workerToken, result, ports.ErrJobNotAssigned, http.StatusForbidden},
+		{"result unknown job", http.MethodPost, "/result", workerToken, result, downstreamNotFound, http.StatusNotFound},
+		{"result job service down", http.MethodPost, "/result", workerToken, result, downstreamDown, http.StatusBadGateway},
+		{"result without token", http.MethodPost, "/result", "", result, nil, http.StatusUnauthorized},
+
+		{"register", http.MethodPost, "/register", "", `{"key":"secret","zone":"DE"}`, nil, http.StatusOK},
+		{"register invalid payload", http.MethodPost, "/register", "", `{`, nil, http.StatusBadRequest},
+		{"register invalid csr", http.MethodPost, "/register", "", `{"key":"secret","zone":"DE","csr":"x"}`, ports.ErrInvalidCSR, http.StatusBadRequest},
+		{"register invalid key", http.MethodPost, "/register", "", `{"key":"wrong","zone":"DE"}`, ports.ErrUnauthorized, http.StatusUnauthorized},
+		{"register registry down", http.MethodPost, "/register", "", `{"key":"secret","zone":"DE"}`, downstreamDown, http.StatusBadGateway},
+		{"register internal error", http.MethodPost, "/register", "", `{"key":"secret","zone":"DE"}`, fmt.Errorf("boom"), http.StatusInternalServerError},
+
+		{"refresh", http.MethodPost, "/worker/token/refresh", workerToken, `{"key":"secret"}`, nil, http.StatusOK},
+		{"refresh without token", http.MethodPost, "/worker/token/refresh", "", `{"key":"secret"}`, nil, http.StatusUnauthorized},
+		{"refresh invalid payload", http.MethodPost, "/worker/token/refresh", workerToken, `{`, nil, http.StatusBadRequest},
+		{"refresh invalid worker token", http.MethodPost, "/worker/token/refresh", "x", `{"key":"secret"}`, ports.ErrInvalidWorkerToken, http.StatusUnauthorized},
+		{"refresh key of another provider", http.MethodPost, "/worker/token/refresh", workerToken, `{"key":"other"}`, ports.ErrProviderMismatch, http.StatusForbidden},
+		{"refresh user service down", http.MethodPost, "/worker/token/refresh", workerToken, `{"key":"secret"}`, downstreamDown, http.StatusBadGateway},
+	}
+
+	tested := map[string]bool{}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := handler_http.NewHandler(&mockApi{err: tc.err}).Routes()
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expected {
+				t.Fatalf("expected %d, got %d: %s", tc.expected, rr.Code, rr.Body)
+			}
+			checkErrorBody(t, rr)
+
+			if rr.Code == http.StatusMethodNotAllowed {
+				return
+			}
+			path := specPath(spec, tc.path)
+			operation, ok := spec.Paths[path][strings.ToLower(tc.method)]
+			if !ok {
+				t.Fatalf("%s %s is not documented", tc.method, path)
+			}
+			tested[tc.method+" "+path] = true
+
+			response, ok := operation.Responses[strconv.Itoa(rr.Code)]
+			if !ok {
+				t.Fatalf("status %d of %s %s is not documented", rr.Code, tc.method, path)
+			}
+			if rr.Code >= http.StatusBadRequest && response.Content["application/json"].Schema.Ref != errorResponseRef {
+				t.Errorf("status %d of %s %s is not documented as ErrorResponse", rr.Code, tc.method, path)
+			}
+		})
+	}
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if !tested[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not tested", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// specPath returns the documented path of a request path, path parameters like {id} match any segment
+func specPath(spec openAPI, requestPath string) string {
+	requestPath, _, _ = strings.Cut(requestPath, "?")
+	segments := strings.Split(requestPath, "/")
+	for path := range spec.Paths {
+		documented := strings.Split(path, "/")
+		if len(documented) != len(segments) {
+			continue
+		}
+		matches := true
+		for i, segment := range documented {
+			if segment != segments[i] && !strings.HasPrefix(segment, "{") {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return path
+		}
+	}
+	return requestPath
+}
+
+// checkErrorBody checks that error answers are an ErrorResponse with the status as code
+func checkErrorBody(t *testing.T, rr *httptest.ResponseRecorder) {
+	t.Helper()
+	if rr.Code < http.StatusBadRequest {
+		return
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected JSON error, got %q: %s", contentType, rr.Body)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid error body %q: %v", rr.Body, err)
+	}
+	for key := range body {
+		if key != "error" && key != "code" && key != "details" {
+			t.Errorf("unexpected field %q in error body", key)
+		}
+	}
+	if message, _ := body["error"].(string); message == "" {
+		t.Errorf("expected error message, got %v", body)
+	}
+	if code, _ := body["code"].(float64); int(code) != rr.Code {
+		t.Errorf("expected code %d, got %v", rr.Code, body["code"])
+	}
+}
+
+// Internal errors must not leak details of the core or other services
+func TestWriteError_InternalDetails(t *testing.T) {
+	api := &mockApi{err: fmt.Errorf("connect to postgres://user:secret@db failed")}
+	router := handler_http.NewHandler(api).Routes()
+
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(`{"key":"secret","zone":"DE"}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError || strings.Contains(rr.Body.String(), "secret") {
+		t.Errorf("expected 500 without details, got %d: %s", rr.Code, rr.Body)
+	}
+}
@@ -0,0 +1,89 @@
+package handler_http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
+)
+
+// ErrorResponse is the body of all error responses, see ErrorResponse in api/api.yaml
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Code    int    `json:"code"`
+	Details string `json:"details,omitempty"`
+}
+
+// errorStatus maps the errors in ports to the HTTP status of the response
+var errorStatus = []struct {
+	err    error
+	status int
+}{
+	{ports.ErrInvalidRequest, http.StatusBadRequest},
+	{ports.ErrInvalidCSR, http.StatusBadRequest},
+	{ports.ErrUnauthorized, http.StatusUnauthorized},
+	{ports.ErrInvalidWorkerToken, http.StatusUnauthorized},
+	{ports.ErrForbidden, http.StatusForbidden},
+	{ports.ErrWorkerMismatch, http.StatusForbidden},
+	{ports.ErrJobNotAssigned, http.StatusForbidden},
+	{ports.ErrProviderMismatch, http.StatusForbidden},
+	{ports.ErrWorkerNotFound, http.StatusNotFound},
+	{ports.ErrJobNotFound, http.StatusNotFound},
+	{ports.ErrInputFileNotFound, http.StatusNotFound},
+	{ports.ErrServiceUnavailable, http.StatusBadGateway},
+}
+
+// writeError answers with the status of the error. Errors that are not in ports become 500
+// without details, they may contain internals.
+func writeError(w http.ResponseWriter, err error) {
+	for _, e := range errorStatus {
+		if errors.Is(err, e.err) {
+			details := ""
+			if err != e.err {
+				details = err.Error()
+			}
+			writeErrorResponse(w, e.status, e.err.Error(), details)
+			return
+		}
+	}
+	writeErrorResponse(w, http.StatusInternalServerError, "internal server error", "")
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, message string, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Code: status, Details: details})
+}
+
+// jsonErrors turns the plain text errors of http.Error into an ErrorResponse, they are written
+// by the auth middleware and the mux before a handler is reached.
+func jsonErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&plainErrorWriter{ResponseWriter: w}, r)
+	})
+}
+
+type plainErrorWriter struct {
+	http.ResponseWriter
+	status int // status of a plain text error, its body is not written yet
+}
+
+func (w *plainErrorWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *plainErrorWriter) Write(body []byte) (int, error) {
+	if w.status == 0 {
+		return w.ResponseWriter.Write(body)
+	}
+	w.Header().Del("X-Content-Type-Options")
+	writeErrorResponse(w.ResponseWriter, w.status, strings.TrimSpace(string(body)), "")
+	w.status = 0
+	return len(body), nil
+}
@@ -2,36 +2,95 @@ package handler_http
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/auth"
 	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
 )
 
+// defaultPollTimeout is used if the worker does not pass a timeout
+const defaultPollTimeout = 30 * time.Second
+
 type Handler struct {
 	api ports.Api
+
+	// RequireClientCert rejects worker requests without a verified client certificate
+	RequireClientCert bool
 }
 
 func NewHandler(api ports.Api) *Handler {
 	return &Handler{api: api}
 }
 
+// Routes returns the router of the gateway API, see api/api.yaml
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /worker/heartbeat", auth.AuthMiddleware(http.HandlerFunc(h.HeartbeatHandler)))
+	mux.Handle("GET /worker/jobs/next", auth.AuthMiddleware(http.HandlerFunc(h.NextJobsHandler)))
+	mux.Handle("GET /worker/jobs/{id}/inputs/{name}", auth.AuthMiddleware(http.HandlerFunc(h.InputFileHandler)))
+	mux.Handle("POST /worker/assignments", auth.AuthMiddleware(http.HandlerFunc(h.AssignmentHandler)))
+	mux.Handle("POST /result", auth.AuthMiddleware(http.HandlerFunc(h.SubmitResultHandler)))
+	mux.Handle("POST /register", http.HandlerFunc(h.RegisterWorkerHandler))
+	mux.Handle("POST /worker/token/refresh", http.HandlerFunc(h.RefreshTokenHandler))
+	// the auth middleware and the mux answer with plain text
+	return jsonErrors(mux)
+}
+
+// workerCredentials returns the worker the request was authenticated as and the provider token
+// used for calls to other services. Requests without a worker token are rejected, as well as
+// requests whose client certificate names another worker.
+func (h *Handler) workerCredentials(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	workerID, ok := auth.WorkerID(r.Context())
+	if !ok {
+		writeErrorResponse(w, http.StatusForbidden, "worker token required", "")
+		return "", "", false
+	}
+
+	certWorkerID, hasCert := clientCertWorkerID(r)
+	if hasCert && certWorkerID != workerID {
+		writeError(w, ports.ErrWorkerMismatch)
+		return "", "", false
+	}
+	if !hasCert && h.RequireClientCert {
+		writeErrorResponse(w, http.StatusForbidden, "client certificate required", "")
+		return "", "", false
+	}
+	return workerID, auth.Claim(r.Context(), ports.ProviderTokenClaim), true
+}
+
+// clientCertWorkerID returns the worker ID of a verified client certificate, the common name of its subject
+func clientCertWorkerID(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return "", false
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName, true
+}
+
 // POST /worker/heartbeat
 func (h *Handler) HeartbeatHandler(w http.ResponseWriter, r *http.Request) {
 	var req ports.HeartbeatRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		writeError(w, fmt.Errorf("%w: %v", ports.ErrInvalidRequest, err))
 		return
 	}
 
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		http.Error(w, "missing token", http.StatusUnauthorized)
+	workerID, token, ok := h.workerCredentials(w, r)
+	if !ok {
+		return
+	}
+	if req.WorkerID != workerID {
+		writeError(w, ports.ErrWorkerMismatch)
 		return
 	}
 
 	jobs, err := h.api.Heartbeat(r.Context(), req, token)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -39,22 +98,104 @@ func (h *Handler) HeartbeatHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(jobs)
 }
 
+// GET /worker/jobs/next?workerId=...&timeout=30
+// long poll, answers as soon as a job is assigned to the worker or with 204 after the timeout in seconds.
+// The workerId is optional, it defaults to the worker of the token.
+func (h *Handler) NextJobsHandler(w http.ResponseWriter, r *http.Request) {
+	workerID, token, ok := h.workerCredentials(w, r)
+	if !ok {
+		return
+	}
+	if value := r.URL.Query().Get("workerId"); value != "" && value != workerID {
+		writeError(w, ports.ErrWorkerMismatch)
+		return
+	}
+
+	timeout := defaultPollTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid timeout", "")
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	jobs, err := h.api.NextJobs(r.Context(), workerID, timeout, token)
+	if err != nil {
+		if r.Context().Err() != nil {
+			// worker disconnected
+			return
+		}
+		writeError(w, err)
+		return
+	}
+	if len(jobs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// GET /worker/jobs/{id}/inputs/{name}
+// streams an uploaded input file of a job assigned to the worker, the worker daemon verifies its checksum
+func (h *Handler) InputFileHandler(w http.ResponseWriter, r *http.Request) {
+	workerID, token, ok := h.workerCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	content, err := h.api.InputFile(r.Context(), workerID, r.PathValue("id"), r.PathValue("name"), token)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	io.Copy(w, content)
+}
+
+// POST /worker/assignments
+func (h *Handler) AssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	// only the job-scheduler notifies assignments, neither workers nor consumers or providers
+	if role, _ := r.Context().Value("role").(string); role != ports.JobSchedulerRole {
+		writeError(w, ports.ErrForbidden)
+		return
+	}
+
+	var assignment ports.Assignment
+	if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil || assignment.WorkerID == "" {
+		writeError(w, fmt.Errorf("%w: %v", ports.ErrInvalidRequest, err))
+		return
+	}
+
+	if err := h.api.NotifyAssignment(r.Context(), assignment); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
 // POST /result
 func (h *Handler) SubmitResultHandler(w http.ResponseWriter, r *http.Request) {
 	var result ports.ResultRequest
 	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		writeError(w, fmt.Errorf("%w: %v", ports.ErrInvalidRequest, err))
 		return
 	}
 
-	token := r.Header.Get("Authorization") // oder z. B. "X-Worker-Token"
-	if token == "" {
-		http.Error(w, "missing token", http.StatusUnauthorized)
+	workerID, token, ok := h.workerCredentials(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.api.Result(r.Context(), result, token); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	err := h.api.Result(r.Context(), workerID, result, token)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -65,16 +206,46 @@ func (h *Handler) SubmitResultHandler(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) RegisterWorkerHandler(w http.ResponseWriter, r *http.Request) {
 	var req ports.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request", http.StatusBadRequest)
+		writeError(w, fmt.Errorf("%w: %v", ports.ErrInvalidRequest, err))
 		return
 	}
 
 	regResp, err := h.api.Register(r.Context(), req)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(regResp)
 }
+
+// POST /worker/token/refresh
+// not behind the auth middleware, the previous worker token may already be expired
+func (h *Handler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	workerToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || workerToken == "" {
+		writeErrorResponse(w, http.StatusUnauthorized, "missing token", "")
+		return
+	}
+
+	var req ports.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, fmt.Errorf("%w: %v", ports.ErrInvalidRequest, err))
+		return
+	}
+
+	resp, err := h.api.RefreshToken(r.Context(), req, workerToken)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if certWorkerID, hasCert := clientCertWorkerID(r); hasCert && certWorkerID != resp.ID {
+		writeError(w, ports.ErrWorkerMismatch)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
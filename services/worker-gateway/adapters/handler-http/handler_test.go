@@ -0,0 +1,229 @@
+package handler_http_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/auth"
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
+	certificate_authority "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/certificate-authority"
+	handler_http "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/handler-http"
+	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
+)
+
+const testWorkerKey = "0123456789abcdef0123456789abcdef"
+
+func init() {
+	logging.Init("worker-gateway-test")
+	if err := auth.InitWorkerKey([]byte(testWorkerKey)); err != nil {
+		panic(err)
+	}
+}
+
+// roleToken creates a token with the role user-management would issue, the JWKS is replaced by the worker key
+func roleToken(t *testing.T, role string) string {
+	t.Helper()
+	claims := jwt.MapClaims{"sub": "user1", "role": role, "exp": time.Now().Add(time.Hour).Unix()}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testWorkerKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// mockApi records the calls that reach the core
+type mockApi struct {
+	heartbeat *ports.HeartbeatRequest
+	token     string
+	err       error // returned by all calls
+}
+
+func (m *mockApi) Heartbeat(_ context.Context, req ports.HeartbeatRequest, token string) ([]ports.Job, error) {
+	m.heartbeat = &req
+	m.token = token
+	return nil, m.err
+}
+
+func (m *mockApi) Result(_ context.Context, _ string, _ ports.ResultRequest, _ string) error {
+	return m.err
+}
+
+func (m *mockApi) Register(_ context.Context, _ ports.RegisterRequest) (*ports.RegisterRespose, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ports.RegisterRespose{ID: "worker1", Status: "AVAILABLE", Zone: "DE", Token: "token"}, nil
+}
+
+func (m *mockApi) RefreshToken(_ context.Context, _ ports.RefreshRequest, _ string) (*ports.RefreshResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ports.RefreshResponse{ID: "worker1", Token: "token"}, nil
+}
+
+func (m *mockApi) NextJobs(_ context.Context, _ string, _ time.Duration, _ string) ([]ports.Job, error) {
+	return nil, m.err
+}
+
+func (m *mockApi) InputFile(_ context.Context, _ string, _ string, _ string, _ string) (io.ReadCloser, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return io.NopCloser(strings.NewReader("hello")), nil
+}
+
+func (m *mockApi) NotifyAssignment(_ context.Context, _ ports.Assignment) error {
+	return m.err
+}
+
+func sendHeartbeat(t *testing.T, api *mockApi, token string, body string) int {
+	t.Helper()
+	handler := auth.AuthMiddleware(http.HandlerFunc(handler_http.NewHandler(api).HeartbeatHandler))
+
+	req := httptest.NewRequest(http.MethodPost, "/worker/heartbeat", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestHeartbeatHandler_WorkerToken(t *testing.T) {
+	token, err := auth.IssueWorkerToken("worker1", map[string]any{ports.ProviderTokenClaim: "provider"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := &mockApi{}
+	if code := sendHeartbeat(t, api, token, `{"workerId":"worker1","status":"AVAILABLE"}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	// downstream calls use the provider token, not the worker token
+	if api.heartbeat == nil || api.token != "provider" {
+		t.Errorf("expected heartbeat with provider token, got %+v %q", api.heartbeat, api.token)
+	}
+
+	api = &mockApi{}
+	if code := sendHeartbeat(t, api, token, `{"workerId":"worker2","status":"AVAILABLE"}`); code != http.StatusForbidden {
+		t.Errorf("expected 403 for heartbeat of another worker, got %d", code)
+	}
+	if api.heartbeat != nil {
+		t.Error("expected heartbeat of another worker not to reach the core")
+	}
+}
+
+func TestAssignmentHandler_OnlyJobScheduler(t *testing.T) {
+	workerToken, err := auth.IssueWorkerToken("worker1", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{"job-scheduler", roleToken(t, ports.JobSchedulerRole), http.StatusAccepted},
+		{"consumer", roleToken(t, "consumer"), http.StatusForbidden},
+		{"provider", roleToken(t, "provider"), http.StatusForbidden},
+		{"worker", workerToken, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/worker/assignments", strings.NewReader(`{"jobId":"job1","workerId":"worker1"}`))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			handler_http.NewHandler(&mockApi{}).Routes().ServeHTTP(rr, req)
+			if rr.Code != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, rr.Code)
+			}
+		})
+	}
+}
+
+// clientCertificate creates a key and lets the CA issue a certificate for it, like a worker at registration
+func clientCertificate(t *testing.T, ca *certificate_authority.CA, workerID string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.IssueClientCertificate(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}), workerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestHeartbeatHandler_ClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := certificate_authority.LoadOrCreate(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.IssueWorkerToken("worker1", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := handler_http.NewHandler(&mockApi{})
+	handler.RequireClientCert = true
+	server := httptest.NewUnstartedServer(auth.AuthMiddleware(http.HandlerFunc(handler.HeartbeatHandler)))
+	server.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: ca.CertPool()}
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		certs    []tls.Certificate
+		expected int
+	}{
+		{"certificate of the worker", []tls.Certificate{clientCertificate(t, ca, "worker1")}, http.StatusOK},
+		{"certificate of another worker", []tls.Certificate{clientCertificate(t, ca, "worker2")}, http.StatusForbidden},
+		{"no certificate", nil, http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// a new transport per case, a reused connection keeps the certificate of its handshake
+			transport := server.Client().Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig.Certificates = tc.certs
+			defer transport.CloseIdleConnections()
+			client := &http.Client{Transport: transport}
+
+			req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"workerId":"worker1","status":"AVAILABLE"}`))
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, resp.StatusCode)
+			}
+		})
+	}
+}
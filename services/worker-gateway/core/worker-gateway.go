@@ -2,59 +2,182 @@ package core
 
 import (
 	"context"
+	"io"
+	"sync"
+	"time"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/auth"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
 )
 
+// MaxPollTimeout limits how long a worker waits for an assignment in NextJobs
+const MaxPollTimeout = 60 * time.Second
+
+// WorkerTokenTTL is used for worker tokens if the provider token has no expiry
+const WorkerTokenTTL = 24 * time.Hour
+
 type WorkerGatewayService struct {
 	registry ports.RegistryService
 	job      ports.JobService
 	user     ports.UserService
+	ca       ports.CertificateAuthority // nil if mutual TLS is disabled
+
+	// workers waiting in NextJobs, woken up by NotifyAssignment. They are only known to this instance,
+	// with several replicas a worker polling another one gets the job with its next poll.
+	mu      sync.Mutex
+	waiters map[string]chan struct{}
 }
 
-func NewWorkerGatewayService(registry ports.RegistryService, job ports.JobService, user ports.UserService) *WorkerGatewayService {
-	return &WorkerGatewayService{registry: registry, job: job, user: user}
+func NewWorkerGatewayService(registry ports.RegistryService, job ports.JobService, user ports.UserService, ca ports.CertificateAuthority) *WorkerGatewayService {
+	return &WorkerGatewayService{registry: registry, job: job, user: user, ca: ca, waiters: make(map[string]chan struct{})}
 }
 
 func (s *WorkerGatewayService) Heartbeat(ctx context.Context, req ports.HeartbeatRequest, token string) ([]ports.Job, error) {
 
 	logging.From(ctx).Debug("Heartbeat received", "workerID", req.WorkerID, "status", req.Status)
 
+	progress := req.Progress
+	if progress != nil {
+		job, err := s.job.FetchJob(ctx, progress.JobID, token)
+		if err != nil {
+			logging.From(ctx).Warn("Dropped progress of unknown job", "jobID", progress.JobID, "error", err)
+			progress = nil
+		} else if job.WorkerID != req.WorkerID {
+			logging.From(ctx).Warn("Progress for job of another worker", "jobID", progress.JobID, "workerID", req.WorkerID, "assignedTo", job.WorkerID)
+			return nil, ports.ErrJobNotAssigned
+		}
+	}
+
 	if err := s.registry.UpdateWorkerStatus(ctx, req, token); err != nil {
 		logging.From(ctx).Error("UpdateWorkerStatus failed", "error", err)
 		return nil, err
 	}
 
-	if req.Status == "AVAILABLE" {
-		jobs, err := s.job.FetchScheduledJobs(ctx, token)
-		if err != nil {
-			logging.From(ctx).Error("Error fetching jobs", "error", err)
-			return nil, err
+	// progress is best effort, a failed update must not fail the heartbeat
+	if progress != nil {
+		if err := s.job.UpdateProgress(ctx, *progress, token); err != nil {
+			logging.From(ctx).Warn("UpdateProgress failed", "jobID", progress.JobID, "error", err)
 		}
-		logging.From(ctx).Debug("Provided jobs", "jobs", jobs)
+	}
 
-		var filteredJobs []ports.Job
-		for _, job := range jobs {
-			if job.WorkerID == req.WorkerID {
-				filteredJobs = append(filteredJobs, job)
-			}
-		}
+	// jobs are handed out through NextJobs
+	return nil, nil
+}
 
-		logging.From(ctx).Debug("Filtered jobs", "filteredJobs", filteredJobs)
+func (s *WorkerGatewayService) NextJobs(ctx context.Context, workerID string, timeout time.Duration, token string) ([]ports.Job, error) {
+	logging.From(ctx).Debug("Worker waiting for jobs", "workerID", workerID, "timeout", timeout)
 
-		return filteredJobs, nil
+	// register before fetching, so an assignment made in between is not missed
+	wake := s.addWaiter(workerID)
+	defer s.removeWaiter(workerID, wake)
+
+	jobs, err := s.assignedJobs(ctx, workerID, token)
+	if err != nil || len(jobs) > 0 {
+		return jobs, err
 	}
 
-	return nil, nil
+	timer := time.NewTimer(min(timeout, MaxPollTimeout))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, nil
+	case <-wake:
+		return s.assignedJobs(ctx, workerID, token)
+	}
+}
+
+func (s *WorkerGatewayService) NotifyAssignment(ctx context.Context, assignment ports.Assignment) error {
+	logging.From(ctx).Debug("Job assigned", "jobID", assignment.JobID, "workerID", assignment.WorkerID)
+
+	s.mu.Lock()
+	wake, ok := s.waiters[assignment.WorkerID]
+	s.mu.Unlock()
+
+	if !ok {
+		// the worker picks the job up with its next poll
+		logging.From(ctx).Debug("Worker not waiting", "workerID", assignment.WorkerID)
+		return nil
+	}
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// assignedJobs returns the scheduled jobs of the worker, the job service filters them by worker
+func (s *WorkerGatewayService) assignedJobs(ctx context.Context, workerID string, token string) ([]ports.Job, error) {
+	jobs, err := s.job.FetchScheduledJobs(ctx, workerID, token)
+	if err != nil {
+		logging.From(ctx).Error("Error fetching jobs", "error", err)
+		return nil, err
+	}
+
+	// never hand out a job of another worker, even if the job service ignored the filter
+	var filteredJobs []ports.Job
+	for _, job := range jobs {
+		if job.WorkerID == workerID {
+			filteredJobs = append(filteredJobs, job)
+		} else {
+			logging.From(ctx).Warn("Dropped job of another worker", "jobID", job.ID, "workerID", workerID, "assignedTo", job.WorkerID)
+		}
+	}
+
+	logging.From(ctx).Debug("Provided jobs", "workerID", workerID, "jobs", filteredJobs)
+
+	return filteredJobs, nil
+}
+
+// addWaiter registers the worker as waiting, a second poll of the same worker replaces the first
+func (s *WorkerGatewayService) addWaiter(workerID string) chan struct{} {
+	wake := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.waiters[workerID] = wake
+	s.mu.Unlock()
+	return wake
 }
 
-func (s *WorkerGatewayService) Result(ctx context.Context, result ports.ResultRequest, token string) error {
+func (s *WorkerGatewayService) removeWaiter(workerID string, wake chan struct{}) {
+	s.mu.Lock()
+	if s.waiters[workerID] == wake {
+		delete(s.waiters, workerID)
+	}
+	s.mu.Unlock()
+}
+
+func (s *WorkerGatewayService) Result(ctx context.Context, workerID string, result ports.ResultRequest, token string) error {
 
-	logging.From(ctx).Debug("Result received", "jobID", result.JobID)
+	logging.From(ctx).Debug("Result received", "jobID", result.JobID, "workerID", workerID)
+
+	job, err := s.job.FetchJob(ctx, result.JobID, token)
+	if err != nil {
+		logging.From(ctx).Error("Fetching job of result failed", "jobID", result.JobID, "error", err)
+		return err
+	}
+	if job.WorkerID != workerID {
+		logging.From(ctx).Warn("Result for job of another worker", "jobID", result.JobID, "workerID", workerID, "assignedTo", job.WorkerID)
+		return ports.ErrJobNotAssigned
+	}
 	return s.job.UpdateJob(ctx, result, token)
 }
 
+func (s *WorkerGatewayService) InputFile(ctx context.Context, workerID string, jobID string, name string, token string) (io.ReadCloser, error) {
+	job, err := s.job.FetchJob(ctx, jobID, token)
+	if err != nil {
+		logging.From(ctx).Error("Fetching job of input file failed", "jobID", jobID, "error", err)
+		return nil, err
+	}
+	if job.WorkerID != workerID {
+		logging.From(ctx).Warn("Input file of a job of another worker", "jobID", jobID, "workerID", workerID, "assignedTo", job.WorkerID)
+		return nil, ports.ErrJobNotAssigned
+	}
+	return s.job.FetchInputFile(ctx, jobID, name, token)
+}
+
 func (s *WorkerGatewayService) Register(ctx context.Context, req ports.RegisterRequest) (*ports.RegisterRespose, error) {
 	logging.From(ctx).Debug("Registering worker", "zone", req.Zone)
 
@@ -76,6 +199,84 @@ func (s *WorkerGatewayService) Register(ctx context.Context, req ports.RegisterR
 		return nil, err
 	}
 
+	workerToken, err := s.issueWorkerToken(ctx, regResp.ID, tokenResp.Token)
+	if err != nil {
+		return nil, err
+	}
+	regResp.Token = workerToken
+
+	if req.CSR != "" {
+		if s.ca == nil {
+			logging.From(ctx).Warn("Mutual TLS disabled, no client certificate issued", "workerID", regResp.ID)
+		} else {
+			cert, err := s.ca.IssueClientCertificate([]byte(req.CSR), regResp.ID)
+			if err != nil {
+				logging.From(ctx).Error("Issuing client certificate failed", "workerID", regResp.ID, "error", err)
+				return nil, err
+			}
+			regResp.Certificate = string(cert)
+			regResp.CACertificate = string(s.ca.CertificatePEM())
+		}
+	}
+
 	logging.From(ctx).Debug("Worker registered", "workerID", regResp.ID, "zone", regResp.Zone)
 	return regResp, nil
 }
+
+func (s *WorkerGatewayService) RefreshToken(ctx context.Context, req ports.RefreshRequest, workerToken string) (*ports.RefreshResponse, error) {
+	claims, err := auth.VerifyWorkerToken(workerToken)
+	if err != nil {
+		logging.From(ctx).Warn("Refresh with invalid worker token", "error", err)
+		return nil, ports.ErrInvalidWorkerToken
+	}
+	workerID := claims[auth.ClaimWorkerID].(string)
+
+	logging.From(ctx).Debug("Refreshing worker token", "workerID", workerID)
+
+	tokenResp, err := s.user.GetToken(ctx, ports.GetTokenRequest{Secret: req.Key})
+	if err != nil {
+		logging.From(ctx).Error("Failed to get provider token from user service", "workerID", workerID, "error", err)
+		return nil, err
+	}
+
+	// the worker keeps its ID, so the key must belong to the provider that registered it
+	if provider, _ := claims[ports.ProviderClaim].(string); provider != providerOf(tokenResp.Token) {
+		logging.From(ctx).Warn("Refresh with key of another provider", "workerID", workerID)
+		return nil, ports.ErrProviderMismatch
+	}
+
+	token, err := s.issueWorkerToken(ctx, workerID, tokenResp.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.From(ctx).Debug("Worker token refreshed", "workerID", workerID)
+	return &ports.RefreshResponse{ID: workerID, Token: token}, nil
+}
+
+// issueWorkerToken wraps the provider token in a token bound to the worker, so it can be used for calls
+// to other services. The worker token expires with the provider token. The provider token is readable
+// from the worker token, it is the one the worker could get with its own key anyway, see the README.
+func (s *WorkerGatewayService) issueWorkerToken(ctx context.Context, workerID string, providerToken string) (string, error) {
+	expiresAt, ok := auth.ExpiresAt(providerToken)
+	if !ok {
+		expiresAt = time.Now().Add(WorkerTokenTTL)
+	}
+	claims := map[string]any{
+		ports.ProviderTokenClaim: providerToken,
+		ports.ProviderClaim:      providerOf(providerToken),
+	}
+	token, err := auth.IssueWorkerToken(workerID, claims, expiresAt)
+	if err != nil {
+		logging.From(ctx).Error("Issuing worker token failed", "workerID", workerID, "error", err)
+		return "", err
+	}
+	return token, nil
+}
+
+// providerOf returns the subject of the provider token, the token was issued by the user service
+func providerOf(providerToken string) string {
+	claims, _ := auth.UnverifiedClaims(providerToken)
+	provider, _ := claims["sub"].(string)
+	return provider
+}
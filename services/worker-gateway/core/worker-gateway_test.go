@@ -2,9 +2,15 @@ package core_test
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/informatik-mannheim/cmg-ss2025/pkg/auth"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/core"
 	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
@@ -12,6 +18,9 @@ import (
 
 func init() {
 	logging.Init("Worker Registry")
+	if err := auth.InitWorkerKey([]byte("0123456789abcdef0123456789abcdef")); err != nil {
+		panic(err)
+	}
 }
 
 // --- Dummy RegistryService für Tests ---
@@ -46,6 +55,20 @@ type dummyJobService struct {
 	UpdateJobCalled          bool
 	FetchScheduledJobsCalled bool
 	ReturnErr                bool
+	FetchInputFileCalled     bool
+	ReceivedProgress         *ports.JobProgress
+	RequestedWorkerID        string
+	IgnoreWorkerFilter       bool // simuliert einen Job Service, der alle Jobs liefert
+
+	mu            sync.Mutex
+	AssignedLater []ports.Job // wird erst nach assign() zurückgegeben
+	assigned      bool
+}
+
+func (d *dummyJobService) assign() {
+	d.mu.Lock()
+	d.assigned = true
+	d.mu.Unlock()
 }
 
 func (d *dummyJobService) UpdateJob(ctx context.Context, req ports.ResultRequest, token string) error {
@@ -56,21 +79,68 @@ func (d *dummyJobService) UpdateJob(ctx context.Context, req ports.ResultRequest
 	return nil
 }
 
-func (d *dummyJobService) FetchScheduledJobs(ctx context.Context, token string) ([]ports.Job, error) {
+func (d *dummyJobService) FetchScheduledJobs(ctx context.Context, workerID string, token string) ([]ports.Job, error) {
 	d.FetchScheduledJobsCalled = true
+	d.RequestedWorkerID = workerID
 	if d.ReturnErr {
 		return nil, errors.New("fetch jobs error")
 	}
+	jobs := scheduledJobs()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.assigned {
+		jobs = append(jobs, d.AssignedLater...)
+	}
+	if d.IgnoreWorkerFilter {
+		return jobs, nil
+	}
+
+	var filtered []ports.Job
+	for _, job := range jobs {
+		if job.WorkerID == workerID {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered, nil
+}
+
+func (d *dummyJobService) FetchJob(ctx context.Context, jobID string, token string) (ports.Job, error) {
+	for _, job := range append(scheduledJobs(), ports.Job{ID: "job123", WorkerID: "worker1"}) {
+		if job.ID == jobID {
+			return job, nil
+		}
+	}
+	return ports.Job{}, errors.New("job not found")
+}
+
+func (d *dummyJobService) FetchInputFile(ctx context.Context, jobID string, name string, token string) (io.ReadCloser, error) {
+	d.FetchInputFileCalled = true
+	if d.ReturnErr {
+		return nil, errors.New("fetch input file error")
+	}
+	return io.NopCloser(strings.NewReader("hello")), nil
+}
+
+func scheduledJobs() []ports.Job {
 	return []ports.Job{
 		{ID: "job1", WorkerID: "worker1", Status: "SCHEDULED"},
 		{ID: "job2", WorkerID: "worker2", Status: "SCHEDULED"},
-	}, nil
+	}
+}
+
+func (d *dummyJobService) UpdateProgress(ctx context.Context, progress ports.JobProgress, token string) error {
+	d.ReceivedProgress = &progress
+	if d.ReturnErr {
+		return errors.New("update progress error")
+	}
+	return nil
 }
 
 // --- Dummy UserClient für Tests ---
 type dummyUserClient struct {
 	GetTokenCalled bool
 	ReturnErr      bool
+	Token          string // Provider Token, Standard ist "mocked.secret.token"
 }
 
 func (d *dummyUserClient) GetToken(ctx context.Context, req ports.GetTokenRequest) (ports.GetTokenResponse, error) {
@@ -78,12 +148,15 @@ func (d *dummyUserClient) GetToken(ctx context.Context, req ports.GetTokenReques
 	if d.ReturnErr {
 		return ports.GetTokenResponse{}, errors.New("user token error")
 	}
+	if d.Token != "" {
+		return ports.GetTokenResponse{Token: d.Token}, nil
+	}
 	return ports.GetTokenResponse{Token: "mocked.secret.token"}, nil
 }
 
 // --- Helper zum Erstellen des Services ---
 func newTestWorkerGatewayService(reg ports.RegistryService, job ports.JobService, user ports.UserService) *core.WorkerGatewayService {
-	return core.NewWorkerGatewayService(reg, job, user)
+	return core.NewWorkerGatewayService(reg, job, user, nil)
 }
 
 // --- Tests ---
@@ -109,7 +182,14 @@ func TestRegisterWorker_WithUserClient_Success(t *testing.T) {
 		t.Error("expected RegisterWorker to be called")
 	}
 	if resp == nil || resp.ID != "worker123" {
-		t.Errorf("unexpected register response: %+v", resp)
+		t.Fatalf("unexpected register response: %+v", resp)
+	}
+	// der Worker bekommt ein eigenes Token, nicht das Provider Token
+	if resp.Token == "" || resp.Token == "mocked.secret.token" {
+		t.Errorf("expected worker token, got %q", resp.Token)
+	}
+	if exp, ok := auth.ExpiresAt(resp.Token); !ok || time.Until(exp) <= 0 {
+		t.Errorf("expected worker token to expire in the future, got %v", exp)
 	}
 }
 
@@ -143,7 +223,7 @@ func TestSubmitResult_Success(t *testing.T) {
 	user := &dummyUserClient{}
 	svc := newTestWorkerGatewayService(reg, job, user)
 
-	err := svc.Result(context.Background(), ports.ResultRequest{
+	err := svc.Result(context.Background(), "worker1", ports.ResultRequest{
 		JobID:  "job123",
 		Status: "COMPLETED",
 		Result: "some output",
@@ -162,7 +242,7 @@ func TestSubmitResult_Error(t *testing.T) {
 	user := &dummyUserClient{}
 	svc := newTestWorkerGatewayService(reg, job, user)
 
-	err := svc.Result(context.Background(), ports.ResultRequest{
+	err := svc.Result(context.Background(), "worker1", ports.ResultRequest{
 		JobID: "job123",
 	}, "")
 	if err == nil {
@@ -170,7 +250,46 @@ func TestSubmitResult_Error(t *testing.T) {
 	}
 }
 
-func TestHeartbeat_Available_WithJobs(t *testing.T) {
+func TestSubmitResult_JobOfAnotherWorker(t *testing.T) {
+	job := &dummyJobService{}
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, job, &dummyUserClient{})
+
+	err := svc.Result(context.Background(), "worker1", ports.ResultRequest{JobID: "job2", Status: "COMPLETED"}, "")
+	if !errors.Is(err, ports.ErrJobNotAssigned) {
+		t.Fatalf("expected ErrJobNotAssigned, got %v", err)
+	}
+	if job.UpdateJobCalled {
+		t.Error("expected UpdateJob NOT to be called")
+	}
+}
+
+func TestInputFile_Success(t *testing.T) {
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, &dummyJobService{}, &dummyUserClient{})
+
+	content, err := svc.InputFile(context.Background(), "worker1", "job1", "data.csv", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer content.Close()
+	if data, _ := io.ReadAll(content); string(data) != "hello" {
+		t.Errorf("expected content hello, got %q", data)
+	}
+}
+
+func TestInputFile_JobOfAnotherWorker(t *testing.T) {
+	job := &dummyJobService{}
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, job, &dummyUserClient{})
+
+	_, err := svc.InputFile(context.Background(), "worker1", "job2", "data.csv", "")
+	if !errors.Is(err, ports.ErrJobNotAssigned) {
+		t.Fatalf("expected ErrJobNotAssigned, got %v", err)
+	}
+	if job.FetchInputFileCalled {
+		t.Error("expected FetchInputFile NOT to be called")
+	}
+}
+
+func TestHeartbeat_Available_DoesNotFetchJobs(t *testing.T) {
 	reg := &dummyRegistryService{}
 	job := &dummyJobService{}
 	user := &dummyUserClient{}
@@ -188,11 +307,12 @@ func TestHeartbeat_Available_WithJobs(t *testing.T) {
 	if !reg.UpdateWorkerStatusCalled {
 		t.Error("expected UpdateWorkerStatus to be called")
 	}
-	if !job.FetchScheduledJobsCalled {
-		t.Error("expected FetchScheduledJobs to be called")
+	// Jobs werden über NextJobs verteilt, nicht mehr pro Heartbeat
+	if job.FetchScheduledJobsCalled {
+		t.Error("expected FetchScheduledJobs NOT to be called")
 	}
-	if len(jobs) != 1 || jobs[0].WorkerID != "worker1" {
-		t.Errorf("expected 1 matching job for worker1, got %v", jobs)
+	if len(jobs) != 0 {
+		t.Errorf("expected no jobs, got %v", jobs)
 	}
 }
 
@@ -221,3 +341,206 @@ func TestHeartbeat_Computing(t *testing.T) {
 		t.Error("expected FetchScheduledJobs NOT to be called")
 	}
 }
+
+func TestHeartbeat_RelaysProgress(t *testing.T) {
+	reg := &dummyRegistryService{}
+	job := &dummyJobService{}
+	user := &dummyUserClient{}
+	svc := newTestWorkerGatewayService(reg, job, user)
+
+	progress := ports.JobProgress{JobID: "job1", Percent: 42, Message: "epoch 3/10"}
+	_, err := svc.Heartbeat(context.Background(), ports.HeartbeatRequest{
+		WorkerID: "worker1",
+		Status:   "RUNNING",
+		Progress: &progress,
+	}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if job.ReceivedProgress == nil || *job.ReceivedProgress != progress {
+		t.Errorf("expected progress %+v to be relayed, got %+v", progress, job.ReceivedProgress)
+	}
+}
+
+func TestHeartbeat_ProgressForJobOfAnotherWorker(t *testing.T) {
+	reg := &dummyRegistryService{}
+	job := &dummyJobService{}
+	svc := newTestWorkerGatewayService(reg, job, &dummyUserClient{})
+
+	_, err := svc.Heartbeat(context.Background(), ports.HeartbeatRequest{
+		WorkerID: "worker1",
+		Status:   "RUNNING",
+		Progress: &ports.JobProgress{JobID: "job2", Percent: 42},
+	}, "")
+	if !errors.Is(err, ports.ErrJobNotAssigned) {
+		t.Fatalf("expected ErrJobNotAssigned, got %v", err)
+	}
+	if reg.UpdateWorkerStatusCalled || job.ReceivedProgress != nil {
+		t.Error("expected heartbeat to be rejected before any update")
+	}
+}
+
+func TestHeartbeat_ProgressFailureDoesNotFailHeartbeat(t *testing.T) {
+	reg := &dummyRegistryService{}
+	job := &dummyJobService{ReturnErr: true}
+	user := &dummyUserClient{}
+	svc := newTestWorkerGatewayService(reg, job, user)
+
+	_, err := svc.Heartbeat(context.Background(), ports.HeartbeatRequest{
+		WorkerID: "worker1",
+		Status:   "RUNNING",
+		Progress: &ports.JobProgress{JobID: "job1", Percent: 42},
+	}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestNextJobs_ReturnsAssignedJobsImmediately(t *testing.T) {
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, &dummyJobService{}, &dummyUserClient{})
+
+	jobs, err := svc.NextJobs(context.Background(), "worker1", time.Minute, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "job1" {
+		t.Errorf("expected job1, got %v", jobs)
+	}
+}
+
+func TestNextJobs_WokenUpByAssignment(t *testing.T) {
+	job := &dummyJobService{AssignedLater: []ports.Job{{ID: "job3", WorkerID: "worker3", Status: "SCHEDULED"}}}
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, job, &dummyUserClient{})
+
+	result := make(chan []ports.Job, 1)
+	go func() {
+		jobs, _ := svc.NextJobs(context.Background(), "worker3", time.Minute, "")
+		result <- jobs
+	}()
+
+	// Scheduler weist den Job zu, während der Worker wartet
+	time.Sleep(50 * time.Millisecond)
+	job.assign()
+	if err := svc.NotifyAssignment(context.Background(), ports.Assignment{JobID: "job3", WorkerID: "worker3"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	select {
+	case jobs := <-result:
+		if len(jobs) != 1 || jobs[0].ID != "job3" {
+			t.Errorf("expected job3, got %v", jobs)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected NextJobs to return after the assignment")
+	}
+}
+
+func TestNextJobs_Timeout(t *testing.T) {
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, &dummyJobService{}, &dummyUserClient{})
+
+	jobs, err := svc.NextJobs(context.Background(), "worker3", 50*time.Millisecond, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("expected no jobs, got %v", jobs)
+	}
+}
+
+func TestNextJobs_NeverReturnsJobsOfOtherWorkers(t *testing.T) {
+	for _, ignoreFilter := range []bool{false, true} {
+		job := &dummyJobService{IgnoreWorkerFilter: ignoreFilter}
+		svc := newTestWorkerGatewayService(&dummyRegistryService{}, job, &dummyUserClient{})
+
+		for _, workerID := range []string{"worker1", "worker2"} {
+			jobs, err := svc.NextJobs(context.Background(), workerID, time.Millisecond, "")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if job.RequestedWorkerID != workerID {
+				t.Errorf("expected jobs to be requested for %s, got %s", workerID, job.RequestedWorkerID)
+			}
+			if len(jobs) != 1 {
+				t.Errorf("expected 1 job for %s, got %v", workerID, jobs)
+			}
+			for _, j := range jobs {
+				if j.WorkerID != workerID {
+					t.Errorf("worker %s received job %s of %s", workerID, j.ID, j.WorkerID)
+				}
+			}
+		}
+	}
+}
+
+// --- Dummy CertificateAuthority für Tests ---
+type dummyCA struct {
+	SignedFor string
+}
+
+func (d *dummyCA) IssueClientCertificate(csrPEM []byte, workerID string) ([]byte, error) {
+	d.SignedFor = workerID
+	return []byte("client certificate"), nil
+}
+
+func (d *dummyCA) CertificatePEM() []byte {
+	return []byte("ca certificate")
+}
+
+func TestRegisterWorker_IssuesClientCertificate(t *testing.T) {
+	ca := &dummyCA{}
+	svc := core.NewWorkerGatewayService(&dummyRegistryService{}, &dummyJobService{}, &dummyUserClient{}, ca)
+
+	resp, err := svc.Register(context.Background(), ports.RegisterRequest{Zone: "EU", CSR: "csr"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ca.SignedFor != "worker123" {
+		t.Errorf("expected certificate for worker123, got %q", ca.SignedFor)
+	}
+	if resp.Certificate != "client certificate" || resp.CACertificate != "ca certificate" {
+		t.Errorf("unexpected certificates in response: %+v", resp)
+	}
+}
+
+// providerToken baut ein unsigniertes Token des Providers, das Gateway liest nur sub und exp
+func providerToken(provider string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + provider + `","exp":4102444800}`))
+	return "eyJhbGciOiJub25lIn0." + payload + "."
+}
+
+func TestRefreshToken_KeepsWorkerID(t *testing.T) {
+	user := &dummyUserClient{Token: providerToken("provider-a")}
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, &dummyJobService{}, user)
+
+	reg, err := svc.Register(context.Background(), ports.RegisterRequest{Zone: "EU"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	resp, err := svc.RefreshToken(context.Background(), ports.RefreshRequest{Key: "key"}, reg.Token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.ID != reg.ID || resp.Token == "" {
+		t.Errorf("expected new token for %s, got %+v", reg.ID, resp)
+	}
+}
+
+func TestRefreshToken_Rejected(t *testing.T) {
+	user := &dummyUserClient{Token: providerToken("provider-a")}
+	svc := newTestWorkerGatewayService(&dummyRegistryService{}, &dummyJobService{}, user)
+	reg, err := svc.Register(context.Background(), ports.RegisterRequest{Zone: "EU"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.RefreshToken(context.Background(), ports.RefreshRequest{Key: "key"}, "invalid"); !errors.Is(err, ports.ErrInvalidWorkerToken) {
+		t.Errorf("expected ErrInvalidWorkerToken, got %v", err)
+	}
+
+	// ein anderer Provider darf den Worker nicht übernehmen
+	user.Token = providerToken("provider-b")
+	if _, err := svc.RefreshToken(context.Background(), ports.RefreshRequest{Key: "other"}, reg.Token); !errors.Is(err, ports.ErrProviderMismatch) {
+		t.Errorf("expected ErrProviderMismatch, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ module github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway
 
 replace github.com/informatik-mannheim/cmg-ss2025/pkg/logging => ../../pkg/logging
 
+replace github.com/informatik-mannheim/cmg-ss2025/pkg/auth => ../../pkg/auth
+
 go 1.24.3
 
 require github.com/informatik-mannheim/cmg-ss2025/pkg/logging v0.0.0-20250703141304-772beaec9a92
@@ -10,12 +12,15 @@ require github.com/informatik-mannheim/cmg-ss2025/pkg/tracing v0.0.0-20250703141
 
 require github.com/informatik-mannheim/cmg-ss2025/pkg/auth v0.0.0-20250703141304-772beaec9a92
 
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/golang-jwt/jwt/v4 v4.5.2
+
 require (
 	github.com/MicahParks/keyfunc v1.9.0 // indirect
 	github.com/cenkalti/backoff/v5 v5.0.2 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
-	github.com/golang-jwt/jwt/v4 v4.5.2 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.1 // indirect
 	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
@@ -2,18 +2,22 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/auth"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/logging"
 	"github.com/informatik-mannheim/cmg-ss2025/pkg/tracing/tracing"
 
+	certificate_authority "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/certificate-authority"
 	client_http "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/client-http"
 	handler_http "github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/adapters/handler-http"
 	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/core"
+	"github.com/informatik-mannheim/cmg-ss2025/services/worker-gateway/ports"
 )
 
 func main() {
@@ -45,21 +49,39 @@ func main() {
 		return
 	}
 
+	// signs the worker tokens issued at registration
+	workerTokenSecret := os.Getenv("WORKER_TOKEN_SECRET")
+	if strings.HasPrefix(workerTokenSecret, "change-me") {
+		logging.Error("WORKER_TOKEN_SECRET is still the example value, set a random secret")
+		return
+	}
+	if err := auth.InitWorkerKey([]byte(workerTokenSecret)); err != nil {
+		logging.Error("Failed to initialize worker tokens: " + err.Error())
+		return
+	}
+
+	// optional mutual TLS for worker daemons, the CA is created on the first start if the files do not exist
+	var ca *certificate_authority.CA
+	var certificateAuthority ports.CertificateAuthority
+	if caCert := os.Getenv("MTLS_CA_CERT"); caCert != "" {
+		ca, err = certificate_authority.LoadOrCreate(caCert, os.Getenv("MTLS_CA_KEY"))
+		if err != nil {
+			logging.Error("Failed to initialize CA: " + err.Error())
+			return
+		}
+		certificateAuthority = ca
+	}
+
 	// init service and handler
 	registryClient := client_http.NewRegistryClient(os.Getenv("WORKER_REGISTRY"))
 	jobClient := client_http.NewJobClient(os.Getenv("JOB_SERVICE"))
 	userClient := client_http.NewUserClient(os.Getenv("USER_MANAGEMENT_SERVICE"))
-	service := core.NewWorkerGatewayService(registryClient, jobClient, userClient)
+	service := core.NewWorkerGatewayService(registryClient, jobClient, userClient, certificateAuthority)
 	handler := handler_http.NewHandler(service)
-
-	// Router (mux)
-	mux := http.NewServeMux()
-	mux.Handle("/worker/heartbeat", auth.AuthMiddleware(http.HandlerFunc(handler.HeartbeatHandler)))
-	mux.Handle("/result", auth.AuthMiddleware(http.HandlerFunc(handler.SubmitResultHandler)))
-	mux.Handle("/register", http.HandlerFunc(handler.RegisterWorkerHandler))
+	handler.RequireClientCert = os.Getenv("MTLS_REQUIRED") == "true"
 
 	// Wrap router with tracing middleware
-	tracingHandler := tracing.Middleware(mux)
+	tracingHandler := tracing.Middleware(handler.Routes())
 
 	// Server-Setup
 	srv := &http.Server{
@@ -67,12 +89,34 @@ func main() {
 		Handler: tracingHandler,
 	}
 
+	// worker daemons connect with their client certificate on a separate port,
+	// internal services keep using plain HTTP
+	var tlsSrv *http.Server
+	if ca != nil {
+		tlsSrv, err = newMTLSServer(ca, tracingHandler)
+		if err != nil {
+			logging.Error("Failed to initialize TLS: " + err.Error())
+			return
+		}
+		go func() {
+			logging.Debug("Worker Gateway listening with mutual TLS", "addr", tlsSrv.Addr)
+			if err := tlsSrv.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				logging.Error("TLS server error", "err", err)
+			}
+		}()
+	}
+
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
 
 		logging.Debug("The service is shutting down...")
+		if tlsSrv != nil {
+			if err := tlsSrv.Shutdown(context.Background()); err != nil {
+				logging.Error("TLS shutdown failed", "err", err)
+			}
+		}
 		if err := srv.Shutdown(context.Background()); err != nil {
 			logging.Error("Shutdown failed", "err", err)
 		}
@@ -83,3 +127,39 @@ func main() {
 		logging.Error("Server error", "err", err)
 	}
 }
+
+// newMTLSServer verifies client certificates against the CA. Registration works without one,
+// so the handlers decide which requests need a certificate. The server certificate is read from
+// TLS_CERT and TLS_KEY, or issued by the CA for the hosts in TLS_HOSTS.
+func newMTLSServer(ca *certificate_authority.CA, handler http.Handler) (*http.Server, error) {
+	port := os.Getenv("MTLS_PORT")
+	if port == "" {
+		port = "8443"
+	}
+
+	var serverCert tls.Certificate
+	var err error
+	if certFile := os.Getenv("TLS_CERT"); certFile != "" {
+		serverCert, err = tls.LoadX509KeyPair(certFile, os.Getenv("TLS_KEY"))
+	} else {
+		hosts := os.Getenv("TLS_HOSTS")
+		if hosts == "" {
+			hosts = "localhost,worker-gateway"
+		}
+		serverCert, err = ca.ServerCertificate(strings.Split(hosts, ","))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Server{
+		Addr:    ":" + port,
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    ca.CertPool(),
+			MinVersion:   tls.VersionTLS12,
+		},
+	}, nil
+}
@@ -2,37 +2,89 @@ package ports
 
 import (
 	"context"
+	"io"
+	"time"
 )
 
 type Api interface {
 	Heartbeat(ctx context.Context, req HeartbeatRequest, token string) ([]Job, error)
-	Result(ctx context.Context, result ResultRequest, token string) error
+	// Result stores the result of a job, which must be assigned to the worker
+	Result(ctx context.Context, workerID string, result ResultRequest, token string) error
+	// Register registers the worker and returns a token bound to its worker ID
 	Register(ctx context.Context, req RegisterRequest) (*RegisterRespose, error)
+	// RefreshToken issues a new token for the worker of a previous, possibly expired, worker token
+	RefreshToken(ctx context.Context, req RefreshRequest, workerToken string) (*RefreshResponse, error)
+	// NextJobs returns the jobs assigned to the worker, waiting up to timeout for an assignment
+	NextJobs(ctx context.Context, workerID string, timeout time.Duration, token string) ([]Job, error)
+	// InputFile returns the content of an uploaded input file of a job, which must be assigned to the worker
+	InputFile(ctx context.Context, workerID string, jobID string, name string, token string) (io.ReadCloser, error)
+	// NotifyAssignment wakes up the worker waiting in NextJobs, called by the job-scheduler
+	NotifyAssignment(ctx context.Context, assignment Assignment) error
+}
+
+// job assignment made by the job-scheduler
+type Assignment struct {
+	JobID    string `json:"jobId"`
+	WorkerID string `json:"workerId"`
 }
 
 // incoming heartbeat from a worker
 type HeartbeatRequest struct {
-	WorkerID string `json:"workerId"`
-	Status   string `json:"status"` // AVAILABLE or RUNNING
+	WorkerID string       `json:"workerId"`
+	Status   string       `json:"status"`             // AVAILABLE or RUNNING
+	Progress *JobProgress `json:"progress,omitempty"` // progress of the running job, if the container reported any
+
+	CachedImages []string `json:"cachedImages,omitempty"` // "name:tag" and "name@digest" of the images the worker has locally
+}
+
+// progress of a running job, relayed to the job service
+type JobProgress struct {
+	JobID   string `json:"jobId"`
+	Percent int    `json:"percent"`
+	Message string `json:"message,omitempty"`
 }
 
 // new worker registration
 type RegisterRequest struct {
 	Key  string `json:"key"`
 	Zone string `json:"zone"`
+	CSR  string `json:"csr,omitempty"` // PEM encoded CSR, a client certificate for mutual TLS is issued if set
+}
+
+// token refresh of a registered worker, the previous worker token is sent as bearer token
+type RefreshRequest struct {
+	Key string `json:"key"`
+}
+
+type RefreshResponse struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
 }
 
 // a finished job result
 type ResultRequest struct {
-	JobID        string `json:"jobId"`
-	Status       string `json:"status"`
-	Result       string `json:"result"`
-	ErrorMessage string `json:"errorMessage,omitempty"`
+	JobID        string        `json:"jobId"`
+	Status       string        `json:"status"`
+	Result       string        `json:"result"`
+	ErrorMessage string        `json:"errorMessage,omitempty"`
+	Energy       *EnergyReport `json:"energy,omitempty"`   // measured by the worker daemon
+	ResultID     string        `json:"resultId,omitempty"` // unique per result, retried deliveries reuse it
+}
+
+// energy used by a job execution
+type EnergyReport struct {
+	EnergyWh        float64 `json:"energyWh"`
+	CPUSeconds      float64 `json:"cpuSeconds"`
+	MemoryGBSeconds float64 `json:"memoryGbSeconds"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Method          string  `json:"method"`
 }
 
 type RegisterRespose struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
-	Zone   string `json:"zone"`
-	Token  string `json:"token"`
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	Zone          string `json:"zone"`
+	Token         string `json:"token"`
+	Certificate   string `json:"certificate,omitempty"`   // PEM encoded client certificate, if a CSR was sent
+	CACertificate string `json:"caCertificate,omitempty"` // PEM encoded CA certificate of the gateway
 }
@@ -0,0 +1,9 @@
+package ports
+
+// CertificateAuthority issues the client certificates worker daemons use for mutual TLS
+type CertificateAuthority interface {
+	// IssueClientCertificate signs the PEM encoded CSR of a worker, the subject is set to the worker ID
+	IssueClientCertificate(csrPEM []byte, workerID string) ([]byte, error)
+	// CertificatePEM returns the CA certificate, which workers use to verify the gateway
+	CertificatePEM() []byte
+}
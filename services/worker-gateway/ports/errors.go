@@ -0,0 +1,32 @@
+package ports
+
+import "errors"
+
+var (
+	ErrWorkerMismatch = errors.New("request is for another worker")
+	ErrJobNotAssigned = errors.New("job is not assigned to the worker")
+	ErrInvalidCSR     = errors.New("invalid certificate signing request")
+
+	ErrInvalidWorkerToken = errors.New("invalid worker token")
+	ErrProviderMismatch   = errors.New("key belongs to another provider")
+
+	// Errors of the calls to the registry, job and user service, mapped from their HTTP status
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrWorkerNotFound     = errors.New("worker not found")
+	ErrJobNotFound        = errors.New("job not found")
+	ErrInputFileNotFound  = errors.New("input file not found")
+	ErrServiceUnavailable = errors.New("downstream service unavailable")
+)
+
+// Claims the gateway adds to worker tokens
+const (
+	// ProviderTokenClaim holds the provider token inside a worker token, it is used for calls to other services
+	ProviderTokenClaim = "provider_token"
+	// ProviderClaim is the provider the worker belongs to, a refresh must use a key of the same provider
+	ProviderClaim = "provider"
+)
+
+// JobSchedulerRole is the role user-management issues to the job-scheduler, only it reports assignments
+const JobSchedulerRole = "job scheduler"
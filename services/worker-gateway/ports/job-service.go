@@ -2,11 +2,17 @@ package ports
 
 import (
 	"context"
+	"io"
 )
 
 type JobService interface {
 	UpdateJob(ctx context.Context, req ResultRequest, token string) error
-	FetchScheduledJobs(ctx context.Context, token string) ([]Job, error)
+	// FetchScheduledJobs returns the scheduled jobs assigned to the worker
+	FetchScheduledJobs(ctx context.Context, workerID string, token string) ([]Job, error)
+	UpdateProgress(ctx context.Context, progress JobProgress, token string) error
+	FetchJob(ctx context.Context, jobID string, token string) (Job, error)
+	// FetchInputFile returns the content of an uploaded input file, the caller closes it
+	FetchInputFile(ctx context.Context, jobID string, name string, token string) (io.ReadCloser, error)
 }
 
 type Job struct {
@@ -14,12 +20,24 @@ type Job struct {
 	WorkerID             string            `json:"workerId"`
 	Image                ContainerImage    `json:"image"`
 	AdjustmentParameters map[string]string `json:"adjustmentParameters"`
+	InputFiles           []InputFile       `json:"inputFiles"`
 	Status               string            `json:"status"`
 	Result               string            `json:"result"`
 	ErrorMessage         string            `json:"errorMessage"`
 }
 
 type ContainerImage struct {
-	Name    string `json:"name" db:"image_name"`
-	Version string `json:"version" db:"image_version"`
+	Name      string `json:"name" db:"image_name"`
+	Version   string `json:"version" db:"image_version"`
+	Digest    string `json:"digest,omitempty" db:"image_digest"`
+	Signature string `json:"signature,omitempty" db:"image_signature"`
+}
+
+// InputFile is passed through to the worker daemon, which stages it into the container.
+// The content of uploaded files is downloaded by the daemon with GET /worker/jobs/{id}/inputs/{name}.
+type InputFile struct {
+	Name     string `json:"name"`
+	URL      string `json:"url,omitempty"`
+	Uploaded bool   `json:"uploaded,omitempty"`
+	Checksum string `json:"checksum"`
 }
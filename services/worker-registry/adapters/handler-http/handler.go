@@ -94,6 +94,13 @@ func (h *Handler) handleUpdateStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if payload.CachedImages != nil {
+		updatedWorker, err = h.service.UpdateCachedImages(id, payload.CachedImages, r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedWorker)
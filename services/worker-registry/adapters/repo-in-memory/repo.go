@@ -58,6 +58,17 @@ func (r *Repo) UpdateWorkerStatus(id string, status ports.WorkerStatus, ctx cont
 	return worker, nil
 }
 
+func (r *Repo) UpdateCachedImages(id string, images []string, ctx context.Context) (ports.Worker, error) {
+	worker, ok := r.workers[id]
+	if !ok {
+		return ports.Worker{}, ports.NewErrWorkerNotFound(id)
+	}
+
+	worker.CachedImages = images
+	r.workers[id] = worker
+	return worker, nil
+}
+
 func isValidStatus(status ports.WorkerStatus) bool {
 	return status == ports.StatusAvailable || status == ports.StatusRunning
 }
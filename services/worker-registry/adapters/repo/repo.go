@@ -3,6 +3,7 @@ package repo_postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/url"
 
@@ -49,7 +50,7 @@ func (r *Repo) GetWorkers(status ports.WorkerStatus, zone string, ctx context.Co
 	message := fmt.Sprintf("GetWorkers called with status=%q zone=%q", status, zone)
 	logging.Debug(message)
 
-	query := `SELECT id, status, zone FROM workers WHERE ($1 = '' OR status = $1) AND ($2 = '' OR zone = $2)`
+	query := `SELECT id, status, zone, cached_images FROM workers WHERE ($1 = '' OR status = $1) AND ($2 = '' OR zone = $2)`
 	logging.Debug("Executing SQL:", query)
 
 	rows, err := r.db.QueryContext(ctx, query, status, zone)
@@ -61,8 +62,8 @@ func (r *Repo) GetWorkers(status ports.WorkerStatus, zone string, ctx context.Co
 
 	var workers []ports.Worker
 	for rows.Next() {
-		var w ports.Worker
-		if err := rows.Scan(&w.Id, &w.Status, &w.Zone); err != nil {
+		w, err := scanWorker(rows)
+		if err != nil {
 			logging.Warn("Failed to scan row:", err)
 			return nil, err
 		}
@@ -77,10 +78,9 @@ func (r *Repo) GetWorkers(status ports.WorkerStatus, zone string, ctx context.Co
 }
 
 func (r *Repo) GetWorkerById(id string, ctx context.Context) (ports.Worker, error) {
-	var w ports.Worker
-	query := `SELECT id, status, zone FROM workers WHERE id = $1`
+	query := `SELECT id, status, zone, cached_images FROM workers WHERE id = $1`
 	logging.Debug("Executing SQL:", query)
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&w.Id, &w.Status, &w.Zone)
+	w, err := scanWorker(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return ports.Worker{}, ports.NewErrWorkerNotFound(id)
 	} else if err != nil {
@@ -120,6 +120,37 @@ func (r *Repo) UpdateWorkerStatus(id string, status ports.WorkerStatus, ctx cont
 	return r.GetWorkerById(id, ctx)
 }
 
+func (r *Repo) UpdateCachedImages(id string, images []string, ctx context.Context) (ports.Worker, error) {
+	imagesJSON, err := json.Marshal(images)
+	if err != nil {
+		return ports.Worker{}, err
+	}
+	query := `UPDATE workers SET cached_images = $1 WHERE id = $2`
+	logging.Debug("Executing SQL:", query)
+	res, err := r.db.ExecContext(ctx, query, imagesJSON, id)
+	if err != nil {
+		return ports.Worker{}, err
+	}
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ports.Worker{}, ports.NewErrWorkerNotFound(id)
+	}
+	return r.GetWorkerById(id, ctx)
+}
+
+// scanWorker reads a worker selected with id, status, zone and cached_images.
+func scanWorker(row interface{ Scan(dest ...any) error }) (ports.Worker, error) {
+	var w ports.Worker
+	var imagesJSON []byte
+	if err := row.Scan(&w.Id, &w.Status, &w.Zone, &imagesJSON); err != nil {
+		return ports.Worker{}, err
+	}
+	if err := json.Unmarshal(imagesJSON, &w.CachedImages); err != nil {
+		return ports.Worker{}, err
+	}
+	return w, nil
+}
+
 func isValidStatus(status ports.WorkerStatus) bool {
 	return status == ports.StatusAvailable || status == ports.StatusRunning
 }
@@ -61,3 +61,7 @@ func (s *WorkerRegistryService) UpdateWorkerStatus(id string, status ports.Worke
 	logging.Debug(resultMessage)
 	return newWorker, nil
 }
+
+func (s *WorkerRegistryService) UpdateCachedImages(id string, images []string, ctx context.Context) (ports.Worker, error) {
+	return s.repo.UpdateCachedImages(id, images, ctx)
+}
@@ -143,3 +143,37 @@ func TestUpdateWorkerStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdateCachedImages(t *testing.T) {
+	repo := repo_in_memory.NewRepo()
+	zoneClient := client.MockZoneClient{}
+	service := NewWorkerRegistryService(repo, zoneClient)
+
+	worker, _ := service.CreateWorker("DE", context.Background())
+
+	t.Run("images are stored with the worker", func(t *testing.T) {
+		_, err := service.UpdateCachedImages(worker.Id, []string{"alpine:latest"}, context.Background())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		stored, _ := service.GetWorkerById(worker.Id, context.Background())
+		if len(stored.CachedImages) != 1 || stored.CachedImages[0] != "alpine:latest" {
+			t.Errorf("expected cached image alpine:latest, got %v", stored.CachedImages)
+		}
+	})
+
+	t.Run("status update keeps the images", func(t *testing.T) {
+		updated, _ := service.UpdateWorkerStatus(worker.Id, "RUNNING", context.Background())
+		if len(updated.CachedImages) != 1 {
+			t.Errorf("expected cached images to be kept, got %v", updated.CachedImages)
+		}
+	})
+
+	t.Run("non-existent worker", func(t *testing.T) {
+		_, err := service.UpdateCachedImages("9999", nil, context.Background())
+		expectedError := "Worker with ID 9999 not found"
+		if err == nil || err.Error() != expectedError {
+			t.Errorf("expected error: %v, got: %v", expectedError, err)
+		}
+	})
+}
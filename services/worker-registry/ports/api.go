@@ -30,4 +30,5 @@ type Api interface {
 	GetWorkerById(id string, ctx context.Context) (Worker, error)
 	CreateWorker(zone string, ctx context.Context) (Worker, error)
 	UpdateWorkerStatus(id string, status WorkerStatus, ctx context.Context) (Worker, error)
+	UpdateCachedImages(id string, images []string, ctx context.Context) (Worker, error)
 }
@@ -8,13 +8,15 @@ const (
 )
 
 type UpdateWorkerStatusRequest struct {
-	Status WorkerStatus `json:"status"`
+	Status       WorkerStatus `json:"status"`
+	CachedImages []string     `json:"cachedImages,omitempty"` // sent with the heartbeats of the worker, unchanged if missing
 }
 
 type Worker struct {
-	Id     string       `json:"id"`
-	Status WorkerStatus `json:"status"`
-	Zone   string       `json:"zone"`
+	Id           string       `json:"id"`
+	Status       WorkerStatus `json:"status"`
+	Zone         string       `json:"zone"`
+	CachedImages []string     `json:"cachedImages,omitempty"` // "name:tag" and "name@digest" of the images the worker has locally
 }
 
 type Zone struct {
@@ -9,4 +9,5 @@ type Repo interface {
 	GetWorkerById(id string, ctx context.Context) (Worker, error)
 	CreateWorker(worker Worker, ctx context.Context) error
 	UpdateWorkerStatus(id string, status WorkerStatus, ctx context.Context) (Worker, error)
+	UpdateCachedImages(id string, images []string, ctx context.Context) (Worker, error)
 }